@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"media/models"
+)
+
+// parseEventTime reads a time filter of the activity feed, either an RFC 3339
+// time or a duration before now such as 1h or 30m
+func parseEventTime(value string, now time.Time) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, true
+	}
+	if d, err := time.ParseDuration(value); err == nil && d > 0 {
+		return now.Add(-d), true
+	}
+	return time.Time{}, false
+}
+
+// getEventsHandler returns the activity feed: events of every movie, newest
+// first, with their movie's title. It filters by type (comma separated),
+// movie_id, since and until, and q, which is matched against the message and
+// the title. Pages are limit events long (1 to 500, 50 by default); pass the
+// returned next_cursor as before to get the next one.
+func (app *App) getEventsHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := models.EventFilter{Query: query.Get("q"), Limit: 50}
+	now := time.Now()
+
+	for _, value := range strings.Split(query.Get("type"), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			filter.Types = append(filter.Types, models.MovieEventType(value))
+		}
+	}
+
+	if value := query.Get("movie_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil || id < 1 {
+			http.Error(w, "Invalid movie ID", http.StatusBadRequest)
+			return
+		}
+		filter.MovieID = id
+	}
+
+	if value := query.Get("since"); value != "" {
+		since, ok := parseEventTime(value, now)
+		if !ok {
+			http.Error(w, "since must be an RFC 3339 time or a duration such as 1h", http.StatusBadRequest)
+			return
+		}
+		filter.Since = since
+	}
+	if value := query.Get("until"); value != "" {
+		until, ok := parseEventTime(value, now)
+		if !ok {
+			http.Error(w, "until must be an RFC 3339 time or a duration such as 1h", http.StatusBadRequest)
+			return
+		}
+		filter.Until = until
+	}
+
+	if value := query.Get("before"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil || id < 1 {
+			http.Error(w, "Invalid cursor", http.StatusBadRequest)
+			return
+		}
+		filter.BeforeID = id
+	}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > 500 {
+			http.Error(w, "limit must be between 1 and 500", http.StatusBadRequest)
+			return
+		}
+		filter.Limit = limit
+	}
+
+	// One extra event tells whether there is another page
+	pageSize := filter.Limit
+	filter.Limit++
+	events, err := app.movieEventRepo.Search(filter)
+	if err != nil {
+		log.Printf("Error searching events: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	page := models.ActivityPage{Events: events}
+	if len(events) > pageSize {
+		page.Events = events[:pageSize]
+		page.NextCursor = page.Events[pageSize-1].ID
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(page); err != nil {
+		log.Printf("Error encoding events: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// compactEventsHandler applies the event retention policy now, reporting how
+// many events were deleted. With vacuum=true the database file is rebuilt
+// afterwards to give the space back, which blocks other writes meanwhile.
+func (app *App) compactEventsHandler(w http.ResponseWriter, r *http.Request) {
+	vacuum := false
+	if value := r.URL.Query().Get("vacuum"); value != "" {
+		var err error
+		vacuum, err = strconv.ParseBool(value)
+		if err != nil {
+			http.Error(w, "vacuum must be true or false", http.StatusBadRequest)
+			return
+		}
+	}
+
+	compaction, err := app.eventRetention.Compact(vacuum)
+	if err != nil {
+		log.Printf("Error compacting events: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(compaction); err != nil {
+		log.Printf("Error encoding event compaction: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
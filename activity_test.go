@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"media/database"
+	"media/models"
+	"media/repository"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetEventsHandler(t *testing.T) {
+	testDB, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	defer func() {
+		if err := testDB.Close(); err != nil {
+			t.Logf("Failed to close test database: %v", err)
+		}
+	}()
+	if err := testDB.InitSchema(); err != nil {
+		t.Fatalf("Failed to initialize test schema: %v", err)
+	}
+
+	movieRepo := repository.NewMovieRepository(testDB)
+	movieEventRepo := repository.NewMovieEventRepository(testDB)
+	app := &App{movieRepo: movieRepo, movieEventRepo: movieEventRepo}
+
+	heat, err := createTestMovie(movieRepo, "Heat")
+	assert.NoError(t, err)
+	ronin, err := createTestMovie(movieRepo, "Ronin")
+	assert.NoError(t, err)
+	assert.NoError(t, movieEventRepo.Create(heat.ID, models.EventSearchStarted, "Searching for Heat", nil))
+	assert.NoError(t, movieEventRepo.Create(ronin.ID, models.EventSearchStarted, "Searching for Ronin", nil))
+	assert.NoError(t, movieEventRepo.Create(heat.ID, models.EventDownloadFailed, "Download failed: stalled", nil))
+	assert.NoError(t, movieEventRepo.Create(ronin.ID, models.EventImported, "Imported Ronin", nil))
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/v1/events", app.getEventsHandler).Methods("GET")
+
+	get := func(url string) (int, models.ActivityPage) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, url, nil))
+		var page models.ActivityPage
+		if rr.Code == http.StatusOK {
+			assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &page))
+		}
+		return rr.Code, page
+	}
+
+	// Newest first, with the movie's title, paged by cursor
+	code, page := get("/api/v1/events?limit=3&since=1h")
+	assert.Equal(t, http.StatusOK, code)
+	if assert.Len(t, page.Events, 3) {
+		assert.Equal(t, 4, page.Events[0].ID)
+		assert.Equal(t, "Ronin", page.Events[0].MovieTitle)
+		assert.Equal(t, 2023, page.Events[0].MovieYear)
+	}
+	assert.Equal(t, 2, page.NextCursor)
+
+	_, page = get("/api/v1/events?limit=3&before=2")
+	if assert.Len(t, page.Events, 1) {
+		assert.Equal(t, "Searching for Heat", page.Events[0].Message)
+	}
+	assert.Zero(t, page.NextCursor)
+
+	// Filters combine
+	_, page = get("/api/v1/events?type=search_started,download_failed&movie_id=1")
+	assert.Len(t, page.Events, 2)
+
+	_, page = get("/api/v1/events?q=ronin")
+	assert.Len(t, page.Events, 2)
+
+	_, page = get("/api/v1/events?q=stalled&type=download_failed")
+	if assert.Len(t, page.Events, 1) {
+		assert.Equal(t, "Heat", page.Events[0].MovieTitle)
+	}
+
+	_, page = get("/api/v1/events?until=2000-01-01T00:00:00Z")
+	assert.NotNil(t, page.Events)
+	assert.Empty(t, page.Events)
+
+	// Events of deleted movies are still listed
+	assert.NoError(t, movieRepo.Delete(ronin.ID))
+	_, page = get("/api/v1/events?movie_id=2")
+	if assert.Len(t, page.Events, 2) {
+		assert.Empty(t, page.Events[0].MovieTitle)
+	}
+
+	for _, url := range []string{
+		"/api/v1/events?movie_id=abc",
+		"/api/v1/events?since=yesterday",
+		"/api/v1/events?before=0",
+		"/api/v1/events?limit=501",
+	} {
+		code, _ := get(url)
+		assert.Equal(t, http.StatusBadRequest, code, url)
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+
+	"media/models"
+	"media/services"
+
+	"github.com/gorilla/mux"
+)
+
+// artworkMaxAge is how long clients may reuse artwork without asking again
+const artworkMaxAge = 7 * 24 * 60 * 60 // seconds
+
+// cacheArtwork downloads a movie's poster and backdrop in the background
+func (app *App) cacheArtwork(movie *models.Movie) {
+	if app.artworkCache == nil || (movie.Poster == "" && movie.Backdrop == "") {
+		return
+	}
+
+	snapshot := *movie
+	cache := func(context.Context) error {
+		if err := app.artworkCache.CacheMovie(&snapshot); err != nil {
+			return fmt.Errorf("failed to cache artwork for '%s': %w", snapshot.Title, err)
+		}
+		return nil
+	}
+	if app.jobManager != nil {
+		app.jobManager.RunTask("artwork cache", cache)
+		return
+	}
+	go func() {
+		if err := cache(context.Background()); err != nil {
+			log.Print(err)
+		}
+	}()
+}
+
+func (app *App) getMoviePosterHandler(w http.ResponseWriter, r *http.Request) {
+	app.serveArtwork(w, r, services.ArtworkPoster)
+}
+
+func (app *App) getMovieBackdropHandler(w http.ResponseWriter, r *http.Request) {
+	app.serveArtwork(w, r, services.ArtworkBackdrop)
+}
+
+// serveArtwork serves the cached artwork of a movie at the width asked for
+// with ?size=, redirecting to TMDB only if it has not been cached
+func (app *App) serveArtwork(w http.ResponseWriter, r *http.Request, kind services.ArtworkKind) {
+	movieID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid movie ID", http.StatusBadRequest)
+		return
+	}
+
+	width, err := services.ParseArtworkSize(r.URL.Query().Get("size"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	movie, err := app.movieRepo.GetByID(movieID)
+	if err != nil {
+		http.Error(w, "Movie not found", http.StatusNotFound)
+		return
+	}
+
+	path := ""
+	if app.artworkCache != nil {
+		path, err = app.artworkCache.Path(movie.ID, kind, width)
+		if err != nil && !errors.Is(err, services.ErrArtworkNotCached) {
+			log.Printf("Error reading cached %s of movie %d: %v", kind, movie.ID, err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if path == "" {
+		remote := movie.Poster
+		if kind == services.ArtworkBackdrop {
+			remote = movie.Backdrop
+		}
+		if remote == "" {
+			http.Error(w, fmt.Sprintf("Movie has no %s", kind), http.StatusNotFound)
+			return
+		}
+		http.Redirect(w, r, remote, http.StatusFound)
+		return
+	}
+
+	file, err := os.Open(path) // #nosec G304 -- path comes from the artwork cache
+	if err != nil {
+		log.Printf("Error opening %s: %v", path, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.Printf("Failed to close %s: %v", path, err)
+		}
+	}()
+
+	info, err := file.Stat()
+	if err != nil {
+		log.Printf("Error reading %s: %v", path, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", artworkMaxAge))
+	w.Header().Set("ETag", fmt.Sprintf(`"%x-%x"`, info.ModTime().UnixNano(), info.Size()))
+	http.ServeContent(w, r, "", info.ModTime(), file)
+}
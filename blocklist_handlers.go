@@ -0,0 +1,41 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+func (app *App) getBlocklistHandler(w http.ResponseWriter, _ *http.Request) {
+	entries, err := app.blocklistRepo.GetAll()
+	if err != nil {
+		log.Printf("Error getting blocklist: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(entries); err != nil {
+		log.Printf("Error encoding blocklist: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (app *App) deleteBlocklistEntryHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid blocklist ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := app.blocklistRepo.Delete(id); err != nil {
+		http.Error(w, "Blocklist entry not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
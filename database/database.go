@@ -41,6 +41,7 @@ func (db *DB) InitSchema() error {
 		genre TEXT,
 		description TEXT,
 		poster TEXT,
+		backdrop TEXT,
 		rating REAL,
 		runtime INTEGER,
 		director TEXT,
@@ -48,6 +49,9 @@ func (db *DB) InitSchema() error {
 		file_size INTEGER,
 		quality TEXT,
 		torrent_hash TEXT,
+		download_name TEXT,
+		indexer TEXT,
+		root_folder_id INTEGER,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);
@@ -65,6 +69,7 @@ func (db *DB) InitSchema() error {
 		type TEXT NOT NULL,
 		message TEXT NOT NULL,
 		details TEXT,
+		details_version INTEGER NOT NULL DEFAULT 0,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		FOREIGN KEY (movie_id) REFERENCES movies (id) ON DELETE CASCADE
 	);
@@ -72,19 +77,154 @@ func (db *DB) InitSchema() error {
 	CREATE INDEX IF NOT EXISTS idx_movie_events_movie_id ON movie_events(movie_id);
 	CREATE INDEX IF NOT EXISTS idx_movie_events_type ON movie_events(type);
 	CREATE INDEX IF NOT EXISTS idx_movie_events_created_at ON movie_events(created_at);
+
+	CREATE TABLE IF NOT EXISTS blocklist (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		movie_id INTEGER NOT NULL,
+		title TEXT NOT NULL,
+		info_hash TEXT,
+		reason TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_blocklist_info_hash ON blocklist(info_hash);
+	CREATE INDEX IF NOT EXISTS idx_blocklist_title ON blocklist(title);
+
+	CREATE TABLE IF NOT EXISTS seeding_policies (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		indexer TEXT NOT NULL UNIQUE,
+		min_ratio REAL NOT NULL DEFAULT 0,
+		min_seed_time INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS root_folders (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		path TEXT NOT NULL UNIQUE,
+		label TEXT,
+		min_free_space INTEGER NOT NULL DEFAULT 0,
+		write_metadata BOOLEAN NOT NULL DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS remote_path_mappings (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		client TEXT NOT NULL,
+		remote_path TEXT NOT NULL,
+		local_path TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (client, remote_path)
+	);
+
+	CREATE TABLE IF NOT EXISTS media_files (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		movie_id INTEGER NOT NULL UNIQUE,
+		path TEXT NOT NULL,
+		size INTEGER NOT NULL DEFAULT 0,
+		container TEXT NOT NULL,
+		duration REAL,
+		video_codec TEXT,
+		width INTEGER,
+		height INTEGER,
+		resolution TEXT,
+		hdr TEXT,
+		audio_tracks TEXT,
+		subtitle_tracks TEXT,
+		probed_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (movie_id) REFERENCES movies (id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS media_servers (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		type TEXT NOT NULL,
+		url TEXT NOT NULL,
+		api_key TEXT,
+		username TEXT,
+		password TEXT,
+		enabled BOOLEAN NOT NULL DEFAULT 1,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS webhooks (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		url TEXT NOT NULL,
+		secret TEXT NOT NULL,
+		events TEXT,
+		enabled BOOLEAN NOT NULL DEFAULT 1,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS webhook_deliveries (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		webhook_id INTEGER NOT NULL,
+		event_id INTEGER,
+		event_type TEXT NOT NULL,
+		payload TEXT NOT NULL,
+		status TEXT NOT NULL DEFAULT 'pending',
+		attempts INTEGER NOT NULL DEFAULT 0,
+		response_status INTEGER,
+		response_body TEXT,
+		error TEXT,
+		next_attempt_at DATETIME,
+		delivered_at DATETIME,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (webhook_id) REFERENCES webhooks (id) ON DELETE CASCADE
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook ON webhook_deliveries(webhook_id, id);
+	CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_pending ON webhook_deliveries(status, next_attempt_at);
+
+	CREATE TABLE IF NOT EXISTS search_runs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		movie_id INTEGER NOT NULL,
+		started_at DATETIME NOT NULL,
+		finished_at DATETIME NOT NULL,
+		duration_ms INTEGER NOT NULL,
+		queries INTEGER NOT NULL DEFAULT 0,
+		requests INTEGER NOT NULL DEFAULT 0,
+		results INTEGER NOT NULL DEFAULT 0,
+		found INTEGER NOT NULL DEFAULT 0
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_search_runs_movie_id ON search_runs(movie_id);
+	CREATE INDEX IF NOT EXISTS idx_search_runs_started_at ON search_runs(started_at);
+
+	CREATE TABLE IF NOT EXISTS search_indexer_timings (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		search_run_id INTEGER NOT NULL,
+		indexer TEXT NOT NULL,
+		requests INTEGER NOT NULL DEFAULT 0,
+		results INTEGER NOT NULL DEFAULT 0,
+		errors INTEGER NOT NULL DEFAULT 0,
+		elapsed_ms INTEGER NOT NULL DEFAULT 0,
+		FOREIGN KEY (search_run_id) REFERENCES search_runs (id) ON DELETE CASCADE
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_search_indexer_timings_run ON search_indexer_timings(search_run_id);
 	`
 
 	if _, err := db.Exec(schema); err != nil {
 		return fmt.Errorf("failed to create schema: %w", err)
 	}
 
-	// Add migration for existing databases
-	migration := `
-	ALTER TABLE movies ADD COLUMN torrent_hash TEXT;
-	`
-	
-	// Try to add the column, ignore error if it already exists
-	db.Exec(migration)
+	// Add migrations for existing databases
+	migrations := []string{
+		`ALTER TABLE movies ADD COLUMN torrent_hash TEXT;`,
+		`ALTER TABLE movies ADD COLUMN download_name TEXT;`,
+		`ALTER TABLE movies ADD COLUMN indexer TEXT;`,
+		`ALTER TABLE movies ADD COLUMN root_folder_id INTEGER;`,
+		`ALTER TABLE root_folders ADD COLUMN write_metadata BOOLEAN NOT NULL DEFAULT 0;`,
+		`ALTER TABLE movies ADD COLUMN backdrop TEXT;`,
+		`ALTER TABLE movie_events ADD COLUMN details_version INTEGER NOT NULL DEFAULT 0;`,
+	}
+
+	// Try to add each column, ignore error if it already exists
+	for _, migration := range migrations {
+		db.Exec(migration)
+	}
 
 	log.Println("Database schema initialized")
 	return nil
@@ -0,0 +1,176 @@
+// Package events provides the in-process bus movie events are published on.
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"media/models"
+)
+
+// queueSize is how many events each worker holds before Publish waits
+const queueSize = 256
+
+// Handler reacts to a published event. Handlers run in the order they were
+// subscribed, so one subscribed early (such as persistence, which sets the
+// event's ID) can fill in the event for those after it. Handlers must not
+// publish events themselves, as they would wait on their own queue.
+type Handler func(event *models.MovieEvent) error
+
+// subscriber is a named handler
+type subscriber struct {
+	name    string
+	handler Handler
+}
+
+// Bus delivers movie events to its subscribers. Events of the same movie are
+// delivered one at a time in the order they were published; events of
+// different movies may be delivered concurrently. A failing or panicking
+// subscriber is logged and does not affect the others.
+type Bus struct {
+	subMu       sync.RWMutex
+	subscribers []subscriber
+
+	// queueMu guards closed; Close waits for the publishers counted in
+	// sending before it closes the queues they send to
+	queueMu sync.RWMutex
+	queues  []chan *models.MovieEvent
+	closed  bool
+	sending sync.WaitGroup
+	wg      sync.WaitGroup
+}
+
+// NewBus creates a bus delivering events on the given number of workers. With
+// no workers, events are delivered on the publisher's goroutine before
+// Publish returns.
+func NewBus(workers int) *Bus {
+	b := &Bus{}
+	for i := 0; i < workers; i++ {
+		queue := make(chan *models.MovieEvent, queueSize)
+		b.queues = append(b.queues, queue)
+		b.wg.Add(1)
+		go b.work(queue)
+	}
+	return b
+}
+
+// Subscribe adds a handler for every event published from now on
+func (b *Bus) Subscribe(name string, handler Handler) {
+	b.subMu.Lock()
+	defer b.subMu.Unlock()
+	b.subscribers = append(b.subscribers, subscriber{name: name, handler: handler})
+}
+
+// Publish sends an event about a movie to the subscribers. Details are
+// encoded as JSON, tagged with the version of their schema. With workers,
+// Publish returns before the subscribers run, so the event may not be stored
+// yet when it does. A nil bus discards the event.
+func (b *Bus) Publish(movieID int, eventType models.MovieEventType, message string, details models.EventDetails) {
+	if b == nil {
+		return
+	}
+
+	event := &models.MovieEvent{
+		MovieID:   movieID,
+		Type:      eventType,
+		Message:   message,
+		CreatedAt: time.Now().UTC(),
+	}
+	if details != nil {
+		data, err := json.Marshal(details)
+		if err != nil {
+			log.Printf("Failed to encode details of %s event: %v", eventType, err)
+		} else {
+			event.Details = data
+			event.DetailsVersion = models.EventDetailsVersion
+		}
+	}
+
+	if b.enqueue(event) {
+		return
+	}
+	b.deliver(event)
+}
+
+// enqueue hands an event to its worker, waiting while the worker's queue is
+// full. It reports false when the bus has no workers or is closed, in which
+// case the publisher delivers the event itself. queueMu is not held while
+// waiting, so a stalled subscriber slows publishers down without keeping
+// Close from starting.
+func (b *Bus) enqueue(event *models.MovieEvent) bool {
+	b.queueMu.RLock()
+	if b.closed || len(b.queues) == 0 {
+		b.queueMu.RUnlock()
+		return false
+	}
+	b.sending.Add(1)
+	b.queueMu.RUnlock()
+	defer b.sending.Done()
+
+	b.queues[shard(event.MovieID, len(b.queues))] <- event
+	return true
+}
+
+// Close stops the workers once the events already published are delivered.
+// Events published afterwards are delivered on the publisher's goroutine.
+func (b *Bus) Close() {
+	b.queueMu.Lock()
+	if b.closed {
+		b.queueMu.Unlock()
+		return
+	}
+	b.closed = true
+	b.queueMu.Unlock()
+
+	// Publishers waiting on a full queue get in before it is closed
+	b.sending.Wait()
+	for _, queue := range b.queues {
+		close(queue)
+	}
+
+	b.wg.Wait()
+}
+
+// work delivers the events of one queue in order
+func (b *Bus) work(queue chan *models.MovieEvent) {
+	defer b.wg.Done()
+	for event := range queue {
+		b.deliver(event)
+	}
+}
+
+// deliver hands an event to every subscriber in turn
+func (b *Bus) deliver(event *models.MovieEvent) {
+	b.subMu.RLock()
+	subscribers := b.subscribers
+	b.subMu.RUnlock()
+
+	for _, sub := range subscribers {
+		if err := call(sub.handler, event); err != nil {
+			log.Printf("Event subscriber %s failed on %s event for movie %d: %v",
+				sub.name, event.Type, event.MovieID, err)
+		}
+	}
+}
+
+// call runs a handler, turning a panic into an error
+func call(handler Handler, event *models.MovieEvent) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v\n%s", r, debug.Stack())
+		}
+	}()
+	return handler(event)
+}
+
+// shard picks the worker for a movie, so its events stay in order
+func shard(movieID, workers int) int {
+	if movieID < 0 {
+		movieID = -movieID
+	}
+	return movieID % workers
+}
@@ -0,0 +1,182 @@
+package events
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"media/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBus_DeliversInOrderPerMovie(t *testing.T) {
+	bus := NewBus(3)
+
+	var mu sync.Mutex
+	received := make(map[int][]string)
+	bus.Subscribe("recorder", func(event *models.MovieEvent) error {
+		mu.Lock()
+		defer mu.Unlock()
+		received[event.MovieID] = append(received[event.MovieID], event.Message)
+		return nil
+	})
+
+	var expected []string
+	for i := 0; i < 50; i++ {
+		message := string(rune('a' + i%26))
+		expected = append(expected, message)
+		for movieID := 1; movieID <= 4; movieID++ {
+			bus.Publish(movieID, models.EventSearchStarted, message, nil)
+		}
+	}
+	bus.Close()
+
+	for movieID := 1; movieID <= 4; movieID++ {
+		assert.Equal(t, expected, received[movieID], "movie %d", movieID)
+	}
+}
+
+func TestBus_IsolatesFailingSubscribers(t *testing.T) {
+	bus := NewBus(0)
+
+	var seen []*models.MovieEvent
+	bus.Subscribe("first", func(event *models.MovieEvent) error {
+		event.ID = 42
+		return errors.New("database is locked")
+	})
+	bus.Subscribe("panicking", func(event *models.MovieEvent) error {
+		panic("boom")
+	})
+	bus.Subscribe("last", func(event *models.MovieEvent) error {
+		seen = append(seen, event)
+		return nil
+	})
+
+	bus.Publish(7, models.EventImported, "Imported Heat", models.ImportDetails{Path: "/movies/Heat.mkv", Mode: "hardlink"})
+
+	// Delivery is synchronous without workers, and later subscribers see what
+	// earlier ones filled in
+	if assert.Len(t, seen, 1) {
+		assert.Equal(t, 42, seen[0].ID)
+		assert.Equal(t, 7, seen[0].MovieID)
+		assert.Equal(t, models.EventImported, seen[0].Type)
+		assert.JSONEq(t, `{"path":"/movies/Heat.mkv","mode":"hardlink"}`, string(seen[0].Details))
+		assert.Equal(t, models.EventDetailsVersion, seen[0].DetailsVersion)
+		assert.False(t, seen[0].CreatedAt.IsZero())
+	}
+}
+
+// stallingBus returns a bus with one worker, stalled on its first event until
+// release is closed, and the messages its subscriber received
+func stallingBus(t *testing.T) (bus *Bus, release chan struct{}, received func() []string) {
+	bus = NewBus(1)
+	stalled := make(chan struct{})
+	release = make(chan struct{})
+	var mu sync.Mutex
+	var messages []string
+	bus.Subscribe("slow", func(event *models.MovieEvent) error {
+		if event.Message == "stall" {
+			close(stalled)
+			<-release
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		messages = append(messages, event.Message)
+		return nil
+	})
+
+	bus.Publish(1, models.EventSearchStarted, "stall", nil)
+	<-stalled
+	return bus, release, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), messages...)
+	}
+}
+
+// publishAll publishes messages for a movie on another goroutine, and closes
+// the returned channel once they are all published
+func publishAll(bus *Bus, messages []string) chan struct{} {
+	published := make(chan struct{})
+	go func() {
+		for _, message := range messages {
+			bus.Publish(1, models.EventSearchStarted, message, nil)
+		}
+		close(published)
+	}()
+	return published
+}
+
+// waitFor fails the test unless a channel is closed within a few seconds
+func waitFor(t *testing.T, done chan struct{}, what string) {
+	t.Helper()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("%s did not return", what)
+	}
+}
+
+func TestBus_FullQueueKeepsOrder(t *testing.T) {
+	bus, release, received := stallingBus(t)
+
+	// The worker's queue fills up and the publisher waits for room
+	var messages []string
+	for i := 0; i < queueSize+10; i++ {
+		messages = append(messages, fmt.Sprint(i))
+	}
+	published := publishAll(bus, messages)
+	select {
+	case <-published:
+		t.Fatal("Publish did not wait for room in the queue")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	waitFor(t, published, "Publish")
+	bus.Close()
+	assert.Equal(t, append([]string{"stall"}, messages...), received())
+}
+
+func TestBus_CloseWithWaitingPublisher(t *testing.T) {
+	bus, release, received := stallingBus(t)
+
+	var messages []string
+	for i := 0; i < queueSize+10; i++ {
+		messages = append(messages, fmt.Sprint(i))
+	}
+	published := publishAll(bus, messages)
+
+	// Closing does not wait on the publisher while it waits on the queue
+	closed := make(chan struct{})
+	go func() {
+		bus.Close()
+		close(closed)
+	}()
+	close(release)
+	waitFor(t, published, "Publish")
+	waitFor(t, closed, "Close")
+	assert.Len(t, received(), queueSize+11)
+}
+
+func TestBus_CloseAndNil(t *testing.T) {
+	bus := NewBus(2)
+	delivered := 0
+	bus.Subscribe("counter", func(*models.MovieEvent) error {
+		delivered++
+		return nil
+	})
+
+	bus.Close()
+	bus.Close()
+
+	// Events published after closing are still delivered
+	bus.Publish(1, models.EventSearchStarted, "Searching", nil)
+	assert.Equal(t, 1, delivered)
+
+	var none *Bus
+	none.Publish(1, models.EventSearchStarted, "Searching", nil)
+}
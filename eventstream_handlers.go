@@ -0,0 +1,248 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"media/models"
+)
+
+const (
+	// streamBuffer is how many messages a slow client may fall behind before
+	// it is disconnected, to resume from its Last-Event-ID
+	streamBuffer = 64
+	// streamReplayLimit caps how many missed events are replayed on reconnect
+	streamReplayLimit = 500
+	// streamHeartbeat keeps idle connections from being closed by proxies
+	streamHeartbeat = 30 * time.Second
+)
+
+// streamMessage is a server-sent event. Stored movie events carry their ID so
+// clients can resume after them; status changes are not stored and have none.
+type streamMessage struct {
+	id      int
+	event   string
+	movieID int
+	data    []byte
+}
+
+// streamStatusChange is the data of a status event
+type streamStatusChange struct {
+	MovieID   int                `json:"movie_id"`
+	Title     string             `json:"title"`
+	OldStatus models.MediaStatus `json:"old_status,omitempty"`
+	NewStatus models.MediaStatus `json:"new_status"`
+	UpdatedAt time.Time          `json:"updated_at"`
+}
+
+// streamClient is a connected event stream, optionally limited to one movie
+type streamClient struct {
+	movieID  int
+	messages chan streamMessage
+}
+
+// eventHub fans movie events and status changes out to connected streams
+type eventHub struct {
+	mu      sync.Mutex
+	clients map[*streamClient]struct{}
+}
+
+func newEventHub() *eventHub {
+	return &eventHub{clients: make(map[*streamClient]struct{})}
+}
+
+// subscribe connects a stream, receiving only one movie's messages if movieID is set
+func (h *eventHub) subscribe(movieID int) *streamClient {
+	client := &streamClient{movieID: movieID, messages: make(chan streamMessage, streamBuffer)}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.clients[client] = struct{}{}
+	return client
+}
+
+// unsubscribe disconnects a stream
+func (h *eventHub) unsubscribe(client *streamClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.clients[client]; ok {
+		delete(h.clients, client)
+		close(client.messages)
+	}
+}
+
+// publish hands a message to every interested stream. Streams whose buffer
+// is full are dropped rather than holding up the writer of the event.
+func (h *eventHub) publish(message streamMessage) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for client := range h.clients {
+		if client.movieID != 0 && client.movieID != message.movieID {
+			continue
+		}
+		select {
+		case client.messages <- message:
+		default:
+			delete(h.clients, client)
+			close(client.messages)
+		}
+	}
+}
+
+// publishEvent is the event bus subscriber streaming stored movie events
+func (h *eventHub) publishEvent(event *models.MovieEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode movie event %d: %w", event.ID, err)
+	}
+	h.publish(streamMessage{id: event.ID, event: "movie_event", movieID: event.MovieID, data: data})
+	return nil
+}
+
+// publishStatus is a movie status listener
+func (h *eventHub) publishStatus(movie models.Movie, oldStatus models.MediaStatus) {
+	data, err := json.Marshal(streamStatusChange{
+		MovieID:   movie.ID,
+		Title:     movie.Title,
+		OldStatus: oldStatus,
+		NewStatus: movie.Status,
+		UpdatedAt: movie.UpdatedAt,
+	})
+	if err != nil {
+		log.Printf("Failed to encode status of movie %d for streaming: %v", movie.ID, err)
+		return
+	}
+	h.publish(streamMessage{event: "status", movieID: movie.ID, data: data})
+}
+
+// writeStreamMessage writes a message in the text/event-stream format
+func writeStreamMessage(w http.ResponseWriter, message streamMessage) error {
+	if message.id != 0 {
+		if _, err := fmt.Fprintf(w, "id: %d\n", message.id); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", message.event, message.data)
+	return err
+}
+
+// streamEventsHandler pushes movie events and status changes as server-sent
+// events. A movie_id query parameter limits the stream to one movie. Clients
+// that reconnect with Last-Event-ID (or last_event_id) first get the events
+// they missed.
+func (app *App) streamEventsHandler(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	query := r.URL.Query()
+	movieID := 0
+	if value := query.Get("movie_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil || id < 1 {
+			http.Error(w, "Invalid movie ID", http.StatusBadRequest)
+			return
+		}
+		movieID = id
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = query.Get("last_event_id")
+	}
+	afterID := 0
+	if lastEventID != "" {
+		id, err := strconv.Atoi(lastEventID)
+		if err != nil || id < 0 {
+			http.Error(w, "Invalid Last-Event-ID", http.StatusBadRequest)
+			return
+		}
+		afterID = id
+	}
+
+	// Subscribe before replaying, so nothing written in between is missed
+	client := app.eventHub.subscribe(movieID)
+	defer app.eventHub.unsubscribe(client)
+
+	var missed []models.MovieEvent
+	if afterID > 0 {
+		var err error
+		missed, err = app.movieEventRepo.GetAfter(afterID, movieID, streamReplayLimit)
+		if err != nil {
+			log.Printf("Error getting missed events: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	// The stream outlives the server's write timeout
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		log.Printf("Failed to clear write deadline for event stream: %v", err)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if _, err := fmt.Fprintf(w, "retry: 3000\n\n"); err != nil {
+		return
+	}
+
+	for _, event := range missed {
+		data, err := json.Marshal(event)
+		if err != nil {
+			continue
+		}
+		if err := writeStreamMessage(w, streamMessage{id: event.ID, event: "movie_event", data: data}); err != nil {
+			return
+		}
+		afterID = event.ID
+	}
+
+	// The current status gives a client watching one movie its starting point
+	if movieID != 0 {
+		if movie, err := app.movieRepo.GetByID(movieID); err == nil {
+			if data, err := json.Marshal(streamStatusChange{MovieID: movie.ID, Title: movie.Title,
+				NewStatus: movie.Status, UpdatedAt: movie.UpdatedAt}); err == nil {
+				if err := writeStreamMessage(w, streamMessage{event: "status", data: data}); err != nil {
+					return
+				}
+			}
+		}
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case message, ok := <-client.messages:
+			if !ok {
+				// Fell too far behind; the client reconnects and resumes
+				return
+			}
+			if message.id != 0 && message.id <= afterID {
+				continue // Already replayed
+			}
+			if err := writeStreamMessage(w, message); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"media/database"
+	"media/events"
+	"media/models"
+	"media/repository"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+// readStreamEvent reads the next server-sent event, skipping comments and
+// retry hints, as "id event data"
+func readStreamEvent(t *testing.T, reader *bufio.Reader) string {
+	var fields []string
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Failed to read event stream: %v", err)
+		}
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case line == "" && len(fields) > 0:
+			return strings.Join(fields, " ")
+		case strings.HasPrefix(line, "id: "), strings.HasPrefix(line, "event: "), strings.HasPrefix(line, "data: "):
+			fields = append(fields, line[strings.Index(line, " ")+1:])
+		}
+	}
+}
+
+func TestStreamEventsHandler(t *testing.T) {
+	testDB, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	defer func() {
+		if err := testDB.Close(); err != nil {
+			t.Logf("Failed to close test database: %v", err)
+		}
+	}()
+	// Every connection to :memory: is a new database, so share one
+	testDB.SetMaxOpenConns(1)
+	if err := testDB.InitSchema(); err != nil {
+		t.Fatalf("Failed to initialize test schema: %v", err)
+	}
+
+	movieRepo := repository.NewMovieRepository(testDB)
+	movieEventRepo := repository.NewMovieEventRepository(testDB)
+	hub := newEventHub()
+	bus := events.NewBus(0)
+	bus.Subscribe("persistence", movieEventRepo.Save)
+	bus.Subscribe("event stream", hub.publishEvent)
+	movieRepo.AddStatusListener(hub.publishStatus)
+	app := &App{movieRepo: movieRepo, movieEventRepo: movieEventRepo, eventHub: hub, bus: bus}
+
+	heat, err := createTestMovie(movieRepo, "Heat")
+	assert.NoError(t, err)
+	ronin, err := createTestMovie(movieRepo, "Ronin")
+	assert.NoError(t, err)
+	bus.Publish(heat.ID, models.EventSearchStarted, "Searching for Heat", nil)
+	bus.Publish(ronin.ID, models.EventSearchStarted, "Searching for Ronin", nil)
+	bus.Publish(heat.ID, models.EventSearchFailed, "Nothing found for Heat", nil)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/v1/events/stream", app.streamEventsHandler).Methods("GET")
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		server.URL+"/api/v1/events/stream?movie_id=1", nil)
+	assert.NoError(t, err)
+	req.Header.Set("Last-Event-ID", "1")
+
+	resp, err := http.DefaultClient.Do(req)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			t.Logf("Failed to close response body: %v", err)
+		}
+	}()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	reader := bufio.NewReader(resp.Body)
+
+	// Missed events of the movie are replayed, then its current status is sent
+	assert.True(t, strings.HasPrefix(readStreamEvent(t, reader), `3 movie_event {"id":3,"movie_id":1,"type":"search_failed"`))
+	assert.True(t, strings.HasPrefix(readStreamEvent(t, reader), `status {"movie_id":1,"title":"Heat","new_status":"wanted"`))
+
+	// Live events and status changes follow, without other movies'
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		time.Sleep(50 * time.Millisecond)
+		bus.Publish(ronin.ID, models.EventSearchFailed, "Nothing found for Ronin", nil)
+		heat.Status = models.StatusSearching
+		assert.NoError(t, movieRepo.Update(heat))
+		bus.Publish(heat.ID, models.EventSearchStarted, "Searching for Heat again", nil)
+	}()
+
+	assert.True(t, strings.HasPrefix(readStreamEvent(t, reader),
+		`status {"movie_id":1,"title":"Heat","old_status":"wanted","new_status":"searching"`))
+	assert.True(t, strings.HasPrefix(readStreamEvent(t, reader), `5 movie_event {"id":5,"movie_id":1,"type":"search_started"`))
+	<-done
+}
+
+func TestEventHub_DropsSlowClients(t *testing.T) {
+	hub := newEventHub()
+	slow := hub.subscribe(0)
+	other := hub.subscribe(2)
+
+	for i := 1; i <= streamBuffer+1; i++ {
+		hub.publish(streamMessage{id: i, event: "movie_event", movieID: 1, data: []byte("{}")})
+	}
+
+	received := 0
+	for range slow.messages {
+		received++
+	}
+	assert.Equal(t, streamBuffer, received)
+	assert.Len(t, other.messages, 0)
+
+	// Unsubscribing a dropped client is harmless
+	hub.unsubscribe(slow)
+	hub.unsubscribe(other)
+}
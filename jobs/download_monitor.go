@@ -0,0 +1,99 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"media/models"
+	"media/repository"
+	"media/services"
+)
+
+// DownloadMonitorJob watches movies that are downloading and hands finished
+// downloads to the importer
+type DownloadMonitorJob struct {
+	movieRepo          *repository.MovieRepository
+	qbittorrentService *services.QBittorrentService
+	blackholeService   *services.BlackholeService
+	importer           *Importer
+	pathMapper         *remotePathMapper
+}
+
+// NewDownloadMonitorJob creates a new download monitor. Either download
+// client may be nil.
+func NewDownloadMonitorJob(movieRepo *repository.MovieRepository, qbittorrentService *services.QBittorrentService,
+	blackholeService *services.BlackholeService, importer *Importer) *DownloadMonitorJob {
+	return &DownloadMonitorJob{
+		movieRepo:          movieRepo,
+		qbittorrentService: qbittorrentService,
+		blackholeService:   blackholeService,
+		importer:           importer,
+	}
+}
+
+// SetRemotePathMappings configures how qBittorrent's paths translate to local ones
+func (j *DownloadMonitorJob) SetRemotePathMappings(repo *repository.RemotePathMappingRepository) {
+	j.pathMapper = newRemotePathMapper(repo, models.DownloadClientQBittorrent)
+}
+
+// CheckDownloads imports every downloading movie whose download has finished
+func (j *DownloadMonitorJob) CheckDownloads(ctx context.Context) error {
+	movies, err := j.movieRepo.GetByStatus(models.StatusDownloading)
+	if err != nil {
+		return fmt.Errorf("failed to get downloading movies: %w", err)
+	}
+	if len(movies) == 0 {
+		return nil
+	}
+
+	var torrents map[string]services.QBTorrent
+	if j.qbittorrentService != nil {
+		list, err := j.qbittorrentService.GetTorrents(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get torrents: %w", err)
+		}
+		torrents = make(map[string]services.QBTorrent, len(list))
+		for _, torrent := range list {
+			torrents[strings.ToLower(torrent.Hash)] = torrent
+		}
+	}
+
+	for i := range movies {
+		movie := &movies[i]
+
+		path, err := j.completedPath(movie, torrents)
+		if err != nil {
+			log.Printf("Failed to check download for '%s': %v", movie.Title, err)
+			continue
+		}
+		if path == "" {
+			continue
+		}
+
+		if err := j.importer.Import(movie, path); err != nil {
+			log.Printf("Import failed: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// completedPath returns where the finished download for a movie lives, or an
+// empty string if it is still in progress
+func (j *DownloadMonitorJob) completedPath(movie *models.Movie, torrents map[string]services.QBTorrent) (string, error) {
+	if j.qbittorrentService != nil && movie.TorrentHash != "" {
+		torrent, ok := torrents[strings.ToLower(movie.TorrentHash)]
+		if !ok || torrent.Progress < 1 {
+			return "", nil
+		}
+		return j.pathMapper.localPath(torrent.DownloadPath())
+	}
+
+	if j.blackholeService != nil && movie.DownloadName != "" {
+		return j.blackholeService.FindCompleted(movie.DownloadName)
+	}
+
+	return "", nil
+}
@@ -0,0 +1,98 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"media/models"
+	"media/repository"
+)
+
+// DefaultEventRetention keeps three months of events, at most 500 per movie,
+// and always the last 3 of each type
+func DefaultEventRetention() models.EventRetention {
+	return models.EventRetention{
+		MaxAge:      90 * 24 * time.Hour,
+		MaxPerMovie: 500,
+		KeepPerType: 3,
+	}
+}
+
+// EventRetentionJob deletes movie events the retention policy no longer keeps,
+// so searches that run every half hour do not grow movie_events without bound
+type EventRetentionJob struct {
+	movieEventRepo *repository.MovieEventRepository
+	searchRunRepo  *repository.SearchRunRepository
+	retention      models.EventRetention
+	// mu keeps the scheduled and on-demand compactions from overlapping
+	mu sync.Mutex
+}
+
+// NewEventRetentionJob creates a new event retention job
+func NewEventRetentionJob(movieEventRepo *repository.MovieEventRepository, retention models.EventRetention) *EventRetentionJob {
+	return &EventRetentionJob{
+		movieEventRepo: movieEventRepo,
+		retention:      retention,
+	}
+}
+
+// SetSearchRunRepository configures the search timings that are deleted along
+// with events past the max age
+func (j *EventRetentionJob) SetSearchRunRepository(searchRunRepo *repository.SearchRunRepository) {
+	j.searchRunRepo = searchRunRepo
+}
+
+// Enforce applies the retention policy, for running as a periodic task
+func (j *EventRetentionJob) Enforce(_ context.Context) error {
+	compaction, err := j.Compact(false)
+	if err != nil {
+		return err
+	}
+	if compaction.Deleted > 0 {
+		log.Printf("Event retention deleted %d events (%d by age, %d over the per-movie limit)",
+			compaction.Deleted, compaction.DeletedByAge, compaction.DeletedOverLimit)
+	}
+	return nil
+}
+
+// Compact applies the retention policy, optionally vacuuming the database
+// afterwards to shrink its file
+func (j *EventRetentionJob) Compact(vacuum bool) (*models.EventCompaction, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	compaction := &models.EventCompaction{}
+	var err error
+
+	if j.retention.MaxAge > 0 {
+		compaction.DeletedByAge, err = j.movieEventRepo.DeleteOldEvents(j.retention.MaxAge, j.retention.KeepPerType)
+		if err != nil {
+			return nil, err
+		}
+		if j.searchRunRepo != nil {
+			compaction.SearchRunsDeleted, err = j.searchRunRepo.DeleteOlderThan(j.retention.MaxAge)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	if j.retention.MaxPerMovie > 0 {
+		compaction.DeletedOverLimit, err = j.movieEventRepo.DeleteExcessEvents(j.retention.MaxPerMovie, j.retention.KeepPerType)
+		if err != nil {
+			return nil, err
+		}
+	}
+	compaction.Deleted = compaction.DeletedByAge + compaction.DeletedOverLimit
+
+	if vacuum {
+		if err := j.movieEventRepo.Vacuum(); err != nil {
+			return nil, fmt.Errorf("deleted %d events but %w", compaction.Deleted, err)
+		}
+		compaction.Vacuumed = true
+	}
+
+	return compaction, nil
+}
@@ -0,0 +1,81 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"media/database"
+	"media/models"
+	"media/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventRetentionJob_Compact(t *testing.T) {
+	testDB, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	defer func() {
+		if err := testDB.Close(); err != nil {
+			t.Logf("Failed to close test database: %v", err)
+		}
+	}()
+	testDB.SetMaxOpenConns(1)
+	if err := testDB.InitSchema(); err != nil {
+		t.Fatalf("Failed to initialize test schema: %v", err)
+	}
+
+	movieEventRepo := repository.NewMovieEventRepository(testDB)
+	save := func(movieID int, eventType models.MovieEventType, age time.Duration) int {
+		event := &models.MovieEvent{MovieID: movieID, Type: eventType, Message: string(eventType),
+			CreatedAt: time.Now().Add(-age)}
+		assert.NoError(t, movieEventRepo.Save(event))
+		return event.ID
+	}
+
+	// Movie 1 was imported long ago and has been searched a lot since
+	imported := save(1, models.EventImported, 200*24*time.Hour)
+	save(1, models.EventSearchStarted, 150*24*time.Hour)
+	for i := 0; i < 6; i++ {
+		save(1, models.EventSearchStarted, time.Duration(6-i)*time.Hour)
+	}
+	// Movie 2 only has recent events
+	save(2, models.EventSearchStarted, time.Hour)
+
+	job := NewEventRetentionJob(movieEventRepo, models.EventRetention{
+		MaxAge:      90 * 24 * time.Hour,
+		MaxPerMovie: 4,
+		KeepPerType: 1,
+	})
+
+	compaction, err := job.Compact(true)
+	assert.NoError(t, err)
+	// The old search goes by age, then the two oldest searches over the limit.
+	// The old import is the last of its type, so it stays.
+	assert.Equal(t, int64(1), compaction.DeletedByAge)
+	assert.Equal(t, int64(2), compaction.DeletedOverLimit)
+	assert.Equal(t, int64(3), compaction.Deleted)
+	assert.True(t, compaction.Vacuumed)
+
+	remaining, err := movieEventRepo.GetByMovieID(1)
+	assert.NoError(t, err)
+	assert.Len(t, remaining, 5)
+	ids := make([]int, len(remaining))
+	for i, event := range remaining {
+		ids[i] = event.ID
+	}
+	assert.Contains(t, ids, imported)
+
+	others, err := movieEventRepo.GetByMovieID(2)
+	assert.NoError(t, err)
+	assert.Len(t, others, 1)
+
+	// Nothing is left to delete
+	assert.NoError(t, job.Enforce(context.Background()))
+	compaction, err = job.Compact(false)
+	assert.NoError(t, err)
+	assert.Zero(t, compaction.Deleted)
+	assert.False(t, compaction.Vacuumed)
+}
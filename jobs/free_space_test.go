@@ -0,0 +1,40 @@
+package jobs
+
+import (
+	"testing"
+
+	"media/database"
+	"media/models"
+	"media/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTorrentSearchJob_CheckFreeSpace(t *testing.T) {
+	testDB, err := database.NewDB(":memory:")
+	assert.NoError(t, err)
+	defer func() { _ = testDB.Close() }()
+	assert.NoError(t, testDB.InitSchema())
+
+	rootFolderRepo := repository.NewRootFolderRepository(testDB)
+	job := NewTorrentSearchJob(repository.NewMovieRepository(testDB), nil, nil, nil)
+
+	movie := &models.Movie{Title: "Test Movie"}
+	release := TorrentResult{Title: "Test.Movie.2023.1080p", Size: 8 << 30}
+
+	// Without root folders nothing is checked
+	assert.NoError(t, job.checkFreeSpace(movie, release))
+
+	job.SetRootFolderRepository(rootFolderRepo)
+	folder := &models.RootFolder{Path: t.TempDir()}
+	assert.NoError(t, rootFolderRepo.Create(folder))
+
+	assert.NoError(t, job.checkFreeSpace(movie, TorrentResult{Title: "Tiny", Size: 1}))
+	assert.Zero(t, movie.RootFolderID, "checking free space does not assign a root folder")
+
+	// A release that would eat into the reserved space is refused
+	reserved := &models.RootFolder{Path: t.TempDir(), MinFreeSpace: 1 << 62}
+	assert.NoError(t, rootFolderRepo.Create(reserved))
+	movie.RootFolderID = reserved.ID
+	assert.Error(t, job.checkFreeSpace(movie, release))
+}
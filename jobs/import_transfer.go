@@ -0,0 +1,213 @@
+package jobs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// ImportMode selects how a finished download is placed in the library
+type ImportMode string
+
+// Import modes
+const (
+	// ImportModeHardlink links the library file to the download, so seeding
+	// continues without using extra space. Falls back to copy across filesystems.
+	ImportModeHardlink ImportMode = "hardlink"
+	ImportModeCopy     ImportMode = "copy"
+	ImportModeMove     ImportMode = "move"
+)
+
+// ParseImportMode validates an import mode name
+func ParseImportMode(mode string) (ImportMode, error) {
+	switch ImportMode(mode) {
+	case ImportModeHardlink, ImportModeCopy, ImportModeMove:
+		return ImportMode(mode), nil
+	default:
+		return "", fmt.Errorf("unknown import mode %q (use hardlink, copy or move)", mode)
+	}
+}
+
+// ImportOptions controls how imported files are written
+type ImportOptions struct {
+	Mode ImportMode
+	// FileMode and DirMode override the permissions of imported files and the
+	// folders created for them; zero keeps the source file's permissions and
+	// uses 0755 for folders. Hardlinks share the download's permissions and
+	// are left alone.
+	FileMode os.FileMode
+	DirMode  os.FileMode
+	// UID and GID change the owner of imported files and folders; -1 leaves it unchanged
+	UID int
+	GID int
+	// Extras also imports trailers, featurettes and similar bonus material
+	// into an "Extras" folder next to the movie
+	Extras bool
+}
+
+// DefaultImportOptions hardlinks files and keeps permissions and ownership
+func DefaultImportOptions() ImportOptions {
+	return ImportOptions{Mode: ImportModeHardlink, UID: -1, GID: -1}
+}
+
+// transferFile places src at dst according to opts and returns the mode that
+// was actually used. dst only appears once complete: files are linked or
+// copied to a hidden temporary name next to it and renamed into place.
+func transferFile(src, dst string, opts ImportOptions) (ImportMode, error) {
+	if err := makeImportDir(filepath.Dir(dst), opts); err != nil {
+		return "", err
+	}
+
+	switch opts.Mode {
+	case ImportModeHardlink:
+		err := linkFile(src, dst)
+		if err == nil {
+			return ImportModeHardlink, nil
+		}
+		if !errors.Is(err, syscall.EXDEV) {
+			return "", err
+		}
+		log.Printf("Cannot hardlink %s across filesystems, copying instead", src)
+	case ImportModeMove:
+		err := os.Rename(src, dst)
+		if err == nil {
+			return ImportModeMove, applyImportPermissions(dst, opts, 0)
+		}
+		if !errors.Is(err, syscall.EXDEV) {
+			return "", fmt.Errorf("failed to move %s: %w", src, err)
+		}
+
+		// Across filesystems a move is a copy followed by removing the source
+		if err := copyFile(src, dst, opts); err != nil {
+			return "", err
+		}
+		if err := os.Remove(src); err != nil {
+			log.Printf("Failed to remove %s after moving it: %v", src, err)
+		}
+		return ImportModeMove, nil
+	}
+
+	if err := copyFile(src, dst, opts); err != nil {
+		return "", err
+	}
+	return ImportModeCopy, nil
+}
+
+// makeImportDir creates the destination folder with the configured permissions
+func makeImportDir(dir string, opts ImportOptions) error {
+	dirMode := opts.DirMode
+	if dirMode == 0 {
+		dirMode = 0o755
+	}
+
+	if err := os.MkdirAll(dir, dirMode); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	return applyImportPermissions(dir, opts, dirMode)
+}
+
+// linkFile hardlinks src to dst via a temporary name, replacing dst atomically
+func linkFile(src, dst string) error {
+	tmp := temporaryImportName(dst)
+	if err := os.Link(src, tmp); err != nil {
+		return fmt.Errorf("failed to hardlink %s: %w", src, err)
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to move hardlink into place: %w", err)
+	}
+	return nil
+}
+
+// copyFile copies src to a temporary file next to dst, syncs it and renames
+// it into place, keeping the source's permissions and modification time
+func copyFile(src, dst string, opts ImportOptions) (err error) {
+	in, err := os.Open(src) // #nosec G304 -- importing files from the download client is the point
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", src, err)
+	}
+	defer func() {
+		if closeErr := in.Close(); closeErr != nil {
+			log.Printf("Failed to close %s: %v", src, closeErr)
+		}
+	}()
+
+	info, err := in.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", src, err)
+	}
+
+	out, err := os.OpenFile(temporaryImportName(dst), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to create import file: %w", err)
+	}
+	tmp := out.Name()
+	defer func() {
+		if err != nil {
+			_ = out.Close()
+			_ = os.Remove(tmp)
+		}
+	}()
+
+	if _, err = io.Copy(out, in); err != nil {
+		return fmt.Errorf("failed to copy %s: %w", src, err)
+	}
+	if err = out.Sync(); err != nil {
+		return fmt.Errorf("failed to flush %s: %w", tmp, err)
+	}
+	if err = out.Close(); err != nil {
+		return fmt.Errorf("failed to close %s: %w", tmp, err)
+	}
+
+	if err = applyImportPermissions(tmp, opts, info.Mode().Perm()); err != nil {
+		return err
+	}
+	if err = os.Chtimes(tmp, time.Now(), info.ModTime()); err != nil {
+		log.Printf("Failed to keep modification time of %s: %v", src, err)
+		err = nil
+	}
+
+	if err = os.Rename(tmp, dst); err != nil {
+		return fmt.Errorf("failed to move copy into place: %w", err)
+	}
+	return nil
+}
+
+// applyImportPermissions sets the configured mode, or fallback if none is
+// configured and fallback is non-zero, and the configured owner
+func applyImportPermissions(path string, opts ImportOptions, fallback os.FileMode) error {
+	mode := opts.FileMode
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+	if info.IsDir() {
+		mode = opts.DirMode
+	}
+	if mode == 0 {
+		mode = fallback
+	}
+
+	if mode != 0 {
+		if err := os.Chmod(path, mode); err != nil {
+			return fmt.Errorf("failed to set permissions on %s: %w", path, err)
+		}
+	}
+	if opts.UID >= 0 || opts.GID >= 0 {
+		if err := os.Chown(path, opts.UID, opts.GID); err != nil {
+			return fmt.Errorf("failed to set owner of %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// temporaryImportName returns a hidden name next to dst for partial files
+func temporaryImportName(dst string) string {
+	return filepath.Join(filepath.Dir(dst),
+		fmt.Sprintf(".%s.%d.partial", filepath.Base(dst), time.Now().UnixNano()))
+}
@@ -0,0 +1,83 @@
+package jobs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransferFile(t *testing.T) {
+	tests := []struct {
+		mode       ImportMode
+		sameFile   bool
+		sourceGone bool
+	}{
+		{ImportModeHardlink, true, false},
+		{ImportModeCopy, false, false},
+		{ImportModeMove, true, true}, // a rename keeps the inode
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			src := filepath.Join(t.TempDir(), "movie.mkv")
+			assert.NoError(t, os.WriteFile(src, []byte("video data"), 0o640))
+			srcInfo, err := os.Stat(src)
+			assert.NoError(t, err)
+
+			library := t.TempDir()
+			dst := filepath.Join(library, "Movie (2023)", "Movie (2023).mkv")
+
+			options := DefaultImportOptions()
+			options.Mode = tt.mode
+			used, err := transferFile(src, dst, options)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.mode, used)
+
+			data, err := os.ReadFile(dst)
+			assert.NoError(t, err)
+			assert.Equal(t, "video data", string(data))
+
+			dstInfo, err := os.Stat(dst)
+			assert.NoError(t, err)
+			assert.Equal(t, os.FileMode(0o640), dstInfo.Mode().Perm(), "permissions are preserved")
+			assert.Equal(t, tt.sameFile, os.SameFile(srcInfo, dstInfo))
+
+			_, err = os.Stat(src)
+			assert.Equal(t, tt.sourceGone, os.IsNotExist(err))
+
+			// Only the finished file is left behind
+			entries, err := os.ReadDir(filepath.Dir(dst))
+			assert.NoError(t, err)
+			assert.Len(t, entries, 1)
+		})
+	}
+}
+
+func TestTransferFile_ConfiguredPermissions(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "movie.mkv")
+	assert.NoError(t, os.WriteFile(src, []byte("video data"), 0o600))
+
+	dst := filepath.Join(t.TempDir(), "Movie (2023)", "Movie (2023).mkv")
+	options := ImportOptions{Mode: ImportModeCopy, FileMode: 0o644, DirMode: 0o750, UID: -1, GID: -1}
+	_, err := transferFile(src, dst, options)
+	assert.NoError(t, err)
+
+	fileInfo, err := os.Stat(dst)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o644), fileInfo.Mode().Perm())
+
+	dirInfo, err := os.Stat(filepath.Dir(dst))
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o750), dirInfo.Mode().Perm())
+}
+
+func TestParseImportMode(t *testing.T) {
+	mode, err := ParseImportMode("copy")
+	assert.NoError(t, err)
+	assert.Equal(t, ImportModeCopy, mode)
+
+	_, err = ParseImportMode("symlink")
+	assert.Error(t, err)
+}
@@ -0,0 +1,272 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"media/events"
+	"media/models"
+	"media/repository"
+	"media/services"
+)
+
+// videoExtensions lists the file extensions treated as movie files
+var videoExtensions = map[string]bool{
+	".mkv": true, ".mp4": true, ".m4v": true, ".avi": true, ".mov": true,
+	".wmv": true, ".ts": true, ".m2ts": true, ".webm": true, ".mpg": true, ".mpeg": true,
+}
+
+// Importer turns a finished download into the library file for a movie
+type Importer struct {
+	movieRepo      *repository.MovieRepository
+	bus            *events.Bus
+	rootFolderRepo *repository.RootFolderRepository
+	mediaAnalyzer  *MediaAnalyzer
+	metadataWriter *MetadataWriter
+	mediaServers   *MediaServerNotifier
+	options        ImportOptions
+}
+
+// NewImporter creates a new importer
+func NewImporter(movieRepo *repository.MovieRepository, bus *events.Bus) *Importer {
+	return &Importer{
+		movieRepo: movieRepo,
+		bus:       bus,
+		options:   DefaultImportOptions(),
+	}
+}
+
+// SetRootFolderRepository configures the library folders files are imported
+// into. Without root folders, movies are used from where they were downloaded.
+func (i *Importer) SetRootFolderRepository(rootFolderRepo *repository.RootFolderRepository) {
+	i.rootFolderRepo = rootFolderRepo
+}
+
+// SetMediaAnalyzer configures probing of imported files for their real
+// resolution and duration
+func (i *Importer) SetMediaAnalyzer(mediaAnalyzer *MediaAnalyzer) {
+	i.mediaAnalyzer = mediaAnalyzer
+}
+
+// SetMetadataWriter configures writing NFO files and artwork next to files
+// imported into root folders that want them
+func (i *Importer) SetMetadataWriter(metadataWriter *MetadataWriter) {
+	i.metadataWriter = metadataWriter
+}
+
+// SetMediaServerNotifier configures the media servers told about imported files
+func (i *Importer) SetMediaServerNotifier(mediaServers *MediaServerNotifier) {
+	i.mediaServers = mediaServers
+}
+
+// SetOptions configures how files are placed in the library
+func (i *Importer) SetOptions(options ImportOptions) {
+	i.options = options
+}
+
+// Import marks the movie's download as complete and places the main video
+// file found at sourcePath, which may be a single file or a directory, in the
+// movie's root folder together with its subtitles and, if enabled, extras.
+// The movie stays in StatusProcessing until the file is fully in place.
+func (i *Importer) Import(movie *models.Movie, sourcePath string) error {
+	log.Printf("Importing '%s' from %s", movie.Title, sourcePath)
+
+	i.bus.Publish(movie.ID, models.EventDownloadCompleted,
+		fmt.Sprintf("Download completed for '%s'", movie.Title),
+		models.DownloadDetails{Path: sourcePath})
+
+	movie.Status = models.StatusDownloaded
+	if err := i.movieRepo.Update(movie); err != nil {
+		log.Printf("Failed to update movie status to downloaded: %v", err)
+	}
+
+	selection, err := selectReleaseFiles(sourcePath, movie.Runtime)
+	if err != nil {
+		return i.fail(movie, sourcePath, err)
+	}
+	videoPath, size := selection.Main.Name, selection.Main.Size
+
+	movie.Status = models.StatusProcessing
+	if err := i.movieRepo.Update(movie); err != nil {
+		log.Printf("Failed to update movie status to processing: %v", err)
+	}
+
+	libraryPath, mode, err := i.placeFile(movie, videoPath)
+	if err != nil {
+		return i.fail(movie, sourcePath, err)
+	}
+
+	oldStatus := movie.Status
+	movie.FilePath = libraryPath
+	movie.FileSize = size
+	movie.Status = models.StatusReady
+	if err := i.movieRepo.Update(movie); err != nil {
+		return fmt.Errorf("failed to update imported movie: %w", err)
+	}
+
+	i.bus.Publish(movie.ID, models.EventStatusChanged,
+		fmt.Sprintf("Status changed to: %s", models.StatusReady),
+		models.StatusChangeDetails{OldStatus: oldStatus, NewStatus: models.StatusReady})
+	details := models.ImportDetails{Path: libraryPath, Size: size}
+	if mode != "" {
+		details.Source = videoPath
+		details.Mode = string(mode)
+		details.Subtitles = i.placeSubtitles(libraryPath, videoPath, selection.Subtitles)
+		if i.options.Extras {
+			details.Extras = i.placeExtras(libraryPath, selection.Extras)
+		}
+		if i.metadataWriter != nil {
+			metadata, err := i.metadataWriter.Write(movie)
+			if err != nil {
+				log.Printf("Failed to write metadata for '%s': %v", movie.Title, err)
+			} else {
+				details.Metadata = metadata
+			}
+		}
+	}
+	i.bus.Publish(movie.ID, models.EventImported,
+		fmt.Sprintf("Imported '%s'", filepath.Base(libraryPath)), details)
+
+	if i.mediaAnalyzer != nil {
+		if _, err := i.mediaAnalyzer.Analyze(movie); err != nil {
+			log.Printf("Failed to probe '%s': %v", movie.Title, err)
+		}
+	}
+	i.mediaServers.Notify(context.Background(), libraryPath, services.MediaCreated)
+
+	log.Printf("Imported '%s' as %s", movie.Title, libraryPath)
+	return nil
+}
+
+// placeFile puts the video into "<root>/<Title (Year)>/<Title (Year)>.<ext>"
+// and returns its library path and the import mode used. Without a root
+// folder the file stays where it is and the mode is empty.
+func (i *Importer) placeFile(movie *models.Movie, videoPath string) (string, ImportMode, error) {
+	folder := rootFolderFor(i.rootFolderRepo, movie)
+	if folder == nil {
+		return videoPath, "", nil
+	}
+
+	name := movieFolderName(movie)
+	destination := filepath.Join(folder.Path, name, name+strings.ToLower(filepath.Ext(videoPath)))
+
+	mode, err := transferFile(videoPath, destination, i.options)
+	if err != nil {
+		return "", "", err
+	}
+
+	// The movie now lives in this folder; the caller saves the assignment
+	if movie.RootFolderID != folder.ID {
+		if movie.RootFolderID != 0 {
+			log.Printf("Moving '%s' from root folder %d to %s", movie.Title, movie.RootFolderID, folder.Path)
+		}
+		movie.RootFolderID = folder.ID
+	}
+	return destination, mode, nil
+}
+
+// placeSubtitles imports subtitles next to the library file, keeping any
+// language tag: "Movie.2020.1080p.en.srt" becomes "Title (Year).en.srt" and
+// "Subs/English.srt" becomes "Title (Year).English.srt". It returns the paths
+// written; failures are logged and skipped.
+func (i *Importer) placeSubtitles(libraryPath, videoPath string, subtitles []releaseFile) []string {
+	base := strings.TrimSuffix(libraryPath, filepath.Ext(libraryPath))
+	videoStem := strings.ToLower(strings.TrimSuffix(filepath.Base(videoPath), filepath.Ext(videoPath)))
+
+	used := make(map[string]bool)
+	var placed []string
+	for _, subtitle := range subtitles {
+		ext := strings.ToLower(filepath.Ext(subtitle.Name))
+		tag := strings.TrimSuffix(filepath.Base(subtitle.Name), filepath.Ext(subtitle.Name))
+		if strings.HasPrefix(strings.ToLower(tag), videoStem) {
+			tag = tag[len(videoStem):]
+		}
+		tag = services.SanitizeFileName(strings.Trim(tag, "._- "))
+
+		destination := base + ext
+		if tag != "" {
+			destination = base + "." + tag + ext
+		}
+		for n := 2; used[destination]; n++ {
+			destination = fmt.Sprintf("%s.%s%d%s", base, tag, n, ext)
+		}
+		used[destination] = true
+
+		if _, err := transferFile(subtitle.Name, destination, i.options); err != nil {
+			log.Printf("Failed to import subtitle %s: %v", subtitle.Name, err)
+			continue
+		}
+		placed = append(placed, destination)
+	}
+	return placed
+}
+
+// placeExtras imports bonus material into an "Extras" folder next to the
+// library file and returns the paths written; failures are logged and skipped
+func (i *Importer) placeExtras(libraryPath string, extras []releaseFile) []string {
+	dir := filepath.Join(filepath.Dir(libraryPath), "Extras")
+
+	var placed []string
+	for _, extra := range extras {
+		destination := filepath.Join(dir, services.SanitizeFileName(filepath.Base(extra.Name)))
+		if _, err := transferFile(extra.Name, destination, i.options); err != nil {
+			log.Printf("Failed to import extra %s: %v", extra.Name, err)
+			continue
+		}
+		placed = append(placed, destination)
+	}
+	return placed
+}
+
+// movieFolderName returns "Title (Year)", safe for use as a file name
+func movieFolderName(movie *models.Movie) string {
+	name := movie.Title
+	if movie.Year > 0 {
+		name = fmt.Sprintf("%s (%d)", movie.Title, movie.Year)
+	}
+	return services.SanitizeFileName(name)
+}
+
+// fail marks the movie's import as failed and returns the wrapped error
+func (i *Importer) fail(movie *models.Movie, sourcePath string, err error) error {
+	movie.Status = models.StatusFailed
+	if updateErr := i.movieRepo.Update(movie); updateErr != nil {
+		log.Printf("Failed to update movie status to failed: %v", updateErr)
+	}
+	i.bus.Publish(movie.ID, models.EventImportFailed,
+		fmt.Sprintf("Import failed: %v", err),
+		models.ImportDetails{Path: sourcePath, Error: err.Error()})
+	return fmt.Errorf("failed to import '%s': %w", movie.Title, err)
+}
+
+// rootFolderFor returns the movie's root folder, or the default one for movies
+// without a folder or whose folder is gone. It does not change the movie, and
+// returns nil if no root folders are configured.
+func rootFolderFor(repo *repository.RootFolderRepository, movie *models.Movie) *models.RootFolder {
+	if repo == nil {
+		return nil
+	}
+
+	if movie.RootFolderID != 0 {
+		folder, err := repo.GetByID(movie.RootFolderID)
+		if err == nil {
+			return folder
+		}
+		log.Printf("Root folder of '%s' unavailable, using the default: %v", movie.Title, err)
+	}
+
+	folder, err := repo.GetDefault()
+	if err != nil {
+		log.Printf("Failed to get default root folder: %v", err)
+		return nil
+	}
+	return folder
+}
+
+// isVideoFile reports whether the file name has a video extension
+func isVideoFile(name string) bool {
+	return videoExtensions[strings.ToLower(filepath.Ext(name))]
+}
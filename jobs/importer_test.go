@@ -0,0 +1,204 @@
+package jobs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"media/database"
+	"media/events"
+	"media/models"
+	"media/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupTestImporter(t *testing.T) (*Importer, *repository.MovieRepository, *repository.MovieEventRepository, func()) {
+	testDB, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+
+	if err := testDB.InitSchema(); err != nil {
+		t.Fatalf("Failed to initialize test schema: %v", err)
+	}
+
+	movieRepo := repository.NewMovieRepository(testDB)
+	movieEventRepo := repository.NewMovieEventRepository(testDB)
+	importer := NewImporter(movieRepo, newTestBus(movieEventRepo))
+
+	cleanup := func() {
+		if err := testDB.Close(); err != nil {
+			t.Logf("Failed to close test database: %v", err)
+		}
+	}
+
+	return importer, movieRepo, movieEventRepo, cleanup
+}
+
+func createDownloadingMovie(t *testing.T, repo *repository.MovieRepository) *models.Movie {
+	movie := &models.Movie{
+		Title:  "Test Movie",
+		Year:   2023,
+		Status: models.StatusDownloading,
+	}
+	assert.NoError(t, repo.Create(movie))
+	return movie
+}
+
+// newTestBus returns a bus storing events as they are published
+func newTestBus(movieEventRepo *repository.MovieEventRepository) *events.Bus {
+	bus := events.NewBus(0)
+	bus.Subscribe("persistence", movieEventRepo.Save)
+	return bus
+}
+
+func writeTestFile(t *testing.T, path string, size int) {
+	assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	assert.NoError(t, os.WriteFile(path, make([]byte, size), 0o644))
+}
+
+func TestImporter_ImportDirectory(t *testing.T) {
+	importer, movieRepo, movieEventRepo, cleanup := setupTestImporter(t)
+	defer cleanup()
+
+	movie := createDownloadingMovie(t, movieRepo)
+
+	download := filepath.Join(t.TempDir(), "Test.Movie.2023.1080p")
+	writeTestFile(t, filepath.Join(download, "Test.Movie.2023.1080p.mkv"), 4096)
+	writeTestFile(t, filepath.Join(download, "Sample", "sample.mkv"), 512)
+	writeTestFile(t, filepath.Join(download, "Test.Movie.2023.1080p.nfo"), 8192)
+
+	assert.NoError(t, importer.Import(movie, download))
+
+	stored, err := movieRepo.GetByID(movie.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.StatusReady, stored.Status)
+	assert.Equal(t, filepath.Join(download, "Test.Movie.2023.1080p.mkv"), stored.FilePath)
+	assert.Equal(t, int64(4096), stored.FileSize)
+
+	events, err := movieEventRepo.GetByMovieID(movie.ID)
+	assert.NoError(t, err)
+	var types []models.MovieEventType
+	for _, event := range events {
+		types = append(types, event.Type)
+	}
+	assert.Contains(t, types, models.EventDownloadCompleted)
+	assert.Contains(t, types, models.EventImported)
+}
+
+func TestImporter_ImportSingleFile(t *testing.T) {
+	importer, movieRepo, _, cleanup := setupTestImporter(t)
+	defer cleanup()
+
+	movie := createDownloadingMovie(t, movieRepo)
+
+	file := filepath.Join(t.TempDir(), "Test.Movie.2023.720p.mp4")
+	writeTestFile(t, file, 2048)
+
+	assert.NoError(t, importer.Import(movie, file))
+
+	stored, err := movieRepo.GetByID(movie.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.StatusReady, stored.Status)
+	assert.Equal(t, file, stored.FilePath)
+}
+
+func TestImporter_ImportNoVideo(t *testing.T) {
+	importer, movieRepo, _, cleanup := setupTestImporter(t)
+	defer cleanup()
+
+	movie := createDownloadingMovie(t, movieRepo)
+
+	download := filepath.Join(t.TempDir(), "Test.Movie.2023")
+	writeTestFile(t, filepath.Join(download, "setup.exe"), 1024)
+
+	assert.Error(t, importer.Import(movie, download))
+
+	stored, err := movieRepo.GetByID(movie.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.StatusFailed, stored.Status)
+	assert.Empty(t, stored.FilePath)
+}
+
+func TestImporter_ImportIntoRootFolder(t *testing.T) {
+	testDB, err := database.NewDB(":memory:")
+	assert.NoError(t, err)
+	defer func() { _ = testDB.Close() }()
+	assert.NoError(t, testDB.InitSchema())
+
+	movieRepo := repository.NewMovieRepository(testDB)
+	movieEventRepo := repository.NewMovieEventRepository(testDB)
+	rootFolderRepo := repository.NewRootFolderRepository(testDB)
+	importer := NewImporter(movieRepo, newTestBus(movieEventRepo))
+
+	library := t.TempDir()
+	folder := &models.RootFolder{Path: library}
+	assert.NoError(t, rootFolderRepo.Create(folder))
+	importer.SetRootFolderRepository(rootFolderRepo)
+
+	movie := createDownloadingMovie(t, movieRepo)
+
+	download := filepath.Join(t.TempDir(), "Test.Movie.2023.1080p")
+	writeTestFile(t, filepath.Join(download, "Test.Movie.2023.1080p.MKV"), 4096)
+
+	assert.NoError(t, importer.Import(movie, download))
+
+	// The movie is assigned the folder it was imported into
+	expected := filepath.Join(library, "Test Movie (2023)", "Test Movie (2023).mkv")
+	stored, err := movieRepo.GetByID(movie.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.StatusReady, stored.Status)
+	assert.Equal(t, expected, stored.FilePath)
+	assert.Equal(t, folder.ID, stored.RootFolderID)
+	assert.FileExists(t, expected)
+
+	// The download is hardlinked, so it keeps seeding
+	assert.FileExists(t, filepath.Join(download, "Test.Movie.2023.1080p.MKV"))
+
+	events, err := movieEventRepo.GetByMovieID(movie.ID)
+	assert.NoError(t, err)
+	for _, event := range events {
+		if event.Type == models.EventImported {
+			var details models.ImportDetails
+			assert.NoError(t, event.DecodeDetails(&details))
+			assert.Equal(t, string(ImportModeHardlink), details.Mode)
+		}
+	}
+}
+
+func TestImporter_ImportSubtitlesAndExtras(t *testing.T) {
+	testDB, err := database.NewDB(":memory:")
+	assert.NoError(t, err)
+	defer func() { _ = testDB.Close() }()
+	assert.NoError(t, testDB.InitSchema())
+
+	movieRepo := repository.NewMovieRepository(testDB)
+	rootFolderRepo := repository.NewRootFolderRepository(testDB)
+	importer := NewImporter(movieRepo, nil)
+
+	library := t.TempDir()
+	assert.NoError(t, rootFolderRepo.Create(&models.RootFolder{Path: library}))
+	importer.SetRootFolderRepository(rootFolderRepo)
+	options := DefaultImportOptions()
+	options.Extras = true
+	importer.SetOptions(options)
+
+	movie := createDownloadingMovie(t, movieRepo)
+
+	download := filepath.Join(t.TempDir(), "Test.Movie.2023.1080p")
+	writeTestFile(t, filepath.Join(download, "Test.Movie.2023.1080p.mkv"), 4096)
+	writeTestFile(t, filepath.Join(download, "Test.Movie.2023.1080p.en.srt"), 64)
+	writeTestFile(t, filepath.Join(download, "Subs", "German.srt"), 64)
+	writeTestFile(t, filepath.Join(download, "Featurettes", "Behind The Scenes.mkv"), 1024)
+	writeTestFile(t, filepath.Join(download, "Sample", "sample.mkv"), 8192)
+
+	assert.NoError(t, importer.Import(movie, download))
+
+	dir := filepath.Join(library, "Test Movie (2023)")
+	assert.FileExists(t, filepath.Join(dir, "Test Movie (2023).mkv"))
+	assert.FileExists(t, filepath.Join(dir, "Test Movie (2023).en.srt"))
+	assert.FileExists(t, filepath.Join(dir, "Test Movie (2023).German.srt"))
+	assert.FileExists(t, filepath.Join(dir, "Extras", "Behind The Scenes.mkv"))
+	assert.NoFileExists(t, filepath.Join(dir, "Extras", "sample.mkv"))
+}
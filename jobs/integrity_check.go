@@ -0,0 +1,176 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"media/events"
+	"media/models"
+	"media/repository"
+	"media/services"
+)
+
+// IntegrityCheckJob verifies that the files of ready movies are still on
+// disk, marking movies whose file disappeared as missing
+type IntegrityCheckJob struct {
+	movieRepo      *repository.MovieRepository
+	bus            *events.Bus
+	rootFolderRepo *repository.RootFolderRepository
+	mediaServers   *MediaServerNotifier
+	requeue        bool
+}
+
+// NewIntegrityCheckJob creates a new integrity check job
+func NewIntegrityCheckJob(movieRepo *repository.MovieRepository, bus *events.Bus) *IntegrityCheckJob {
+	return &IntegrityCheckJob{
+		movieRepo: movieRepo,
+		bus:       bus,
+	}
+}
+
+// SetRootFolderRepository configures the library folders whose availability is
+// checked first, so an unmounted share does not mark every movie in it missing
+func (j *IntegrityCheckJob) SetRootFolderRepository(rootFolderRepo *repository.RootFolderRepository) {
+	j.rootFolderRepo = rootFolderRepo
+}
+
+// SetMediaServerNotifier configures the media servers told about files that
+// disappeared or came back
+func (j *IntegrityCheckJob) SetMediaServerNotifier(mediaServers *MediaServerNotifier) {
+	j.mediaServers = mediaServers
+}
+
+// SetRequeue makes movies whose file disappeared wanted again, so they are
+// searched for and downloaded anew, instead of marking them missing
+func (j *IntegrityCheckJob) SetRequeue(requeue bool) {
+	j.requeue = requeue
+}
+
+// CheckFiles stats the file of every ready movie. Movies whose file is gone are
+// marked missing (or wanted when requeueing), changed file sizes are recorded,
+// and missing movies whose file is back are ready again.
+func (j *IntegrityCheckJob) CheckFiles(ctx context.Context) error {
+	var movies []models.Movie
+	for _, status := range []models.MediaStatus{models.StatusReady, models.StatusMissing} {
+		byStatus, err := j.movieRepo.GetByStatus(status)
+		if err != nil {
+			return fmt.Errorf("failed to get %s movies: %w", status, err)
+		}
+		movies = append(movies, byStatus...)
+	}
+
+	// Root folders that cannot be read, keyed by id, checked once per run
+	unavailable := make(map[int]bool)
+
+	for i := range movies {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		movie := &movies[i]
+		if movie.FilePath == "" || !j.rootFolderAvailable(movie.RootFolderID, unavailable) {
+			continue
+		}
+
+		info, err := os.Stat(movie.FilePath)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			if movie.Status == models.StatusReady {
+				j.markMissing(ctx, movie)
+			}
+		case err != nil:
+			log.Printf("Cannot check file of '%s': %v", movie.Title, err)
+		case movie.Status == models.StatusMissing:
+			j.markFound(ctx, movie, info.Size())
+		case info.Size() != movie.FileSize:
+			log.Printf("File size of '%s' changed from %d to %d bytes", movie.Title, movie.FileSize, info.Size())
+			movie.FileSize = info.Size()
+			if err := j.movieRepo.Update(movie); err != nil {
+				log.Printf("Failed to update file size of '%s': %v", movie.Title, err)
+			}
+			j.mediaServers.Notify(ctx, movie.FilePath, services.MediaModified)
+		}
+	}
+
+	return nil
+}
+
+// rootFolderAvailable reports whether a movie's root folder can be read,
+// remembering unavailable folders for the rest of the run
+func (j *IntegrityCheckJob) rootFolderAvailable(folderID int, unavailable map[int]bool) bool {
+	if j.rootFolderRepo == nil || folderID == 0 {
+		return true
+	}
+	if down, ok := unavailable[folderID]; ok {
+		return !down
+	}
+
+	folder, err := j.rootFolderRepo.GetByID(folderID)
+	if err != nil {
+		// Folder was deleted; the movie's file can still be checked on its own
+		unavailable[folderID] = false
+		return true
+	}
+	// An empty root folder is far more likely an unmounted share than a
+	// library whose every movie was deleted
+	entries, err := os.ReadDir(folder.Path)
+	if err != nil || len(entries) == 0 {
+		if err == nil {
+			err = errors.New("folder is empty")
+		}
+		log.Printf("Root folder %s is not available, skipping its movies: %v", folder.Path, err)
+		unavailable[folderID] = true
+		return false
+	}
+	unavailable[folderID] = false
+	return true
+}
+
+// markMissing records that a ready movie's file is gone
+func (j *IntegrityCheckJob) markMissing(ctx context.Context, movie *models.Movie) {
+	log.Printf("File of '%s' is missing: %s", movie.Title, movie.FilePath)
+
+	j.bus.Publish(movie.ID, models.EventFileMissing,
+		fmt.Sprintf("File missing for '%s'", movie.Title),
+		models.FileMissingDetails{Path: movie.FilePath, Size: movie.FileSize, Requeued: j.requeue})
+
+	j.mediaServers.Notify(ctx, movie.FilePath, services.MediaDeleted)
+
+	oldStatus := movie.Status
+	movie.Status = models.StatusMissing
+	if j.requeue {
+		movie.Status = models.StatusWanted
+		movie.FilePath = ""
+		movie.FileSize = 0
+	}
+	if err := j.movieRepo.Update(movie); err != nil {
+		log.Printf("Failed to update status of '%s': %v", movie.Title, err)
+		return
+	}
+
+	j.bus.Publish(movie.ID, models.EventStatusChanged,
+		fmt.Sprintf("Status changed to: %s", movie.Status),
+		models.StatusChangeDetails{OldStatus: oldStatus, NewStatus: movie.Status})
+}
+
+// markFound makes a missing movie ready again once its file is back
+func (j *IntegrityCheckJob) markFound(ctx context.Context, movie *models.Movie, size int64) {
+	log.Printf("File of '%s' is back: %s", movie.Title, movie.FilePath)
+
+	oldStatus := movie.Status
+	movie.Status = models.StatusReady
+	movie.FileSize = size
+	if err := j.movieRepo.Update(movie); err != nil {
+		log.Printf("Failed to update status of '%s': %v", movie.Title, err)
+		return
+	}
+
+	j.bus.Publish(movie.ID, models.EventStatusChanged,
+		fmt.Sprintf("Status changed to: %s", movie.Status),
+		models.StatusChangeDetails{OldStatus: oldStatus, NewStatus: movie.Status, Path: movie.FilePath})
+
+	j.mediaServers.Notify(ctx, movie.FilePath, services.MediaCreated)
+}
@@ -0,0 +1,131 @@
+package jobs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"media/database"
+	"media/models"
+	"media/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupTestIntegrityCheck(t *testing.T) (*IntegrityCheckJob, *repository.MovieRepository,
+	*repository.MovieEventRepository, *repository.RootFolderRepository, func()) {
+	testDB, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	if err := testDB.InitSchema(); err != nil {
+		t.Fatalf("Failed to initialize test schema: %v", err)
+	}
+
+	movieRepo := repository.NewMovieRepository(testDB)
+	movieEventRepo := repository.NewMovieEventRepository(testDB)
+	rootFolderRepo := repository.NewRootFolderRepository(testDB)
+	job := NewIntegrityCheckJob(movieRepo, newTestBus(movieEventRepo))
+	job.SetRootFolderRepository(rootFolderRepo)
+
+	cleanup := func() {
+		if err := testDB.Close(); err != nil {
+			t.Logf("Failed to close test database: %v", err)
+		}
+	}
+
+	return job, movieRepo, movieEventRepo, rootFolderRepo, cleanup
+}
+
+func createReadyMovie(t *testing.T, repo *repository.MovieRepository, title, path string, size int64, folderID int) *models.Movie {
+	movie := &models.Movie{
+		Title:        title,
+		Status:       models.StatusReady,
+		FilePath:     path,
+		FileSize:     size,
+		RootFolderID: folderID,
+	}
+	assert.NoError(t, repo.Create(movie))
+	return movie
+}
+
+func TestIntegrityCheckJob_CheckFiles(t *testing.T) {
+	job, movieRepo, movieEventRepo, rootFolderRepo, cleanup := setupTestIntegrityCheck(t)
+	defer cleanup()
+
+	library := t.TempDir()
+	folder := &models.RootFolder{Path: library}
+	assert.NoError(t, rootFolderRepo.Create(folder))
+
+	present := filepath.Join(library, "Present (2020)", "Present (2020).mkv")
+	writeTestFile(t, present, 2048)
+	resized := createReadyMovie(t, movieRepo, "Present", present, 1024, folder.ID)
+	gone := createReadyMovie(t, movieRepo, "Gone", filepath.Join(library, "Gone (2019)", "Gone (2019).mkv"), 4096, folder.ID)
+
+	assert.NoError(t, job.CheckFiles(context.Background()))
+
+	stored, err := movieRepo.GetByID(resized.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.StatusReady, stored.Status)
+	assert.Equal(t, int64(2048), stored.FileSize)
+
+	stored, err = movieRepo.GetByID(gone.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.StatusMissing, stored.Status)
+	assert.Equal(t, gone.FilePath, stored.FilePath)
+
+	events, err := movieEventRepo.GetByMovieID(gone.ID)
+	assert.NoError(t, err)
+	var types []models.MovieEventType
+	for _, event := range events {
+		types = append(types, event.Type)
+	}
+	assert.Contains(t, types, models.EventFileMissing)
+
+	// Once the file is back the movie is ready again
+	writeTestFile(t, gone.FilePath, 4096)
+	assert.NoError(t, job.CheckFiles(context.Background()))
+
+	stored, err = movieRepo.GetByID(gone.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.StatusReady, stored.Status)
+}
+
+func TestIntegrityCheckJob_Requeue(t *testing.T) {
+	job, movieRepo, _, _, cleanup := setupTestIntegrityCheck(t)
+	defer cleanup()
+	job.SetRequeue(true)
+
+	gone := createReadyMovie(t, movieRepo, "Gone", filepath.Join(t.TempDir(), "Gone.mkv"), 4096, 0)
+
+	assert.NoError(t, job.CheckFiles(context.Background()))
+
+	stored, err := movieRepo.GetByID(gone.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.StatusWanted, stored.Status)
+	assert.Empty(t, stored.FilePath)
+	assert.Zero(t, stored.FileSize)
+}
+
+func TestIntegrityCheckJob_SkipsUnavailableRootFolder(t *testing.T) {
+	job, movieRepo, _, rootFolderRepo, cleanup := setupTestIntegrityCheck(t)
+	defer cleanup()
+
+	// An emptied mount point must not mark its movies missing
+	library := t.TempDir()
+	folder := &models.RootFolder{Path: library}
+	assert.NoError(t, rootFolderRepo.Create(folder))
+	unmounted := createReadyMovie(t, movieRepo, "Unmounted", filepath.Join(library, "Unmounted.mkv"), 4096, folder.ID)
+
+	assert.NoError(t, job.CheckFiles(context.Background()))
+	stored, err := movieRepo.GetByID(unmounted.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.StatusReady, stored.Status)
+
+	assert.NoError(t, os.Remove(library))
+	assert.NoError(t, job.CheckFiles(context.Background()))
+	stored, err = movieRepo.GetByID(unmounted.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.StatusReady, stored.Status)
+}
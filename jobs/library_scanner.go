@@ -0,0 +1,414 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"media/events"
+	"media/models"
+	"media/repository"
+	"media/services"
+)
+
+// ErrScanInProgress is returned when a root folder is already being scanned
+var ErrScanInProgress = errors.New("a scan of this root folder is already running")
+
+// yearPattern matches a possible release year; parseMovieName checks that it
+// stands on its own
+var yearPattern = regexp.MustCompile(`(?:19|20)\d{2}`)
+
+// releaseTagPattern matches the first of the tags that follow the title in a
+// release name, for names without a year
+var releaseTagPattern = regexp.MustCompile(`(?i)[\s.\[(_-](2160p|1080p|720p|480p|4k|uhd|blu-?ray|bdrip|brrip|` +
+	`web-?dl|web-?rip|hdtv|dvdrip|remux|x264|x265|h\.?26[45]|hevc|proper|repack|extended|unrated|remastered)\b`)
+
+// movieLookup is the part of the TMDB service a library scan needs
+type movieLookup interface {
+	SearchMovies(query string, year int) ([]services.TMDBSearchResult, error)
+	GetMovie(tmdbID int) (*models.Movie, error)
+}
+
+// LibraryScanner adopts movie files already present in a root folder by
+// matching their names against TMDB. Files already in the library are left
+// alone, so a folder can be rescanned at any time.
+type LibraryScanner struct {
+	movieRepo     *repository.MovieRepository
+	bus           *events.Bus
+	tmdb          movieLookup
+	mediaAnalyzer *MediaAnalyzer
+
+	mu    sync.Mutex
+	scans map[int]*models.LibraryScan // latest scan per root folder
+}
+
+// NewLibraryScanner creates a new library scanner
+func NewLibraryScanner(movieRepo *repository.MovieRepository, bus *events.Bus,
+	tmdbService *services.TMDBService) *LibraryScanner {
+	return &LibraryScanner{
+		movieRepo: movieRepo,
+		bus:       bus,
+		tmdb:      tmdbService,
+		scans:     make(map[int]*models.LibraryScan),
+	}
+}
+
+// SetMediaAnalyzer configures probing of adopted files for their real
+// resolution and duration
+func (s *LibraryScanner) SetMediaAnalyzer(mediaAnalyzer *MediaAnalyzer) {
+	s.mediaAnalyzer = mediaAnalyzer
+}
+
+// Start registers a new scan of a root folder and returns its initial state.
+// The scan itself is performed by Run.
+func (s *LibraryScanner) Start(folderID int) (models.LibraryScan, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if scan, ok := s.scans[folderID]; ok && scan.Status == models.LibraryScanRunning {
+		return copyLibraryScan(scan), ErrScanInProgress
+	}
+
+	scan := &models.LibraryScan{
+		RootFolderID: folderID,
+		Status:       models.LibraryScanRunning,
+		StartedAt:    time.Now(),
+		Unmatched:    []models.UnmatchedPath{},
+	}
+	s.scans[folderID] = scan
+	return copyLibraryScan(scan), nil
+}
+
+// LastScan returns the latest scan of a root folder, if there was one
+func (s *LibraryScanner) LastScan(folderID int) (models.LibraryScan, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	scan, ok := s.scans[folderID]
+	if !ok {
+		return models.LibraryScan{}, false
+	}
+	return copyLibraryScan(scan), true
+}
+
+// Scan starts and runs a scan of folder, returning its outcome
+func (s *LibraryScanner) Scan(ctx context.Context, folder *models.RootFolder) (models.LibraryScan, error) {
+	if _, err := s.Start(folder.ID); err != nil {
+		return models.LibraryScan{}, err
+	}
+	err := s.Run(ctx, folder)
+	scan, _ := s.LastScan(folder.ID)
+	return scan, err
+}
+
+// Run scans the movie folders and files directly inside folder, which must
+// have been registered with Start
+func (s *LibraryScanner) Run(ctx context.Context, folder *models.RootFolder) error {
+	log.Printf("Scanning root folder %s", folder.Path)
+
+	err := s.scanFolder(ctx, folder)
+
+	s.update(folder.ID, func(scan *models.LibraryScan) {
+		now := time.Now()
+		scan.FinishedAt = &now
+		scan.Status = models.LibraryScanCompleted
+		if err != nil {
+			scan.Status = models.LibraryScanFailed
+			scan.Error = err.Error()
+		}
+		log.Printf("Scan of %s %s: %d added, %d adopted, %d existing, %d unmatched",
+			folder.Path, scan.Status, scan.Added, scan.Adopted, scan.Existing, len(scan.Unmatched))
+	})
+	return err
+}
+
+// scanFolder matches every entry of the root folder
+func (s *LibraryScanner) scanFolder(ctx context.Context, folder *models.RootFolder) error {
+	entries, err := os.ReadDir(folder.Path)
+	if err != nil {
+		return fmt.Errorf("failed to read root folder: %w", err)
+	}
+
+	movies, err := s.movieRepo.GetAll()
+	if err != nil {
+		return fmt.Errorf("failed to get movies: %w", err)
+	}
+	byPath := make(map[string]*models.Movie, len(movies))
+	byTMDBID := make(map[int]*models.Movie, len(movies))
+	for i := range movies {
+		if movies[i].FilePath != "" {
+			byPath[filepath.Clean(movies[i].FilePath)] = &movies[i]
+		}
+		if movies[i].TMDBID != 0 {
+			byTMDBID[movies[i].TMDBID] = &movies[i]
+		}
+	}
+
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if strings.HasPrefix(entry.Name(), ".") || (!entry.IsDir() && !isVideoFile(entry.Name())) {
+			continue
+		}
+
+		path := filepath.Join(folder.Path, entry.Name())
+		s.update(folder.ID, func(scan *models.LibraryScan) { scan.Scanned++ })
+
+		unmatched := s.scanEntry(folder, path, byPath, byTMDBID)
+		if unmatched != nil {
+			log.Printf("Could not match %s: %s", unmatched.Path, unmatched.Reason)
+			s.update(folder.ID, func(scan *models.LibraryScan) { scan.Unmatched = append(scan.Unmatched, *unmatched) })
+		}
+	}
+
+	return nil
+}
+
+// scanEntry matches one movie folder or file and adds it to the library. It
+// returns why the entry could not be matched, or nil.
+func (s *LibraryScanner) scanEntry(folder *models.RootFolder, path string,
+	byPath map[string]*models.Movie, byTMDBID map[int]*models.Movie) *models.UnmatchedPath {
+	selection, err := selectReleaseFiles(path, 0)
+	if err != nil {
+		return &models.UnmatchedPath{Path: path, Reason: err.Error()}
+	}
+	file := selection.Main
+
+	if _, ok := byPath[filepath.Clean(file.Name)]; ok {
+		s.update(folder.ID, func(scan *models.LibraryScan) { scan.Existing++ })
+		return nil
+	}
+
+	// Folders are usually named better than the files inside them
+	title, year := parseMovieName(filepath.Base(path))
+	if year == 0 && file.Name != path {
+		if fileTitle, fileYear := parseMovieName(filepath.Base(file.Name)); fileYear != 0 {
+			title, year = fileTitle, fileYear
+		}
+	}
+	unmatched := &models.UnmatchedPath{Path: file.Name, Title: title, Year: year}
+	if title == "" {
+		unmatched.Reason = "no title in the name"
+		return unmatched
+	}
+
+	match, reason := s.findMovie(title, year)
+	if match == nil {
+		unmatched.Reason = reason
+		return unmatched
+	}
+
+	if movie, ok := byTMDBID[match.ID]; ok {
+		switch {
+		case movie.FilePath != "" && movie.Status != models.StatusMissing:
+			unmatched.Reason = fmt.Sprintf("'%s' is already in the library at %s", movie.Title, movie.FilePath)
+			return unmatched
+		case movie.Status == models.StatusSearching || movie.Status == models.StatusDownloading ||
+			movie.Status == models.StatusDownloaded || movie.Status == models.StatusProcessing:
+			unmatched.Reason = fmt.Sprintf("'%s' is being downloaded", movie.Title)
+			return unmatched
+		}
+
+		s.adoptFile(movie, folder, file, path)
+		if err := s.movieRepo.Update(movie); err != nil {
+			unmatched.Reason = fmt.Sprintf("failed to update '%s': %v", movie.Title, err)
+			return unmatched
+		}
+		byPath[filepath.Clean(file.Name)] = movie
+		s.logAdopted(movie)
+		s.analyze(movie)
+		s.update(folder.ID, func(scan *models.LibraryScan) { scan.Adopted++ })
+		return nil
+	}
+
+	movie, err := s.tmdb.GetMovie(match.ID)
+	if err != nil {
+		unmatched.Reason = fmt.Sprintf("failed to fetch TMDB movie %d: %v", match.ID, err)
+		return unmatched
+	}
+	s.adoptFile(movie, folder, file, path)
+	if err := s.movieRepo.Create(movie); err != nil {
+		unmatched.Reason = fmt.Sprintf("failed to add '%s': %v", movie.Title, err)
+		return unmatched
+	}
+	byPath[filepath.Clean(file.Name)] = movie
+	byTMDBID[movie.TMDBID] = movie
+	s.logAdopted(movie)
+	s.analyze(movie)
+	s.update(folder.ID, func(scan *models.LibraryScan) { scan.Added++ })
+	return nil
+}
+
+// findMovie searches TMDB for a parsed title and year. Without a hit for the
+// year it searches again without one, as release years are sometimes off.
+func (s *LibraryScanner) findMovie(title string, year int) (*services.TMDBSearchResult, string) {
+	results, err := s.tmdb.SearchMovies(title, year)
+	if err == nil && len(results) == 0 && year != 0 {
+		results, err = s.tmdb.SearchMovies(title, 0)
+	}
+	if err != nil {
+		return nil, fmt.Sprintf("TMDB search failed: %v", err)
+	}
+	return matchSearchResult(results, title, year)
+}
+
+// adoptFile points movie at a file found in folder and marks it ready
+func (s *LibraryScanner) adoptFile(movie *models.Movie, folder *models.RootFolder, file releaseFile, entryPath string) {
+	movie.FilePath = file.Name
+	movie.FileSize = file.Size
+	movie.Quality = extractQuality(filepath.Base(file.Name))
+	if movie.Quality == "Unknown" {
+		movie.Quality = extractQuality(filepath.Base(entryPath))
+	}
+	movie.RootFolderID = folder.ID
+	movie.Status = models.StatusReady
+}
+
+// logAdopted records that a movie's file was found by a scan
+func (s *LibraryScanner) logAdopted(movie *models.Movie) {
+	log.Printf("Adopted '%s' (%d) from %s", movie.Title, movie.Year, movie.FilePath)
+	s.bus.Publish(movie.ID, models.EventImported,
+		fmt.Sprintf("Found existing file '%s'", filepath.Base(movie.FilePath)),
+		models.ImportDetails{Path: movie.FilePath, Size: movie.FileSize, Adopted: true})
+}
+
+// analyze probes an adopted file, if media analysis is configured
+func (s *LibraryScanner) analyze(movie *models.Movie) {
+	if s.mediaAnalyzer == nil {
+		return
+	}
+	if _, err := s.mediaAnalyzer.Analyze(movie); err != nil {
+		log.Printf("Failed to probe '%s': %v", movie.Title, err)
+	}
+}
+
+// update changes the registered scan of a root folder under the lock
+func (s *LibraryScanner) update(folderID int, change func(scan *models.LibraryScan)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if scan, ok := s.scans[folderID]; ok {
+		change(scan)
+	}
+}
+
+// copyLibraryScan returns a copy of scan that shares no memory with it
+func copyLibraryScan(scan *models.LibraryScan) models.LibraryScan {
+	result := *scan
+	result.Unmatched = append([]models.UnmatchedPath{}, scan.Unmatched...)
+	if scan.FinishedAt != nil {
+		finishedAt := *scan.FinishedAt
+		result.FinishedAt = &finishedAt
+	}
+	return result
+}
+
+// matchSearchResult picks the search result whose title matches and whose
+// year is closest, within a year. It returns why nothing matched otherwise.
+func matchSearchResult(results []services.TMDBSearchResult, title string, year int) (*services.TMDBSearchResult, string) {
+	if len(results) == 0 {
+		return nil, "no TMDB results"
+	}
+
+	want := normalizeTitle(title)
+	var sameTitle []*services.TMDBSearchResult
+	for i := range results {
+		if normalizeTitle(results[i].Title) == want || normalizeTitle(results[i].OriginalTitle) == want {
+			sameTitle = append(sameTitle, &results[i])
+		}
+	}
+	if len(sameTitle) == 0 {
+		return nil, fmt.Sprintf("no TMDB result titled '%s'", title)
+	}
+
+	if year == 0 {
+		if len(sameTitle) > 1 {
+			return nil, fmt.Sprintf("%d TMDB results titled '%s' and no year to tell them apart", len(sameTitle), title)
+		}
+		return sameTitle[0], ""
+	}
+
+	// Results are in relevance order, so the first one at the closest year wins
+	for _, offset := range []int{0, 1, -1} {
+		for _, result := range sameTitle {
+			if result.Year() == year+offset {
+				return result, ""
+			}
+		}
+	}
+	return nil, fmt.Sprintf("no TMDB result titled '%s' from around %d", title, year)
+}
+
+// parseMovieName extracts the title and year from a folder or file name such
+// as "Title (2010)", "Title.2010.1080p.BluRay.x264" or "Title [2010].mkv".
+// The year is 0 if the name does not contain one.
+func parseMovieName(name string) (string, int) {
+	if ext := filepath.Ext(name); isVideoFile(name) || isSubtitleFile(name) {
+		name = strings.TrimSuffix(name, ext)
+	}
+
+	title, year := name, 0
+	// The last year wins, so "2001 A Space Odyssey (1968)" keeps its title
+	matches := yearPattern.FindAllStringIndex(name, -1)
+	for i := len(matches) - 1; i >= 0; i-- {
+		start, end := matches[i][0], matches[i][1]
+		if !yearBoundary(name[:start], true) || !yearBoundary(name[end:], false) ||
+			strings.TrimFunc(name[:start], isNameSeparator) == "" {
+			continue
+		}
+		title = name[:start]
+		year, _ = strconv.Atoi(name[start:end])
+		break
+	}
+	if year == 0 {
+		if loc := releaseTagPattern.FindStringIndex(name); loc != nil {
+			title = name[:loc[0]]
+		}
+	}
+
+	// Release names separate words with dots, folder names with spaces
+	if !strings.Contains(title, " ") {
+		title = strings.ReplaceAll(title, ".", " ")
+	}
+	title = strings.ReplaceAll(title, "_", " ")
+	title = strings.TrimFunc(title, isNameSeparator)
+	return strings.Join(strings.Fields(title), " "), year
+}
+
+// yearBoundary reports whether the text before (or after) a year ends (or
+// starts) with a separator or bracket
+func yearBoundary(text string, before bool) bool {
+	if text == "" {
+		return true
+	}
+	r := rune(text[0])
+	if before {
+		r = rune(text[len(text)-1])
+	}
+	return isNameSeparator(r) || r == ')' || r == ']' || r == '}'
+}
+
+// isNameSeparator reports whether r separates the parts of a release name
+func isNameSeparator(r rune) bool {
+	return unicode.IsSpace(r) || strings.ContainsRune(".-_([{", r)
+}
+
+// normalizeTitle reduces a title to lowercase letters and digits for comparison
+func normalizeTitle(title string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(strings.ReplaceAll(title, "&", "and")) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
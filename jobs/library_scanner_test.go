@@ -0,0 +1,184 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"media/database"
+	"media/models"
+	"media/repository"
+	"media/services"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeTMDB answers searches from a fixed catalogue
+type fakeTMDB struct {
+	movies   []services.TMDBSearchResult
+	searches int
+}
+
+func (f *fakeTMDB) SearchMovies(query string, year int) ([]services.TMDBSearchResult, error) {
+	f.searches++
+	var results []services.TMDBSearchResult
+	for _, movie := range f.movies {
+		if normalizeTitle(movie.Title) == normalizeTitle(query) && (year == 0 || movie.Year() == year) {
+			results = append(results, movie)
+		}
+	}
+	return results, nil
+}
+
+func (f *fakeTMDB) GetMovie(tmdbID int) (*models.Movie, error) {
+	for _, movie := range f.movies {
+		if movie.ID == tmdbID {
+			return &models.Movie{Title: movie.Title, TMDBID: movie.ID, Year: movie.Year(), Status: models.StatusWanted}, nil
+		}
+	}
+	return nil, fmt.Errorf("movie %d not found", tmdbID)
+}
+
+func TestParseMovieName(t *testing.T) {
+	tests := []struct {
+		name  string
+		title string
+		year  int
+	}{
+		{"The Matrix (1999)", "The Matrix", 1999},
+		{"The.Matrix.1999.1080p.BluRay.x264-GROUP", "The Matrix", 1999},
+		{"Blade_Runner_2049_2017_2160p.mkv", "Blade Runner 2049", 2017},
+		{"1917 (2019)", "1917", 2019},
+		{"2001 A Space Odyssey [1968]", "2001 A Space Odyssey", 1968},
+		{"Mr. Nobody (2009)", "Mr. Nobody", 2009},
+		{"Heat.1080p.BluRay.mkv", "Heat", 0},
+		{"Amelie", "Amelie", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			title, year := parseMovieName(tt.name)
+			assert.Equal(t, tt.title, title)
+			assert.Equal(t, tt.year, year)
+		})
+	}
+}
+
+func TestMatchSearchResult(t *testing.T) {
+	results := []services.TMDBSearchResult{
+		{ID: 1, Title: "Dune", ReleaseDate: "2021-09-15"},
+		{ID: 2, Title: "Dune", ReleaseDate: "1984-12-14"},
+		{ID: 3, Title: "Dune: Part Two", ReleaseDate: "2024-02-27"},
+	}
+
+	match, _ := matchSearchResult(results, "Dune", 1984)
+	if assert.NotNil(t, match) {
+		assert.Equal(t, 2, match.ID)
+	}
+
+	// Release years may be a year off
+	match, _ = matchSearchResult(results, "Dune", 2022)
+	if assert.NotNil(t, match) {
+		assert.Equal(t, 1, match.ID)
+	}
+
+	match, reason := matchSearchResult(results, "Dune", 0)
+	assert.Nil(t, match)
+	assert.Contains(t, reason, "no year to tell them apart")
+
+	match, _ = matchSearchResult(results, "Dune Part Two", 0)
+	if assert.NotNil(t, match) {
+		assert.Equal(t, 3, match.ID)
+	}
+}
+
+func TestLibraryScanner_Scan(t *testing.T) {
+	testDB, err := database.NewDB(":memory:")
+	assert.NoError(t, err)
+	defer func() { _ = testDB.Close() }()
+	assert.NoError(t, testDB.InitSchema())
+
+	movieRepo := repository.NewMovieRepository(testDB)
+	rootFolderRepo := repository.NewRootFolderRepository(testDB)
+	tmdb := &fakeTMDB{movies: []services.TMDBSearchResult{
+		{ID: 603, Title: "The Matrix", ReleaseDate: "1999-03-30"},
+		{ID: 949, Title: "Heat", ReleaseDate: "1995-12-15"},
+	}}
+	scanner := NewLibraryScanner(movieRepo, nil, nil)
+	scanner.tmdb = tmdb
+
+	library := t.TempDir()
+	folder := &models.RootFolder{Path: library}
+	assert.NoError(t, rootFolderRepo.Create(folder))
+
+	matrix := filepath.Join(library, "The Matrix (1999)", "The.Matrix.1999.1080p.BluRay.x264.mkv")
+	writeTestFile(t, matrix, 4096)
+	writeTestFile(t, filepath.Join(library, "The Matrix (1999)", "Sample", "sample.mkv"), 8192)
+	heat := filepath.Join(library, "Heat.1995.2160p.mkv")
+	writeTestFile(t, heat, 2048)
+	writeTestFile(t, filepath.Join(library, "Unknown Film (2003)", "film.mkv"), 1024)
+	writeTestFile(t, filepath.Join(library, "notes.txt"), 10)
+
+	// Heat is already wanted, so its file is adopted rather than added again
+	wanted := &models.Movie{Title: "Heat", Year: 1995, TMDBID: 949, Status: models.StatusWanted}
+	assert.NoError(t, movieRepo.Create(wanted))
+
+	scan, err := scanner.Scan(context.Background(), folder)
+	assert.NoError(t, err)
+	assert.Equal(t, models.LibraryScanCompleted, scan.Status)
+	assert.Equal(t, 3, scan.Scanned)
+	assert.Equal(t, 1, scan.Added)
+	assert.Equal(t, 1, scan.Adopted)
+	if assert.Len(t, scan.Unmatched, 1) {
+		assert.Equal(t, "Unknown Film", scan.Unmatched[0].Title)
+		assert.Equal(t, 2003, scan.Unmatched[0].Year)
+	}
+
+	movies, err := movieRepo.GetAll()
+	assert.NoError(t, err)
+	assert.Len(t, movies, 2)
+	for _, movie := range movies {
+		assert.Equal(t, models.StatusReady, movie.Status)
+		assert.Equal(t, folder.ID, movie.RootFolderID)
+		switch movie.TMDBID {
+		case 603:
+			assert.Equal(t, matrix, movie.FilePath)
+			assert.Equal(t, int64(4096), movie.FileSize)
+			assert.Equal(t, "1080p", movie.Quality)
+		case 949:
+			assert.Equal(t, wanted.ID, movie.ID)
+			assert.Equal(t, heat, movie.FilePath)
+			assert.Equal(t, "4K", movie.Quality)
+		}
+	}
+
+	// A rescan finds nothing new
+	scan, err = scanner.Scan(context.Background(), folder)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, scan.Added)
+	assert.Equal(t, 0, scan.Adopted)
+	assert.Equal(t, 2, scan.Existing)
+	assert.Len(t, scan.Unmatched, 1)
+
+	movies, err = movieRepo.GetAll()
+	assert.NoError(t, err)
+	assert.Len(t, movies, 2)
+
+	last, ok := scanner.LastScan(folder.ID)
+	assert.True(t, ok)
+	assert.Equal(t, scan.Existing, last.Existing)
+}
+
+func TestLibraryScanner_RejectsConcurrentScans(t *testing.T) {
+	scanner := NewLibraryScanner(nil, nil, nil)
+
+	_, err := scanner.Start(1)
+	assert.NoError(t, err)
+	_, err = scanner.Start(1)
+	assert.ErrorIs(t, err, ErrScanInProgress)
+
+	// Other folders can be scanned at the same time
+	_, err = scanner.Start(2)
+	assert.NoError(t, err)
+}
@@ -11,21 +11,75 @@ import (
 // JobManager handles background job execution
 type JobManager struct {
 	torrentSearchJob *TorrentSearchJob
+	periodicTasks    []periodicTask
 	ctx              context.Context
 	cancel           context.CancelFunc
 	wg               sync.WaitGroup
 	running          bool
 	mu               sync.RWMutex
+	active           *activeJobs
+}
+
+// ActiveJob describes a job that is running for a movie right now
+type ActiveJob struct {
+	Name      string    `json:"name"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// activeJobs tracks which movies have a job running
+type activeJobs struct {
+	mu   sync.Mutex
+	jobs map[int]ActiveJob
+}
+
+// start marks a job as running for a movie and returns the function that
+// marks it finished. A nil tracker tracks nothing.
+func (a *activeJobs) start(movieID int, name string) func() {
+	if a == nil {
+		return func() {}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	job := ActiveJob{Name: name, StartedAt: time.Now()}
+	a.jobs[movieID] = job
+	return func() {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		if a.jobs[movieID] == job {
+			delete(a.jobs, movieID)
+		}
+	}
+}
+
+// get returns the job running for a movie, if any
+func (a *activeJobs) get(movieID int) (ActiveJob, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	job, ok := a.jobs[movieID]
+	return job, ok
+}
+
+// periodicTask is a background task run on a fixed interval
+type periodicTask struct {
+	name     string
+	interval time.Duration
+	run      func(ctx context.Context) error
 }
 
 // NewJobManager creates a new job manager
 func NewJobManager(torrentSearchJob *TorrentSearchJob) *JobManager {
 	ctx, cancel := context.WithCancel(context.Background())
+	active := &activeJobs{jobs: make(map[int]ActiveJob)}
+	if torrentSearchJob != nil {
+		torrentSearchJob.active = active
+	}
 	return &JobManager{
 		torrentSearchJob: torrentSearchJob,
 		ctx:              ctx,
 		cancel:           cancel,
 		running:          false,
+		active:           active,
 	}
 }
 
@@ -45,6 +99,26 @@ func (jm *JobManager) Start() {
 	// Start periodic torrent search job
 	jm.wg.Add(1)
 	go jm.runPeriodicTorrentSearch()
+
+	// Start any other registered periodic tasks
+	for _, task := range jm.periodicTasks {
+		jm.wg.Add(1)
+		go jm.runPeriodicTask(task)
+	}
+}
+
+// AddPeriodicTask registers a task to run every interval while the manager is
+// running. The task's context is cancelled when the manager stops. Tasks must
+// be added before Start.
+func (jm *JobManager) AddPeriodicTask(name string, interval time.Duration, run func(ctx context.Context) error) {
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+
+	jm.periodicTasks = append(jm.periodicTasks, periodicTask{
+		name:     name,
+		interval: interval,
+		run:      run,
+	})
 }
 
 // Stop stops the job manager
@@ -82,12 +156,32 @@ func (jm *JobManager) TriggerTorrentSearchForMovie(movieID int) {
 	jm.wg.Add(1)
 	go func() {
 		defer jm.wg.Done()
-		if err := jm.torrentSearchJob.SearchForMovie(movieID); err != nil {
+		if err := jm.torrentSearchJob.SearchForMovie(jm.ctx, movieID); err != nil {
 			log.Printf("Failed to search for torrents for movie %d: %v", movieID, err)
 		}
 	}()
 }
 
+// RunTask runs a one-off task in the background. Its context is cancelled
+// when the manager stops, which waits for it to return.
+func (jm *JobManager) RunTask(name string, run func(ctx context.Context) error) {
+	jm.wg.Add(1)
+	go func() {
+		defer jm.wg.Done()
+		if err := run(jm.ctx); err != nil {
+			log.Printf("Task %s failed: %v", name, err)
+		}
+	}()
+}
+
+// ActiveJob returns the job running for a movie right now, if any
+func (jm *JobManager) ActiveJob(movieID int) (ActiveJob, bool) {
+	if jm == nil {
+		return ActiveJob{}, false
+	}
+	return jm.active.get(movieID)
+}
+
 // CancelJobsForMovie cancels any active jobs for a specific movie
 func (jm *JobManager) CancelJobsForMovie(movieID int) {
 	jm.mu.RLock()
@@ -132,7 +226,7 @@ func (jm *JobManager) runPeriodicTorrentSearch() {
 	}
 
 	// Run immediately on startup
-	if err := jm.torrentSearchJob.ProcessMovieQueue(); err != nil {
+	if err := jm.torrentSearchJob.ProcessMovieQueue(jm.ctx); err != nil {
 		log.Printf("Initial torrent search failed: %v", err)
 	}
 
@@ -147,9 +241,29 @@ func (jm *JobManager) runPeriodicTorrentSearch() {
 			return
 		case <-ticker.C:
 			log.Println("Running periodic torrent search...")
-			if err := jm.torrentSearchJob.ProcessMovieQueue(); err != nil {
+			if err := jm.torrentSearchJob.ProcessMovieQueue(jm.ctx); err != nil {
 				log.Printf("Periodic torrent search failed: %v", err)
 			}
 		}
 	}
 }
+
+// runPeriodicTask runs a registered task on its interval until the manager stops
+func (jm *JobManager) runPeriodicTask(task periodicTask) {
+	defer jm.wg.Done()
+
+	ticker := time.NewTicker(task.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-jm.ctx.Done():
+			log.Printf("Periodic task %s stopped", task.name)
+			return
+		case <-ticker.C:
+			if err := task.run(jm.ctx); err != nil {
+				log.Printf("Periodic task %s failed: %v", task.name, err)
+			}
+		}
+	}
+}
@@ -26,7 +26,7 @@ func setupTestJobManager(t *testing.T) (*JobManager, func()) {
 	movieEventRepo := repository.NewMovieEventRepository(testDB)
 
 	// Create a real TorrentSearchJob but with nil services for testing
-	torrentSearchJob := NewTorrentSearchJob(movieRepo, movieEventRepo, nil, nil)
+	torrentSearchJob := NewTorrentSearchJob(movieRepo, newTestBus(movieEventRepo), nil, nil)
 	jm := NewJobManager(torrentSearchJob)
 
 	// Return cleanup function
@@ -304,4 +304,32 @@ func TestJobManager_NilTorrentSearchJob(t *testing.T) {
 
 	// TriggerTorrentSearchForMovie might panic with nil job, which is expected behavior
 	// We don't test it here as it would be a programming error
-}
\ No newline at end of file
+}
+
+func TestJobManager_ActiveJob(t *testing.T) {
+	jm, cleanup := setupTestJobManager(t)
+	defer cleanup()
+
+	_, ok := jm.ActiveJob(1)
+	assert.False(t, ok)
+
+	done := jm.active.start(1, "searching")
+	job, ok := jm.ActiveJob(1)
+	assert.True(t, ok)
+	assert.Equal(t, "searching", job.Name)
+	assert.WithinDuration(t, time.Now(), job.StartedAt, time.Second)
+
+	// A finished job does not clear one that replaced it
+	time.Sleep(time.Millisecond)
+	replaced := jm.active.start(1, "searching")
+	done()
+	_, ok = jm.ActiveJob(1)
+	assert.True(t, ok)
+	replaced()
+	_, ok = jm.ActiveJob(1)
+	assert.False(t, ok)
+
+	var none *JobManager
+	_, ok = none.ActiveJob(1)
+	assert.False(t, ok)
+}
@@ -0,0 +1,89 @@
+package jobs
+
+import (
+	"fmt"
+	"log"
+	"math"
+
+	"media/events"
+	"media/models"
+	"media/repository"
+	"media/services"
+)
+
+// minRuntimeTolerance is the smallest difference between a file's duration
+// and the TMDB runtime that is reported; longer movies get 10% leeway
+const minRuntimeTolerance = 10 * 60 // seconds
+
+// MediaAnalyzer probes movie files for their real streams and flags files that
+// are not what their release name claimed
+type MediaAnalyzer struct {
+	movieRepo     *repository.MovieRepository
+	bus           *events.Bus
+	mediaFileRepo *repository.MediaFileRepository
+}
+
+// NewMediaAnalyzer creates a new media analyzer
+func NewMediaAnalyzer(movieRepo *repository.MovieRepository, bus *events.Bus, mediaFileRepo *repository.MediaFileRepository) *MediaAnalyzer {
+	return &MediaAnalyzer{
+		movieRepo:     movieRepo,
+		bus:           bus,
+		mediaFileRepo: mediaFileRepo,
+	}
+}
+
+// Analyze probes the movie's file and stores the result. A resolution that
+// differs from the movie's quality, or a duration far from its runtime, is
+// logged as a media_mismatch event, and the quality is corrected to what the
+// file actually contains.
+func (a *MediaAnalyzer) Analyze(movie *models.Movie) (*models.MediaFile, error) {
+	if movie.FilePath == "" {
+		return nil, fmt.Errorf("movie %d has no file", movie.ID)
+	}
+
+	media, err := services.ProbeMediaFile(movie.FilePath)
+	if err != nil {
+		return nil, err
+	}
+	media.MovieID = movie.ID
+	if err := a.mediaFileRepo.Save(media); err != nil {
+		return nil, err
+	}
+
+	if mismatches := mediaMismatches(movie, media); mismatches.Any() {
+		details := models.MediaMismatchDetails{Path: media.Path, Mismatches: mismatches}
+		if mismatches.Resolution != nil {
+			movie.Quality = media.Resolution
+			if err := a.movieRepo.Update(movie); err != nil {
+				log.Printf("Failed to update quality of '%s': %v", movie.Title, err)
+			}
+		}
+		a.bus.Publish(movie.ID, models.EventMediaMismatch,
+			fmt.Sprintf("'%s' is not what its release claimed", movie.Title), details)
+	}
+
+	return media, nil
+}
+
+// mediaMismatches compares a probed file with the movie it belongs to and
+// returns the claimed and actual value of every property that differs
+func mediaMismatches(movie *models.Movie, media *models.MediaFile) models.MediaMismatches {
+	var mismatches models.MediaMismatches
+
+	if movie.Quality != "" && movie.Quality != "Unknown" && media.Resolution != "" && movie.Quality != media.Resolution {
+		mismatches.Resolution = &models.ResolutionMismatch{Claimed: movie.Quality, Actual: media.Resolution}
+	}
+
+	if movie.Runtime > 0 && media.Duration > 0 {
+		expected := float64(movie.Runtime * 60)
+		tolerance := math.Max(minRuntimeTolerance, expected/10)
+		if math.Abs(media.Duration-expected) > tolerance {
+			mismatches.Runtime = &models.RuntimeMismatch{
+				Claimed: movie.Runtime,
+				Actual:  int(math.Round(media.Duration / 60)),
+			}
+		}
+	}
+
+	return mismatches
+}
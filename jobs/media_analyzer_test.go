@@ -0,0 +1,163 @@
+package jobs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"media/database"
+	"media/models"
+	"media/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupTestMediaAnalyzer(t *testing.T) (*MediaAnalyzer, *repository.MovieRepository,
+	*repository.MovieEventRepository, *repository.MediaFileRepository, func()) {
+	testDB, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	if err := testDB.InitSchema(); err != nil {
+		t.Fatalf("Failed to initialize test schema: %v", err)
+	}
+
+	movieRepo := repository.NewMovieRepository(testDB)
+	movieEventRepo := repository.NewMovieEventRepository(testDB)
+	mediaFileRepo := repository.NewMediaFileRepository(testDB)
+
+	cleanup := func() {
+		if err := testDB.Close(); err != nil {
+			t.Logf("Failed to close test database: %v", err)
+		}
+	}
+
+	return NewMediaAnalyzer(movieRepo, newTestBus(movieEventRepo), mediaFileRepo), movieRepo, movieEventRepo, mediaFileRepo, cleanup
+}
+
+// writeTestMP4 writes an MP4 file with one video track of the given size and
+// duration in seconds
+func writeTestMP4(t *testing.T, path string, width, height int, duration uint32) {
+	box := func(boxType string, payload ...[]byte) []byte {
+		data := bytes.Join(payload, nil)
+		header := make([]byte, 8)
+		binary.BigEndian.PutUint32(header, uint32(8+len(data)))
+		copy(header[4:], boxType)
+		return append(header, data...)
+	}
+
+	mvhd := make([]byte, 100)
+	binary.BigEndian.PutUint32(mvhd[12:], 1)
+	binary.BigEndian.PutUint32(mvhd[16:], duration)
+
+	hdlr := make([]byte, 25)
+	copy(hdlr[8:], "vide")
+	stsd := make([]byte, 8)
+	binary.BigEndian.PutUint32(stsd[4:], 1)
+	visual := make([]byte, 78)
+	binary.BigEndian.PutUint16(visual[24:], uint16(width))
+	binary.BigEndian.PutUint16(visual[26:], uint16(height))
+
+	data := bytes.Join([][]byte{
+		box("ftyp", []byte("isom\x00\x00\x02\x00isom")),
+		box("moov",
+			box("mvhd", mvhd),
+			box("trak", box("mdia",
+				box("mdhd", make([]byte, 24)),
+				box("hdlr", hdlr),
+				box("minf", box("stbl", box("stsd", stsd, box("avc1", visual)))),
+			)),
+		),
+	}, nil)
+
+	assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	assert.NoError(t, os.WriteFile(path, data, 0o644))
+}
+
+func TestMediaAnalyzer_Analyze(t *testing.T) {
+	analyzer, movieRepo, movieEventRepo, mediaFileRepo, cleanup := setupTestMediaAnalyzer(t)
+	defer cleanup()
+
+	path := filepath.Join(t.TempDir(), "Heat (1995).mp4")
+	writeTestMP4(t, path, 1920, 800, 170*60)
+
+	movie := createReadyMovie(t, movieRepo, "Heat", path, 1024, 0)
+	movie.Quality = "1080p"
+	movie.Runtime = 170
+	assert.NoError(t, movieRepo.Update(movie))
+
+	media, err := analyzer.Analyze(movie)
+	assert.NoError(t, err)
+	if assert.NotNil(t, media) {
+		assert.Equal(t, movie.ID, media.MovieID)
+		assert.Equal(t, "1080p", media.Resolution)
+		assert.Equal(t, "h264", media.VideoCodec)
+	}
+
+	stored, err := mediaFileRepo.GetByMovieID(movie.ID)
+	assert.NoError(t, err)
+	if assert.NotNil(t, stored) {
+		assert.Equal(t, 1920, stored.Width)
+	}
+
+	// The file matches its release: nothing to flag
+	events, err := movieEventRepo.GetByMovieID(movie.ID)
+	assert.NoError(t, err)
+	for _, event := range events {
+		assert.NotEqual(t, models.EventMediaMismatch, event.Type)
+	}
+}
+
+func TestMediaAnalyzer_AnalyzeMismatch(t *testing.T) {
+	analyzer, movieRepo, movieEventRepo, _, cleanup := setupTestMediaAnalyzer(t)
+	defer cleanup()
+
+	// Released as 1080p, but upscaled from 720p and cut short
+	path := filepath.Join(t.TempDir(), "Heat.1995.1080p.BluRay.x264.mp4")
+	writeTestMP4(t, path, 1280, 534, 95*60)
+
+	movie := createReadyMovie(t, movieRepo, "Heat", path, 1024, 0)
+	movie.Quality = "1080p"
+	movie.Runtime = 170
+	assert.NoError(t, movieRepo.Update(movie))
+
+	_, err := analyzer.Analyze(movie)
+	assert.NoError(t, err)
+
+	updated, err := movieRepo.GetByID(movie.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "720p", updated.Quality)
+
+	events, err := movieEventRepo.GetByMovieID(movie.ID)
+	assert.NoError(t, err)
+	var mismatch *models.MovieEvent
+	for i := range events {
+		if events[i].Type == models.EventMediaMismatch {
+			mismatch = &events[i]
+		}
+	}
+	if assert.NotNil(t, mismatch) {
+		var details models.MediaMismatchDetails
+		assert.NoError(t, mismatch.DecodeDetails(&details))
+		assert.Equal(t, &models.ResolutionMismatch{Claimed: "1080p", Actual: "720p"}, details.Mismatches.Resolution)
+		assert.Equal(t, &models.RuntimeMismatch{Claimed: 170, Actual: 95}, details.Mismatches.Runtime)
+	}
+}
+
+func TestMediaMismatches_Tolerance(t *testing.T) {
+	movie := &models.Movie{Quality: "Unknown", Runtime: 120}
+
+	// Within 10 minutes of the runtime, and an unknown claimed quality
+	media := &models.MediaFile{Resolution: "720p", Duration: 128 * 60}
+	assert.Empty(t, mediaMismatches(movie, media))
+
+	// Long movies get 10% leeway
+	movie.Runtime = 200
+	media.Duration = 215 * 60
+	assert.Empty(t, mediaMismatches(movie, media))
+
+	media.Duration = 225 * 60
+	assert.NotNil(t, mediaMismatches(movie, media).Runtime)
+}
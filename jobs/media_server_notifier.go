@@ -0,0 +1,53 @@
+package jobs
+
+import (
+	"context"
+	"log"
+
+	"media/models"
+	"media/repository"
+	"media/services"
+)
+
+// MediaServerNotifier tells the enabled media servers about changed movie
+// files, so their libraries pick them up without a full scan
+type MediaServerNotifier struct {
+	mediaServerRepo *repository.MediaServerRepository
+	newClient       func(server models.MediaServer) (services.MediaServerClient, error)
+}
+
+// NewMediaServerNotifier creates a new media server notifier
+func NewMediaServerNotifier(mediaServerRepo *repository.MediaServerRepository) *MediaServerNotifier {
+	return &MediaServerNotifier{
+		mediaServerRepo: mediaServerRepo,
+		newClient:       services.NewMediaServerClient,
+	}
+}
+
+// Notify asks every enabled media server to refresh the folder of the movie
+// file at path. Servers that cannot be reached are logged and skipped. A nil
+// notifier does nothing.
+func (n *MediaServerNotifier) Notify(ctx context.Context, path string, change services.MediaChange) {
+	if n == nil || path == "" {
+		return
+	}
+
+	servers, err := n.mediaServerRepo.GetEnabled()
+	if err != nil {
+		log.Printf("Failed to get media servers: %v", err)
+		return
+	}
+
+	for _, server := range servers {
+		client, err := n.newClient(server)
+		if err != nil {
+			log.Printf("Skipping media server '%s': %v", server.Name, err)
+			continue
+		}
+		if err := client.Refresh(ctx, path, change); err != nil {
+			log.Printf("Failed to refresh %s on media server '%s': %v", path, server.Name, err)
+			continue
+		}
+		log.Printf("Asked media server '%s' to refresh %s (%s)", server.Name, path, change)
+	}
+}
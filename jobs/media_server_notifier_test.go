@@ -0,0 +1,69 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"media/database"
+	"media/models"
+	"media/repository"
+	"media/services"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeMediaServer records the refreshes a media server is asked for
+type fakeMediaServer struct {
+	name      string
+	refreshed *[]string
+	fail      bool
+}
+
+func (f *fakeMediaServer) Test(context.Context) error { return nil }
+
+func (f *fakeMediaServer) Refresh(_ context.Context, path string, change services.MediaChange) error {
+	if f.fail {
+		return errors.New("connection refused")
+	}
+	*f.refreshed = append(*f.refreshed, f.name+" "+string(change)+" "+path)
+	return nil
+}
+
+func TestMediaServerNotifier_Notify(t *testing.T) {
+	testDB, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	defer func() {
+		if err := testDB.Close(); err != nil {
+			t.Logf("Failed to close test database: %v", err)
+		}
+	}()
+	if err := testDB.InitSchema(); err != nil {
+		t.Fatalf("Failed to initialize test schema: %v", err)
+	}
+
+	repo := repository.NewMediaServerRepository(testDB)
+	for _, server := range []*models.MediaServer{
+		{Name: "down", Type: models.MediaServerKodi, URL: "http://kodi", Enabled: true},
+		{Name: "jellyfin", Type: models.MediaServerJellyfin, URL: "http://jellyfin", APIKey: "key", Enabled: true},
+		{Name: "disabled", Type: models.MediaServerPlex, URL: "http://plex", APIKey: "token"},
+	} {
+		assert.NoError(t, repo.Create(server))
+	}
+
+	var refreshed []string
+	notifier := NewMediaServerNotifier(repo)
+	notifier.newClient = func(server models.MediaServer) (services.MediaServerClient, error) {
+		return &fakeMediaServer{name: server.Name, refreshed: &refreshed, fail: server.Name == "down"}, nil
+	}
+
+	// A failing server does not keep the others from being told
+	notifier.Notify(context.Background(), "/movies/Heat (1995)/Heat (1995).mkv", services.MediaCreated)
+	assert.Equal(t, []string{"jellyfin created /movies/Heat (1995)/Heat (1995).mkv"}, refreshed)
+
+	// Without a notifier nothing happens
+	var none *MediaServerNotifier
+	none.Notify(context.Background(), "/movies/Heat (1995)/Heat (1995).mkv", services.MediaDeleted)
+}
@@ -0,0 +1,199 @@
+package jobs
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"media/models"
+	"media/repository"
+	"media/services"
+)
+
+// artworkLookup is the part of the TMDB service the metadata writer needs
+type artworkLookup interface {
+	GetArtwork(tmdbID int) (*services.TMDBArtwork, error)
+}
+
+// MetadataWriter writes Kodi style movie.nfo files and poster and fanart
+// images next to movie files, so media servers pick up the right metadata
+// without scraping. It only writes into root folders that have it enabled.
+type MetadataWriter struct {
+	rootFolderRepo *repository.RootFolderRepository
+	tmdb           artworkLookup
+	client         *http.Client
+}
+
+// NewMetadataWriter creates a new metadata writer
+func NewMetadataWriter(rootFolderRepo *repository.RootFolderRepository, tmdbService *services.TMDBService) *MetadataWriter {
+	writer := &MetadataWriter{
+		rootFolderRepo: rootFolderRepo,
+		client:         &http.Client{Timeout: time.Minute},
+	}
+	if tmdbService != nil {
+		writer.tmdb = tmdbService
+	}
+	return writer
+}
+
+// movieNFO is the movie.nfo document read by Kodi, Jellyfin and Emby
+type movieNFO struct {
+	XMLName   xml.Name      `xml:"movie"`
+	Title     string        `xml:"title"`
+	Year      int           `xml:"year,omitempty"`
+	Plot      string        `xml:"plot,omitempty"`
+	Runtime   int           `xml:"runtime,omitempty"`
+	Rating    float64       `xml:"rating,omitempty"`
+	Ratings   *nfoRatings   `xml:"ratings,omitempty"`
+	Genres    []string      `xml:"genre"`
+	Director  string        `xml:"director,omitempty"`
+	TMDBID    int           `xml:"tmdbid,omitempty"`
+	IMDBID    string        `xml:"imdbid,omitempty"`
+	UniqueIDs []nfoUniqueID `xml:"uniqueid"`
+}
+
+type nfoRatings struct {
+	Ratings []nfoRating `xml:"rating"`
+}
+
+type nfoRating struct {
+	Name    string  `xml:"name,attr"`
+	Max     int     `xml:"max,attr"`
+	Default bool    `xml:"default,attr"`
+	Value   float64 `xml:"value"`
+}
+
+type nfoUniqueID struct {
+	Type    string `xml:"type,attr"`
+	Default bool   `xml:"default,attr,omitempty"`
+	Value   string `xml:",chardata"`
+}
+
+// Write writes the NFO file and artwork of a movie that has a file in a root
+// folder with metadata enabled, replacing earlier ones. It returns the paths
+// written, which is empty when the root folder does not want metadata.
+// Artwork that cannot be downloaded is skipped rather than failing the NFO.
+func (w *MetadataWriter) Write(movie *models.Movie) ([]string, error) {
+	if movie.FilePath == "" || movie.RootFolderID == 0 || w.rootFolderRepo == nil {
+		return nil, nil
+	}
+	folder, err := w.rootFolderRepo.GetByID(movie.RootFolderID)
+	if err != nil {
+		return nil, err
+	}
+	if !folder.WriteMetadata {
+		return nil, nil
+	}
+
+	nfoPath, posterPath, fanartPath := metadataPaths(movie.FilePath, folder.Path)
+
+	nfo, err := xml.MarshalIndent(newMovieNFO(movie), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode NFO: %w", err)
+	}
+	if err := services.WriteFileAtomic(nfoPath, append([]byte(xml.Header), append(nfo, '\n')...)); err != nil {
+		return nil, err
+	}
+	written := []string{nfoPath}
+
+	posterURL, fanartURL := movie.Poster, ""
+	if w.tmdb != nil && movie.TMDBID != 0 {
+		artwork, err := w.tmdb.GetArtwork(movie.TMDBID)
+		if err != nil {
+			log.Printf("Failed to look up artwork for '%s': %v", movie.Title, err)
+		} else {
+			if artwork.PosterURL != "" {
+				posterURL = artwork.PosterURL
+			}
+			fanartURL = artwork.FanartURL
+		}
+	}
+
+	for _, image := range []struct{ url, path string }{{posterURL, posterPath}, {fanartURL, fanartPath}} {
+		if image.url == "" {
+			continue
+		}
+		if err := w.download(image.url, image.path); err != nil {
+			log.Printf("Failed to save %s for '%s': %v", filepath.Base(image.path), movie.Title, err)
+			continue
+		}
+		written = append(written, image.path)
+	}
+
+	return written, nil
+}
+
+// download saves the image at url to path
+func (w *MetadataWriter) download(url, path string) error {
+	resp, err := w.client.Get(url)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Printf("Failed to close response body: %v", err)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned status %d", url, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	return services.WriteFileAtomic(path, data)
+}
+
+// metadataPaths returns where the NFO, poster and fanart of a movie file go.
+// A movie in its own folder gets movie.nfo, poster.jpg and fanart.jpg; a file
+// directly in the root folder gets names based on its own, so movies sharing
+// the folder do not overwrite each other's.
+func metadataPaths(filePath, rootPath string) (nfo, poster, fanart string) {
+	dir := filepath.Dir(filePath)
+	if filepath.Clean(dir) == filepath.Clean(rootPath) {
+		base := strings.TrimSuffix(filePath, filepath.Ext(filePath))
+		return base + ".nfo", base + "-poster.jpg", base + "-fanart.jpg"
+	}
+	return filepath.Join(dir, "movie.nfo"), filepath.Join(dir, "poster.jpg"), filepath.Join(dir, "fanart.jpg")
+}
+
+// newMovieNFO builds the NFO document of a movie
+func newMovieNFO(movie *models.Movie) movieNFO {
+	nfo := movieNFO{
+		Title:    movie.Title,
+		Year:     movie.Year,
+		Plot:     movie.Description,
+		Runtime:  movie.Runtime,
+		Rating:   movie.Rating,
+		Director: movie.Director,
+		TMDBID:   movie.TMDBID,
+		IMDBID:   movie.IMDBID,
+	}
+	if movie.Rating > 0 {
+		nfo.Ratings = &nfoRatings{Ratings: []nfoRating{
+			{Name: "themoviedb", Max: 10, Default: true, Value: movie.Rating},
+		}}
+	}
+	for _, genre := range strings.Split(movie.Genre, ",") {
+		if genre = strings.TrimSpace(genre); genre != "" {
+			nfo.Genres = append(nfo.Genres, genre)
+		}
+	}
+	if movie.TMDBID != 0 {
+		nfo.UniqueIDs = append(nfo.UniqueIDs,
+			nfoUniqueID{Type: "tmdb", Default: true, Value: strconv.Itoa(movie.TMDBID)})
+	}
+	if movie.IMDBID != "" {
+		nfo.UniqueIDs = append(nfo.UniqueIDs,
+			nfoUniqueID{Type: "imdb", Default: movie.TMDBID == 0, Value: movie.IMDBID})
+	}
+	return nfo
+}
@@ -0,0 +1,141 @@
+package jobs
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"media/database"
+	"media/models"
+	"media/repository"
+	"media/services"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeArtwork serves artwork URLs without calling TMDB
+type fakeArtwork struct {
+	artwork *services.TMDBArtwork
+}
+
+func (f *fakeArtwork) GetArtwork(int) (*services.TMDBArtwork, error) {
+	return f.artwork, nil
+}
+
+func setupTestMetadataWriter(t *testing.T) (*MetadataWriter, *repository.RootFolderRepository, func()) {
+	testDB, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	if err := testDB.InitSchema(); err != nil {
+		t.Fatalf("Failed to initialize test schema: %v", err)
+	}
+
+	rootFolderRepo := repository.NewRootFolderRepository(testDB)
+
+	cleanup := func() {
+		if err := testDB.Close(); err != nil {
+			t.Logf("Failed to close test database: %v", err)
+		}
+	}
+
+	return NewMetadataWriter(rootFolderRepo, nil), rootFolderRepo, cleanup
+}
+
+func TestMetadataWriter_Write(t *testing.T) {
+	writer, rootFolderRepo, cleanup := setupTestMetadataWriter(t)
+	defer cleanup()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing.jpg" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte("image " + r.URL.Path))
+	}))
+	defer server.Close()
+	writer.tmdb = &fakeArtwork{&services.TMDBArtwork{
+		PosterURL: server.URL + "/poster-original.jpg",
+		FanartURL: server.URL + "/backdrop.jpg",
+	}}
+
+	library := t.TempDir()
+	folder := &models.RootFolder{Path: library}
+	assert.NoError(t, rootFolderRepo.Create(folder))
+
+	movieDir := filepath.Join(library, "Heat (1995)")
+	writeTestFile(t, filepath.Join(movieDir, "Heat (1995).mkv"), 1024)
+	movie := &models.Movie{
+		Title:        "Heat",
+		Year:         1995,
+		Description:  "A group of high-end professional thieves & a detective",
+		Rating:       7.9,
+		Runtime:      170,
+		Genre:        "Action, Crime, Drama",
+		Director:     "Michael Mann",
+		TMDBID:       949,
+		IMDBID:       "tt0113277",
+		Poster:       server.URL + "/poster-w500.jpg",
+		FilePath:     filepath.Join(movieDir, "Heat (1995).mkv"),
+		RootFolderID: folder.ID,
+	}
+
+	// Metadata is off by default
+	written, err := writer.Write(movie)
+	assert.NoError(t, err)
+	assert.Empty(t, written)
+	assert.NoFileExists(t, filepath.Join(movieDir, "movie.nfo"))
+
+	folder.WriteMetadata = true
+	assert.NoError(t, rootFolderRepo.Update(folder))
+
+	written, err = writer.Write(movie)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(movieDir, "movie.nfo"),
+		filepath.Join(movieDir, "poster.jpg"),
+		filepath.Join(movieDir, "fanart.jpg"),
+	}, written)
+
+	nfo, err := os.ReadFile(filepath.Join(movieDir, "movie.nfo"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(nfo), "<title>Heat</title>")
+	assert.Contains(t, string(nfo), "<year>1995</year>")
+	assert.Contains(t, string(nfo), "<plot>A group of high-end professional thieves &amp; a detective</plot>")
+	assert.Contains(t, string(nfo), "<genre>Crime</genre>")
+	assert.Contains(t, string(nfo), "<director>Michael Mann</director>")
+	assert.Contains(t, string(nfo), `<uniqueid type="tmdb" default="true">949</uniqueid>`)
+	assert.Contains(t, string(nfo), `<uniqueid type="imdb">tt0113277</uniqueid>`)
+	assert.Contains(t, string(nfo), `<rating name="themoviedb" max="10" default="true">`)
+
+	poster, err := os.ReadFile(filepath.Join(movieDir, "poster.jpg"))
+	assert.NoError(t, err)
+	assert.Equal(t, "image /poster-original.jpg", string(poster))
+
+	// Artwork that cannot be fetched does not fail the NFO
+	writer.tmdb = &fakeArtwork{&services.TMDBArtwork{FanartURL: server.URL + "/missing.jpg"}}
+	written, err = writer.Write(movie)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(movieDir, "movie.nfo"),
+		filepath.Join(movieDir, "poster.jpg"),
+	}, written)
+	poster, err = os.ReadFile(filepath.Join(movieDir, "poster.jpg"))
+	assert.NoError(t, err)
+	assert.Equal(t, "image /poster-w500.jpg", string(poster))
+}
+
+func TestMetadataPaths(t *testing.T) {
+	nfo, poster, fanart := metadataPaths("/movies/Heat (1995)/Heat (1995).mkv", "/movies")
+	assert.Equal(t, "/movies/Heat (1995)/movie.nfo", nfo)
+	assert.Equal(t, "/movies/Heat (1995)/poster.jpg", poster)
+	assert.Equal(t, "/movies/Heat (1995)/fanart.jpg", fanart)
+
+	// Files loose in the root folder get their own names
+	nfo, poster, fanart = metadataPaths("/movies/Heat.1995.mkv", "/movies/")
+	assert.Equal(t, "/movies/Heat.1995.nfo", nfo)
+	assert.Equal(t, "/movies/Heat.1995-poster.jpg", poster)
+	assert.Equal(t, "/movies/Heat.1995-fanart.jpg", fanart)
+}
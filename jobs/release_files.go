@@ -0,0 +1,193 @@
+package jobs
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// minBytesPerRuntimeMinute is the smallest size per minute of the movie's
+// runtime the main file may have; anything smaller is a sample or a fake
+const minBytesPerRuntimeMinute = 2 << 20
+
+// samplePattern matches sample clips by file or folder name
+var samplePattern = regexp.MustCompile(`(?i)(^|[^a-z0-9])samples?([^a-z0-9]|$)`)
+
+// extrasPattern matches bonus material shipped alongside the main feature
+var extrasPattern = regexp.MustCompile(`(?i)(^|[^a-z0-9])(trailers?|teasers?|featurettes?|extras|bonus|` +
+	`behind[^a-z0-9]?the[^a-z0-9]?scenes|deleted[^a-z0-9]?scenes?|interviews?|making[^a-z0-9]?of)([^a-z0-9]|$)`)
+
+// subtitleExtensions lists the subtitle formats imported next to the movie
+var subtitleExtensions = map[string]bool{
+	".srt": true, ".ass": true, ".ssa": true, ".sub": true, ".idx": true, ".vtt": true, ".sup": true,
+}
+
+// releaseSelection sorts the files of a finished download by what they are
+type releaseSelection struct {
+	Main      releaseFile
+	Extras    []releaseFile
+	Subtitles []releaseFile
+}
+
+// selectReleaseFiles finds the main movie file at path, which may be a single
+// file or a directory, along with the extras and subtitles next to it. The
+// main file is the largest video that is neither a sample nor an extra; when
+// runtime (in minutes) is known it must also be large enough to plausibly
+// hold the whole movie.
+func selectReleaseFiles(path string, runtime int) (*releaseSelection, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("download not accessible: %w", err)
+	}
+
+	if !info.IsDir() {
+		if !isVideoFile(path) {
+			return nil, fmt.Errorf("%s is not a video file", filepath.Base(path))
+		}
+		selection := &releaseSelection{Main: releaseFile{Name: path, Size: info.Size()}}
+		return selection, checkRuntimeSize(selection.Main, runtime)
+	}
+
+	var selection releaseSelection
+	var fallback releaseFile
+	err = filepath.WalkDir(path, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(path, p)
+		if err != nil {
+			return err
+		}
+		rel = withoutReleaseName(rel, filepath.Base(path))
+		fileInfo, err := d.Info()
+		if err != nil {
+			return err
+		}
+		file := releaseFile{Name: p, Size: fileInfo.Size()}
+
+		switch {
+		case isSubtitleFile(rel) && !isSampleFile(rel):
+			selection.Subtitles = append(selection.Subtitles, file)
+		case !isVideoFile(rel):
+			// .nfo files, images and the like are left behind
+		case isSampleFile(rel):
+			if file.Size > fallback.Size {
+				fallback = file
+			}
+		case isExtraFile(rel):
+			selection.Extras = append(selection.Extras, file)
+			if file.Size > fallback.Size {
+				fallback = file
+			}
+		case file.Size > selection.Main.Size:
+			selection.Main = file
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan download: %w", err)
+	}
+
+	if selection.Main.Name == "" {
+		if fallback.Name == "" {
+			return nil, fmt.Errorf("no video files found in %s", path)
+		}
+		// Every video looks like a sample or extra, which happens when the
+		// pattern is part of the title; trust the size check instead
+		log.Printf("No main feature found in %s, using %s", path, filepath.Base(fallback.Name))
+		selection.Main = fallback
+		selection.Extras = withoutFile(selection.Extras, fallback.Name)
+	}
+
+	if err := checkRuntimeSize(selection.Main, runtime); err != nil {
+		return nil, err
+	}
+	return &selection, nil
+}
+
+// checkRuntimeSize rejects a main file that is too small for the movie's runtime
+func checkRuntimeSize(main releaseFile, runtime int) error {
+	if runtime <= 0 {
+		return nil
+	}
+	if minSize := int64(runtime) * minBytesPerRuntimeMinute; main.Size < minSize {
+		return fmt.Errorf("%s is only %d MB, too small for a %d minute movie",
+			filepath.Base(main.Name), main.Size>>20, runtime)
+	}
+	return nil
+}
+
+// withoutFile returns files without the entry named name
+func withoutFile(files []releaseFile, name string) []releaseFile {
+	kept := files[:0]
+	for _, file := range files {
+		if file.Name != name {
+			kept = append(kept, file)
+		}
+	}
+	return kept
+}
+
+// sampleFileIndexes returns the positions of sample videos in a torrent's
+// file list, unless every video is a sample
+func sampleFileIndexes(files []releaseFile) []int {
+	var samples []int
+	videos := 0
+	for i, file := range files {
+		if !isVideoFile(file.Name) {
+			continue
+		}
+		videos++
+		if isSampleFile(torrentRelativeName(file.Name)) {
+			samples = append(samples, i)
+		}
+	}
+	if len(samples) == videos {
+		return nil
+	}
+	return samples
+}
+
+// torrentRelativeName strips the torrent's top-level folder from a file name,
+// and the folder's name from the start of the file name, so that a release
+// such as "Sample.Movie.2023" does not mark every file as a sample
+func torrentRelativeName(name string) string {
+	name = filepath.ToSlash(name)
+	i := strings.Index(name, "/")
+	if i < 0 {
+		return name
+	}
+	return withoutReleaseName(name[i+1:], name[:i])
+}
+
+// withoutReleaseName removes the release name from the start of the file name
+// in rel, leaving what sets the file apart, e.g. ".trailer.mkv"
+func withoutReleaseName(rel, release string) string {
+	dir, file := filepath.Split(rel)
+	if release != "" && len(file) > len(release) && strings.EqualFold(file[:len(release)], release) {
+		return dir + file[len(release):]
+	}
+	return rel
+}
+
+// isSampleFile reports whether a path inside a release is a sample clip
+func isSampleFile(rel string) bool {
+	return samplePattern.MatchString(rel)
+}
+
+// isExtraFile reports whether a path inside a release is bonus material
+func isExtraFile(rel string) bool {
+	return extrasPattern.MatchString(rel)
+}
+
+// isSubtitleFile reports whether the file name has a subtitle extension
+func isSubtitleFile(name string) bool {
+	return subtitleExtensions[strings.ToLower(filepath.Ext(name))]
+}
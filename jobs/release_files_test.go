@@ -0,0 +1,93 @@
+package jobs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeSparseFile creates a file of the given size without writing its data
+func writeSparseFile(t *testing.T, path string, size int64) {
+	assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	file, err := os.Create(path)
+	assert.NoError(t, err)
+	assert.NoError(t, file.Truncate(size))
+	assert.NoError(t, file.Close())
+}
+
+func TestSelectReleaseFiles(t *testing.T) {
+	download := filepath.Join(t.TempDir(), "Movie.2023.1080p")
+	main := filepath.Join(download, "Movie.2023.1080p.mkv")
+	writeSparseFile(t, main, 4<<30)
+	// Larger than the main file, but still a sample
+	writeSparseFile(t, filepath.Join(download, "Sample", "movie-sample.mkv"), 5<<30)
+	writeSparseFile(t, filepath.Join(download, "Featurettes", "Making.Of.mkv"), 300<<20)
+	writeSparseFile(t, filepath.Join(download, "Movie.2023.1080p-trailer.mp4"), 80<<20)
+	writeSparseFile(t, filepath.Join(download, "Movie.2023.1080p.en.srt"), 60<<10)
+	writeSparseFile(t, filepath.Join(download, "Subs", "German.srt"), 60<<10)
+	writeSparseFile(t, filepath.Join(download, "Sample", "sample.srt"), 1<<10)
+	writeSparseFile(t, filepath.Join(download, "Movie.2023.1080p.nfo"), 4<<10)
+
+	selection, err := selectReleaseFiles(download, 120)
+	assert.NoError(t, err)
+	assert.Equal(t, main, selection.Main.Name)
+	assert.Equal(t, int64(4<<30), selection.Main.Size)
+
+	var extras, subtitles []string
+	for _, file := range selection.Extras {
+		extras = append(extras, filepath.Base(file.Name))
+	}
+	for _, file := range selection.Subtitles {
+		subtitles = append(subtitles, filepath.Base(file.Name))
+	}
+	assert.ElementsMatch(t, []string{"Making.Of.mkv", "Movie.2023.1080p-trailer.mp4"}, extras)
+	assert.ElementsMatch(t, []string{"Movie.2023.1080p.en.srt", "German.srt"}, subtitles)
+}
+
+func TestSelectReleaseFiles_TooSmallForRuntime(t *testing.T) {
+	download := filepath.Join(t.TempDir(), "Movie.2023.1080p")
+	writeSparseFile(t, filepath.Join(download, "Movie.2023.1080p.mkv"), 100<<20)
+
+	_, err := selectReleaseFiles(download, 120)
+	assert.ErrorContains(t, err, "too small for a 120 minute movie")
+
+	// Without a known runtime the size is not checked
+	_, err = selectReleaseFiles(download, 0)
+	assert.NoError(t, err)
+}
+
+func TestSelectReleaseFiles_TitleMatchesPattern(t *testing.T) {
+	// Patterns are only matched against what follows the release name
+	download := filepath.Join(t.TempDir(), "Bonus.Track.2023.1080p")
+	main := filepath.Join(download, "Bonus.Track.2023.1080p.mkv")
+	writeSparseFile(t, main, 4<<30)
+	writeSparseFile(t, filepath.Join(download, "Bonus.Track.2023.1080p.trailer.mkv"), 80<<20)
+
+	selection, err := selectReleaseFiles(download, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, main, selection.Main.Name)
+	assert.Len(t, selection.Extras, 1)
+
+	// A file that still looks like an extra is used if nothing else is there
+	single := filepath.Join(t.TempDir(), "Extras.2023.1080p")
+	writeSparseFile(t, filepath.Join(single, "extras.mkv"), 4<<30)
+	selection, err = selectReleaseFiles(single, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(single, "extras.mkv"), selection.Main.Name)
+	assert.Empty(t, selection.Extras)
+}
+
+func TestSampleFileIndexes(t *testing.T) {
+	files := []releaseFile{
+		{Name: "Sample.Movie.2023.1080p/Sample.Movie.2023.1080p.mkv", Size: 4 << 30},
+		{Name: "Sample.Movie.2023.1080p/Sample/sample.mkv", Size: 50 << 20},
+		{Name: "Sample.Movie.2023.1080p/Sample.Movie.2023.1080p.nfo", Size: 4 << 10},
+		{Name: "Sample.Movie.2023.1080p/movie-SAMPLE.mp4", Size: 30 << 20},
+	}
+	assert.Equal(t, []int{1, 3}, sampleFileIndexes(files))
+
+	// A lone video is never skipped, even if it looks like a sample
+	assert.Nil(t, sampleFileIndexes([]releaseFile{{Name: "Sample.2023.1080p.mkv", Size: 4 << 30}}))
+}
@@ -0,0 +1,105 @@
+package jobs
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// minMainFileRatio is the smallest share of the release size the largest video
+// may have before the release is considered fake
+const minMainFileRatio = 0.5
+
+// executableExtensions lists file types that never belong in a movie release
+var executableExtensions = map[string]bool{
+	".exe": true, ".lnk": true, ".bat": true, ".cmd": true, ".com": true, ".scr": true,
+	".msi": true, ".pif": true, ".vbs": true, ".vbe": true, ".ps1": true, ".jar": true,
+	".apk": true, ".dll": true, ".hta": true, ".wsf": true,
+}
+
+// archiveExtensions lists archive formats, often password protected in fake releases
+var archiveExtensions = map[string]bool{
+	".rar": true, ".zip": true, ".7z": true, ".tar": true, ".gz": true, ".bz2": true, ".xz": true,
+}
+
+// splitArchivePattern matches multi-part archive volumes such as .r00 or .001
+var splitArchivePattern = regexp.MustCompile(`\.(r\d{2}|\d{3})$`)
+
+// releaseFile is a file inside a release, as reported by the download client or .torrent
+type releaseFile struct {
+	Name string
+	Size int64
+}
+
+// releaseRejection explains why a release was rejected after inspecting its files
+type releaseRejection struct {
+	Reason string
+	Files  []string
+}
+
+// Error implements the error interface
+func (r *releaseRejection) Error() string {
+	return fmt.Sprintf("release rejected: %s (%s)", r.Reason, strings.Join(r.Files, ", "))
+}
+
+// inspectReleaseFiles checks a release's file list for the hallmarks of a fake:
+// executables, archives instead of video, or a main file far smaller than the
+// advertised size. It returns nil when the release looks genuine.
+func inspectReleaseFiles(files []releaseFile, expectedSize int64) *releaseRejection {
+	var executables, archives []string
+	var totalSize int64
+	var largestVideo releaseFile
+
+	for _, file := range files {
+		totalSize += file.Size
+		switch {
+		case isExecutableFile(file.Name):
+			executables = append(executables, file.Name)
+		case isArchiveFile(file.Name):
+			archives = append(archives, file.Name)
+		case isVideoFile(file.Name) && file.Size > largestVideo.Size:
+			largestVideo = file
+		}
+	}
+
+	if len(executables) > 0 {
+		return &releaseRejection{Reason: "contains executable files", Files: executables}
+	}
+
+	if largestVideo.Name == "" {
+		if len(archives) > 0 {
+			return &releaseRejection{Reason: "contains only archives", Files: archives}
+		}
+		var names []string
+		for _, file := range files {
+			names = append(names, file.Name)
+		}
+		return &releaseRejection{Reason: "contains no video files", Files: names}
+	}
+
+	referenceSize := totalSize
+	if expectedSize > referenceSize {
+		referenceSize = expectedSize
+	}
+	if referenceSize > 0 && float64(largestVideo.Size) < float64(referenceSize)*minMainFileRatio {
+		return &releaseRejection{
+			Reason: fmt.Sprintf("largest video is %.0f%% of the release size",
+				100*float64(largestVideo.Size)/float64(referenceSize)),
+			Files: []string{largestVideo.Name},
+		}
+	}
+
+	return nil
+}
+
+// isExecutableFile reports whether the file name has an executable extension
+func isExecutableFile(name string) bool {
+	return executableExtensions[strings.ToLower(filepath.Ext(name))]
+}
+
+// isArchiveFile reports whether the file name is an archive or archive volume
+func isArchiveFile(name string) bool {
+	lower := strings.ToLower(name)
+	return archiveExtensions[filepath.Ext(lower)] || splitArchivePattern.MatchString(lower)
+}
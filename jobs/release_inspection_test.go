@@ -0,0 +1,180 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"media/database"
+	"media/models"
+	"media/repository"
+	"media/services"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInspectReleaseFiles(t *testing.T) {
+	const gb = int64(1 << 30)
+
+	tests := []struct {
+		name         string
+		files        []releaseFile
+		expectedSize int64
+		wantReason   string
+	}{
+		{
+			name: "genuine release with sample and nfo",
+			files: []releaseFile{
+				{Name: "Movie.2023.1080p/Movie.2023.1080p.mkv", Size: 8 * gb},
+				{Name: "Movie.2023.1080p/Sample/sample.mkv", Size: 50 << 20},
+				{Name: "Movie.2023.1080p/Movie.2023.1080p.nfo", Size: 4 << 10},
+			},
+			expectedSize: 8 * gb,
+		},
+		{
+			name: "executable alongside video",
+			files: []releaseFile{
+				{Name: "Movie.2023.1080p.mkv", Size: 8 * gb},
+				{Name: "Codec.Installer.EXE", Size: 2 << 20},
+			},
+			wantReason: "contains executable files",
+		},
+		{
+			name: "shortcut file",
+			files: []releaseFile{
+				{Name: "Movie.2023.1080p.lnk", Size: 1 << 10},
+			},
+			wantReason: "contains executable files",
+		},
+		{
+			name: "password protected archive",
+			files: []releaseFile{
+				{Name: "Movie.2023.1080p.rar", Size: 8 * gb},
+				{Name: "Movie.2023.1080p.r00", Size: 8 * gb},
+				{Name: "password.txt", Size: 20},
+			},
+			wantReason: "contains only archives",
+		},
+		{
+			name: "no video at all",
+			files: []releaseFile{
+				{Name: "readme.txt", Size: 20},
+			},
+			wantReason: "contains no video files",
+		},
+		{
+			name: "tiny video in a large release",
+			files: []releaseFile{
+				{Name: "Movie.2023.1080p.mkv", Size: 100 << 20},
+				{Name: "Movie.2023.1080p.iso", Size: 8 * gb},
+			},
+			wantReason: "largest video is 1% of the release size",
+		},
+		{
+			name: "video smaller than advertised",
+			files: []releaseFile{
+				{Name: "Movie.2023.1080p.mp4", Size: 1 * gb},
+			},
+			expectedSize: 10 * gb,
+			wantReason:   "largest video is 10% of the release size",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rejection := inspectReleaseFiles(tt.files, tt.expectedSize)
+			if tt.wantReason == "" {
+				assert.Nil(t, rejection)
+				return
+			}
+			if assert.NotNil(t, rejection) {
+				assert.Equal(t, tt.wantReason, rejection.Reason)
+				assert.NotEmpty(t, rejection.Files)
+			}
+		})
+	}
+}
+
+func TestTorrentSearchJob_DownloadTorrentRemovesUnstartedTorrents(t *testing.T) {
+	const hash = "c12fe1c06bba254a9dc9f519b335aa7c1367a88a"
+
+	tests := []struct {
+		name         string
+		filesStatus  int
+		resumeStatus int
+		wantErr      error
+	}{
+		{"file list unavailable", http.StatusInternalServerError, http.StatusOK, errNoFileList},
+		{"resume fails", http.StatusOK, http.StatusInternalServerError, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var deleted, resumed string
+			mux := http.NewServeMux()
+			mux.HandleFunc("/api/v2/auth/login", func(w http.ResponseWriter, _ *http.Request) {
+				http.SetCookie(w, &http.Cookie{Name: "SID", Value: "session", Path: "/"})
+				_, _ = w.Write([]byte("Ok."))
+			})
+			mux.HandleFunc("/api/v2/app/version", func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write([]byte("v4.6.2"))
+			})
+			mux.HandleFunc("/api/v2/torrents/add", func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write([]byte("Ok."))
+			})
+			mux.HandleFunc("/api/v2/torrents/info", func(w http.ResponseWriter, _ *http.Request) {
+				_ = json.NewEncoder(w).Encode([]services.QBTorrent{{Hash: hash, Name: "Test.Movie.2023.1080p"}})
+			})
+			mux.HandleFunc("/api/v2/torrents/files", func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tt.filesStatus)
+				if tt.filesStatus == http.StatusOK {
+					_ = json.NewEncoder(w).Encode([]services.QBTorrentFile{
+						{Name: "Test.Movie.2023.1080p/Test.Movie.2023.1080p.mkv", Size: 4 << 30},
+					})
+				}
+			})
+			mux.HandleFunc("/api/v2/torrents/resume", func(w http.ResponseWriter, r *http.Request) {
+				resumed = r.FormValue("hashes")
+				w.WriteHeader(tt.resumeStatus)
+			})
+			mux.HandleFunc("/api/v2/torrents/delete", func(w http.ResponseWriter, r *http.Request) {
+				deleted = r.FormValue("hashes")
+				_, _ = w.Write([]byte("Ok."))
+			})
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			testDB, err := database.NewDB(":memory:")
+			assert.NoError(t, err)
+			defer func() { _ = testDB.Close() }()
+			assert.NoError(t, testDB.InitSchema())
+
+			movieRepo := repository.NewMovieRepository(testDB)
+			movie := &models.Movie{Title: "Test Movie", Year: 2023, Status: models.StatusSearching}
+			assert.NoError(t, movieRepo.Create(movie))
+
+			qbittorrent := services.NewQBittorrentService(server.URL, "admin", "secret")
+			job := NewTorrentSearchJob(movieRepo, newTestBus(repository.NewMovieEventRepository(testDB)), nil, qbittorrent)
+
+			result := TorrentResult{Title: "Test.Movie.2023.1080p", Size: 4 << 30,
+				MagnetURI: "magnet:?xt=urn:btih:" + hash}
+			err = job.downloadTorrent(context.Background(), result, movie)
+			assert.Error(t, err)
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr))
+			}
+
+			// The paused torrent is removed, and the movie does not track it
+			assert.Equal(t, hash, deleted)
+			if tt.filesStatus != http.StatusOK {
+				assert.Empty(t, resumed)
+			}
+			stored, err := movieRepo.GetByID(movie.ID)
+			assert.NoError(t, err)
+			assert.Empty(t, stored.TorrentHash)
+		})
+	}
+}
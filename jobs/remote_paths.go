@@ -0,0 +1,94 @@
+package jobs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"media/models"
+	"media/repository"
+)
+
+// remotePathMapper translates paths reported by a download client into paths
+// this service can read. A nil mapper, or a client without mappings, returns
+// paths unchanged.
+type remotePathMapper struct {
+	repo   *repository.RemotePathMappingRepository
+	client string
+}
+
+// newRemotePathMapper creates a mapper for one download client
+func newRemotePathMapper(repo *repository.RemotePathMappingRepository, client string) *remotePathMapper {
+	if repo == nil {
+		return nil
+	}
+	return &remotePathMapper{repo: repo, client: client}
+}
+
+// localPath maps a remote path and checks that the result exists
+func (m *remotePathMapper) localPath(remote string) (string, error) {
+	if m == nil {
+		return remote, nil
+	}
+
+	mappings, err := m.repo.GetByClient(m.client)
+	if err != nil {
+		return "", err
+	}
+
+	local, ok := mapRemotePath(mappings, remote)
+	if !ok {
+		return remote, nil
+	}
+	if _, err := os.Stat(local); err != nil {
+		return "", fmt.Errorf("remote path %s maps to %s, which is not accessible: %w", remote, local, err)
+	}
+	return local, nil
+}
+
+// mapRemotePath applies the mapping with the longest matching remote prefix.
+// Remote paths may use Windows separators, which are then matched case
+// insensitively.
+func mapRemotePath(mappings []models.RemotePathMapping, remote string) (string, bool) {
+	var best *models.RemotePathMapping
+	var bestRest string
+
+	for i := range mappings {
+		mapping := &mappings[i]
+		rest, ok := trimRemotePrefix(remote, mapping.RemotePath)
+		if ok && (best == nil || len(mapping.RemotePath) > len(best.RemotePath)) {
+			best, bestRest = mapping, rest
+		}
+	}
+
+	if best == nil {
+		return "", false
+	}
+	return filepath.Join(best.LocalPath, filepath.FromSlash(bestRest)), true
+}
+
+// trimRemotePrefix returns the part of path below prefix, with forward
+// slashes, if path is prefix or lies inside it
+func trimRemotePrefix(path, prefix string) (string, bool) {
+	windows := strings.Contains(prefix, `\`)
+	if windows {
+		path = strings.ReplaceAll(path, `\`, "/")
+		prefix = strings.ReplaceAll(prefix, `\`, "/")
+	}
+	prefix = strings.TrimRight(prefix, "/")
+
+	if len(path) < len(prefix) {
+		return "", false
+	}
+	head, rest := path[:len(prefix)], path[len(prefix):]
+
+	matches := head == prefix
+	if windows {
+		matches = strings.EqualFold(head, prefix)
+	}
+	if !matches || (rest != "" && rest[0] != '/') {
+		return "", false
+	}
+	return strings.TrimLeft(rest, "/"), true
+}
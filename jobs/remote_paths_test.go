@@ -0,0 +1,79 @@
+package jobs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"media/database"
+	"media/models"
+	"media/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMapRemotePath(t *testing.T) {
+	mappings := []models.RemotePathMapping{
+		{RemotePath: "/downloads", LocalPath: "/srv/downloads"},
+		{RemotePath: "/downloads/movies/", LocalPath: "/mnt/movies"},
+		{RemotePath: `D:\Torrents`, LocalPath: "/mnt/d/torrents"},
+	}
+
+	tests := []struct {
+		remote string
+		want   string
+		mapped bool
+	}{
+		{"/downloads/Movie.2023/movie.mkv", "/srv/downloads/Movie.2023/movie.mkv", true},
+		{"/downloads", "/srv/downloads", true},
+		{"/downloads/movies/Movie.2023", "/mnt/movies/Movie.2023", true}, // longest prefix wins
+		{"/downloads-old/Movie.2023", "", false},                         // not a path boundary
+		{`d:\torrents\Movie.2023\movie.mkv`, "/mnt/d/torrents/Movie.2023/movie.mkv", true},
+		{"/data/Movie.2023", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.remote, func(t *testing.T) {
+			got, ok := mapRemotePath(mappings, tt.remote)
+			assert.Equal(t, tt.mapped, ok)
+			if tt.mapped {
+				assert.Equal(t, filepath.FromSlash(tt.want), got)
+			}
+		})
+	}
+}
+
+func TestRemotePathMapper_LocalPath(t *testing.T) {
+	testDB, err := database.NewDB(":memory:")
+	assert.NoError(t, err)
+	defer func() { _ = testDB.Close() }()
+	assert.NoError(t, testDB.InitSchema())
+
+	local := t.TempDir()
+	assert.NoError(t, os.Mkdir(filepath.Join(local, "Movie.2023"), 0o755))
+
+	repo := repository.NewRemotePathMappingRepository(testDB)
+	assert.NoError(t, repo.Create(&models.RemotePathMapping{
+		Client: models.DownloadClientQBittorrent, RemotePath: "/downloads", LocalPath: local,
+	}))
+
+	mapper := newRemotePathMapper(repo, models.DownloadClientQBittorrent)
+
+	path, err := mapper.localPath("/downloads/Movie.2023")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(local, "Movie.2023"), path)
+
+	// A mapped path that does not exist is an error rather than a silent miss
+	_, err = mapper.localPath("/downloads/Missing.2023")
+	assert.Error(t, err)
+
+	// Unmapped paths and a nil mapper pass through
+	path, err = mapper.localPath("/elsewhere/Movie.2023")
+	assert.NoError(t, err)
+	assert.Equal(t, "/elsewhere/Movie.2023", path)
+
+	var noMapper *remotePathMapper
+	path, err = noMapper.localPath("/downloads/Movie.2023")
+	assert.NoError(t, err)
+	assert.Equal(t, "/downloads/Movie.2023", path)
+}
@@ -0,0 +1,67 @@
+package jobs
+
+import (
+	"sort"
+	"time"
+
+	"media/models"
+	"media/services"
+)
+
+// searchTimer collects the timings of one search for a movie as it runs
+type searchTimer struct {
+	run      models.SearchRun
+	indexers map[string]*models.SearchIndexerTiming
+}
+
+// newSearchTimer starts timing a search
+func newSearchTimer(movieID int) *searchTimer {
+	return &searchTimer{
+		run:      models.SearchRun{MovieID: movieID, StartedAt: time.Now()},
+		indexers: make(map[string]*models.SearchIndexerTiming),
+	}
+}
+
+// record counts a request to Jackett and adds the time each indexer took to
+// answer it. A failed request has no response.
+func (t *searchTimer) record(response *services.JackettResponse) {
+	t.run.Requests++
+	if response == nil {
+		return
+	}
+	t.run.Results += len(response.Results)
+
+	for _, status := range response.Indexers {
+		name := status.Name
+		if name == "" {
+			name = status.ID
+		}
+		timing, ok := t.indexers[name]
+		if !ok {
+			timing = &models.SearchIndexerTiming{Indexer: name}
+			t.indexers[name] = timing
+		}
+		timing.Requests++
+		timing.Results += status.Results
+		timing.ElapsedMs += status.ElapsedTime
+		if status.Error != "" {
+			timing.Errors++
+		}
+	}
+}
+
+// finish stops the timer and returns the run
+func (t *searchTimer) finish(queries, found int) *models.SearchRun {
+	t.run.FinishedAt = time.Now()
+	t.run.DurationMs = t.run.FinishedAt.Sub(t.run.StartedAt).Milliseconds()
+	t.run.Queries = queries
+	t.run.Found = found
+
+	t.run.Indexers = make([]models.SearchIndexerTiming, 0, len(t.indexers))
+	for _, timing := range t.indexers {
+		t.run.Indexers = append(t.run.Indexers, *timing)
+	}
+	sort.Slice(t.run.Indexers, func(i, j int) bool { return t.run.Indexers[i].Indexer < t.run.Indexers[j].Indexer })
+
+	return &t.run
+}
@@ -0,0 +1,88 @@
+package jobs
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"media/database"
+	"media/models"
+	"media/repository"
+	"media/services"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTorrentSearchJob_RecordsSearchTimings(t *testing.T) {
+	testDB, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	defer func() {
+		if err := testDB.Close(); err != nil {
+			t.Logf("Failed to close test database: %v", err)
+		}
+	}()
+	testDB.SetMaxOpenConns(1)
+	if err := testDB.InitSchema(); err != nil {
+		t.Fatalf("Failed to initialize test schema: %v", err)
+	}
+
+	// Jackett finds nothing usable; one of its indexers times out
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"Results":[{"Title":"Heat.1995.CAM","Seeders":0}],"Indexers":[
+			{"ID":"yts","Name":"YTS","Results":1,"Error":null,"ElapsedTime":120},
+			{"ID":"1337x","Name":"1337x","Results":0,"Error":"Request timed out","ElapsedTime":3000}]}`))
+	}))
+	defer server.Close()
+
+	movieRepo := repository.NewMovieRepository(testDB)
+	movieEventRepo := repository.NewMovieEventRepository(testDB)
+	searchRunRepo := repository.NewSearchRunRepository(testDB)
+	movie := &models.Movie{Title: "Heat", Year: 1995, Status: models.StatusWanted}
+	assert.NoError(t, movieRepo.Create(movie))
+
+	job := NewTorrentSearchJob(movieRepo, newTestBus(movieEventRepo), services.NewJackettService(server.URL, "key"), nil)
+	job.SetSearchRunRepository(searchRunRepo)
+	assert.NoError(t, job.SearchForMovie(context.Background(), movie.ID))
+
+	stats, err := searchRunRepo.GetStats(time.Now().Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, stats.Runs.Count)
+	if assert.Len(t, stats.Indexers, 2) {
+		slow, yts := stats.Indexers[0], stats.Indexers[1]
+		assert.Equal(t, "1337x", slow.Indexer)
+		assert.Equal(t, int(requests), slow.Count)
+		assert.Equal(t, int(requests), slow.Errors)
+		assert.Equal(t, float64(3000), slow.AvgMs)
+		assert.Equal(t, "YTS", yts.Indexer)
+		assert.Equal(t, int(requests), yts.Results)
+		assert.Equal(t, int64(120), yts.P90Ms)
+	}
+
+	// The search failure carries the search's numbers
+	events, err := movieEventRepo.GetByMovieID(movie.ID)
+	assert.NoError(t, err)
+	var failed *models.MovieEvent
+	for i := range events {
+		if events[i].Type == models.EventSearchFailed {
+			failed = &events[i]
+		}
+	}
+	if assert.NotNil(t, failed) {
+		var details models.SearchDetails
+		assert.NoError(t, failed.DecodeDetails(&details))
+		assert.Equal(t, 2, details.SearchQueries)
+		assert.Equal(t, "no_quality_torrents_after_filtering", details.Reason)
+	}
+
+	movieStats, err := movieEventRepo.GetStatistics(movie.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, movieStats.TotalSearches)
+}
@@ -0,0 +1,149 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"media/events"
+	"media/models"
+	"media/repository"
+	"media/services"
+)
+
+// SeedingCleanupJob removes torrents of imported movies from qBittorrent once
+// their seeding policy is satisfied
+type SeedingCleanupJob struct {
+	movieRepo          *repository.MovieRepository
+	bus                *events.Bus
+	policyRepo         *repository.SeedingPolicyRepository
+	qbittorrentService *services.QBittorrentService
+	pathMapper         *remotePathMapper
+}
+
+// NewSeedingCleanupJob creates a new seeding cleanup job
+func NewSeedingCleanupJob(movieRepo *repository.MovieRepository, bus *events.Bus,
+	policyRepo *repository.SeedingPolicyRepository, qbittorrentService *services.QBittorrentService) *SeedingCleanupJob {
+	return &SeedingCleanupJob{
+		movieRepo:          movieRepo,
+		bus:                bus,
+		policyRepo:         policyRepo,
+		qbittorrentService: qbittorrentService,
+	}
+}
+
+// SetRemotePathMappings configures how qBittorrent's paths translate to local ones
+func (j *SeedingCleanupJob) SetRemotePathMappings(repo *repository.RemotePathMappingRepository) {
+	j.pathMapper = newRemotePathMapper(repo, models.DownloadClientQBittorrent)
+}
+
+// CheckSeeding removes every imported movie's torrent whose seeding goals are met
+func (j *SeedingCleanupJob) CheckSeeding(ctx context.Context) error {
+	movies, err := j.movieRepo.GetByStatus(models.StatusReady)
+	if err != nil {
+		return fmt.Errorf("failed to get ready movies: %w", err)
+	}
+
+	var seeding []*models.Movie
+	for i := range movies {
+		if movies[i].TorrentHash != "" {
+			seeding = append(seeding, &movies[i])
+		}
+	}
+	if len(seeding) == 0 {
+		return nil
+	}
+
+	list, err := j.qbittorrentService.GetTorrents(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get torrents: %w", err)
+	}
+	torrents := make(map[string]services.QBTorrent, len(list))
+	for _, torrent := range list {
+		torrents[strings.ToLower(torrent.Hash)] = torrent
+	}
+
+	for _, movie := range seeding {
+		torrent, ok := torrents[strings.ToLower(movie.TorrentHash)]
+		if !ok {
+			// Removed from the client by hand, nothing left to clean up
+			continue
+		}
+
+		policy, err := j.policyRepo.GetForIndexer(movie.Indexer)
+		if err != nil {
+			log.Printf("Failed to get seeding policy for '%s': %v", movie.Title, err)
+			continue
+		}
+		if policy == nil || !seedingGoalMet(policy, torrent) {
+			continue
+		}
+
+		if err := j.removeTorrent(ctx, movie, torrent, policy); err != nil {
+			log.Printf("Failed to remove torrent for '%s': %v", movie.Title, err)
+		}
+	}
+
+	return nil
+}
+
+// seedingGoalMet reports whether a finished torrent has met every goal the
+// policy sets
+func seedingGoalMet(policy *models.SeedingPolicy, torrent services.QBTorrent) bool {
+	if torrent.Progress < 1 || (policy.MinRatio <= 0 && policy.MinSeedTime <= 0) {
+		return false
+	}
+	if policy.MinRatio > 0 && torrent.Ratio < policy.MinRatio {
+		return false
+	}
+	minSeedTime := time.Duration(policy.MinSeedTime) * time.Minute
+	return time.Duration(torrent.SeedingTime)*time.Second >= minSeedTime
+}
+
+// removeTorrent deletes the torrent from qBittorrent, keeping the imported copy
+func (j *SeedingCleanupJob) removeTorrent(ctx context.Context, movie *models.Movie, torrent services.QBTorrent, policy *models.SeedingPolicy) error {
+	// Only delete the downloaded data if the library file does not live inside it
+	contentPath, err := j.pathMapper.localPath(torrent.DownloadPath())
+	if err != nil {
+		return err
+	}
+	deleteFiles := movie.FilePath != "" && !isWithinPath(movie.FilePath, contentPath)
+
+	if err := j.qbittorrentService.RemoveTorrent(ctx, torrent.Hash, deleteFiles); err != nil {
+		return err
+	}
+
+	movie.TorrentHash = ""
+	if err := j.movieRepo.Update(movie); err != nil {
+		log.Printf("Failed to clear torrent hash for '%s': %v", movie.Title, err)
+	}
+
+	log.Printf("Removed torrent for '%s' after seeding to ratio %.2f for %s",
+		movie.Title, torrent.Ratio, time.Duration(torrent.SeedingTime)*time.Second)
+
+	j.bus.Publish(movie.ID, models.EventTorrentRemoved,
+		fmt.Sprintf("Seeding goals met, removed torrent for '%s'", movie.Title),
+		models.TorrentRemovedDetails{
+			TorrentHash:    torrent.Hash,
+			Ratio:          torrent.Ratio,
+			SeedingTime:    torrent.SeedingTime,
+			PolicyIndexer:  policy.Indexer,
+			PolicyRatio:    policy.MinRatio,
+			PolicySeedTime: policy.MinSeedTime,
+			FilesDeleted:   deleteFiles,
+		})
+
+	return nil
+}
+
+// isWithinPath reports whether path is dir or lies inside it
+func isWithinPath(path, dir string) bool {
+	rel, err := filepath.Rel(filepath.Clean(dir), filepath.Clean(path))
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
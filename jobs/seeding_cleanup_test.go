@@ -0,0 +1,111 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"media/database"
+	"media/models"
+	"media/repository"
+	"media/services"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeedingGoalMet(t *testing.T) {
+	policy := &models.SeedingPolicy{MinRatio: 1.5, MinSeedTime: 60}
+
+	tests := []struct {
+		name    string
+		torrent services.QBTorrent
+		want    bool
+	}{
+		{"still downloading", services.QBTorrent{Progress: 0.5, Ratio: 3}, false},
+		{"neither goal met", services.QBTorrent{Progress: 1, Ratio: 1.0, SeedingTime: 1800}, false},
+		{"ratio met, seed time not met", services.QBTorrent{Progress: 1, Ratio: 1.5, SeedingTime: 1800}, false},
+		{"seed time met, ratio not met", services.QBTorrent{Progress: 1, Ratio: 0.1, SeedingTime: 3600}, false},
+		{"both goals met", services.QBTorrent{Progress: 1, Ratio: 1.5, SeedingTime: 3600}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, seedingGoalMet(policy, tt.torrent))
+		})
+	}
+
+	// Unset goals are not enforced
+	ratioOnly := &models.SeedingPolicy{MinRatio: 2}
+	assert.False(t, seedingGoalMet(ratioOnly, services.QBTorrent{Progress: 1, Ratio: 1, SeedingTime: 1 << 30}))
+	assert.True(t, seedingGoalMet(ratioOnly, services.QBTorrent{Progress: 1, Ratio: 2}))
+
+	// A policy without goals keeps seeding
+	assert.False(t, seedingGoalMet(&models.SeedingPolicy{}, services.QBTorrent{Progress: 1, Ratio: 10, SeedingTime: 1 << 30}))
+}
+
+func TestIsWithinPath(t *testing.T) {
+	assert.True(t, isWithinPath("/downloads/Movie/movie.mkv", "/downloads/Movie"))
+	assert.True(t, isWithinPath("/downloads/movie.mkv", "/downloads/movie.mkv"))
+	assert.False(t, isWithinPath("/library/Movie (2023)/movie.mkv", "/downloads/Movie"))
+	assert.False(t, isWithinPath("/downloads/Movie2/movie.mkv", "/downloads/Movie"))
+}
+
+func TestSeedingCleanupJob_RemovesTorrentOnceGoalMet(t *testing.T) {
+	const hash = "c12fe1c06bba254a9dc9f519b335aa7c1367a88a"
+
+	var deleted map[string]string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/auth/login", func(w http.ResponseWriter, _ *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "SID", Value: "session", Path: "/"})
+		_, _ = w.Write([]byte("Ok."))
+	})
+	mux.HandleFunc("/api/v2/torrents/info", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode([]services.QBTorrent{{
+			Hash: hash, Name: "Test.Movie.2023", Progress: 1, Ratio: 2.5,
+			ContentPath: "/downloads/Test.Movie.2023",
+		}})
+	})
+	mux.HandleFunc("/api/v2/torrents/delete", func(w http.ResponseWriter, r *http.Request) {
+		deleted = map[string]string{"hashes": r.FormValue("hashes"), "deleteFiles": r.FormValue("deleteFiles")}
+		_, _ = w.Write([]byte("Ok."))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	testDB, err := database.NewDB(":memory:")
+	assert.NoError(t, err)
+	defer func() { _ = testDB.Close() }()
+	assert.NoError(t, testDB.InitSchema())
+
+	movieRepo := repository.NewMovieRepository(testDB)
+	movieEventRepo := repository.NewMovieEventRepository(testDB)
+	policyRepo := repository.NewSeedingPolicyRepository(testDB)
+	assert.NoError(t, policyRepo.Save(&models.SeedingPolicy{MinRatio: 2}))
+
+	movie := &models.Movie{
+		Title:       "Test Movie",
+		Status:      models.StatusReady,
+		TorrentHash: hash,
+		FilePath:    "/library/Test Movie (2023)/Test Movie (2023).mkv",
+	}
+	assert.NoError(t, movieRepo.Create(movie))
+
+	qb := services.NewQBittorrentService(server.URL, "admin", "secret")
+	job := NewSeedingCleanupJob(movieRepo, newTestBus(movieEventRepo), policyRepo, qb)
+	assert.NoError(t, job.CheckSeeding(context.Background()))
+
+	// The library copy lives outside the download, so the download is deleted too
+	assert.Equal(t, map[string]string{"hashes": hash, "deleteFiles": "true"}, deleted)
+
+	updated, err := movieRepo.GetByID(movie.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, updated.TorrentHash)
+
+	events, err := movieEventRepo.GetByMovieID(movie.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, models.EventTorrentRemoved, events[0].Type)
+	}
+}
@@ -2,12 +2,16 @@
 package jobs
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"os"
 	"sort"
 	"strings"
 	"time"
 
+	"media/events"
 	"media/models"
 	"media/repository"
 	"media/services"
@@ -16,11 +20,28 @@ import (
 // TorrentSearchJob handles searching for torrents for a movie
 type TorrentSearchJob struct {
 	movieRepo          *repository.MovieRepository
-	movieEventRepo     *repository.MovieEventRepository
+	bus                *events.Bus
 	jackettService     *services.JackettService
 	qbittorrentService *services.QBittorrentService
+	blackholeService   *services.BlackholeService
+	blocklistRepo      *repository.BlocklistRepository
+	rootFolderRepo     *repository.RootFolderRepository
+	searchRunRepo      *repository.SearchRunRepository
+	category           string
+	downloadDir        string
+	active             *activeJobs
 }
 
+// How long to wait for a newly added magnet's metadata before inspecting its files
+const (
+	metadataTimeout      = 60 * time.Second
+	metadataPollInterval = 2 * time.Second
+)
+
+// errNoFileList means a torrent's file list could not be read, so it was
+// removed rather than downloaded without inspection
+var errNoFileList = errors.New("torrent file list unavailable")
+
 // TorrentResult represents a processed torrent search result
 type TorrentResult struct {
 	Title       string
@@ -30,23 +51,58 @@ type TorrentResult struct {
 	MagnetURI   string
 	DownloadURL string
 	InfoHash    string
+	Indexer     string
 	Quality     string
 	Score       int
 }
 
 // NewTorrentSearchJob creates a new torrent search job
-func NewTorrentSearchJob(movieRepo *repository.MovieRepository, movieEventRepo *repository.MovieEventRepository, jackettService *services.JackettService, qbittorrentService *services.QBittorrentService) *TorrentSearchJob {
+func NewTorrentSearchJob(movieRepo *repository.MovieRepository, bus *events.Bus, jackettService *services.JackettService, qbittorrentService *services.QBittorrentService) *TorrentSearchJob {
 	return &TorrentSearchJob{
 		movieRepo:          movieRepo,
-		movieEventRepo:     movieEventRepo,
+		bus:                bus,
 		jackettService:     jackettService,
 		qbittorrentService: qbittorrentService,
+		category:           "movies",
 	}
 }
 
+// SetBlackholeService configures a watch-folder client used when qBittorrent is not available
+func (j *TorrentSearchJob) SetBlackholeService(blackholeService *services.BlackholeService) {
+	j.blackholeService = blackholeService
+}
+
+// SetBlocklistRepository configures where rejected releases are recorded and looked up
+func (j *TorrentSearchJob) SetBlocklistRepository(blocklistRepo *repository.BlocklistRepository) {
+	j.blocklistRepo = blocklistRepo
+}
+
+// SetRootFolderRepository configures the library folders checked for free space before grabbing
+func (j *TorrentSearchJob) SetRootFolderRepository(rootFolderRepo *repository.RootFolderRepository) {
+	j.rootFolderRepo = rootFolderRepo
+}
+
+// SetSearchRunRepository configures where the timings of each search are recorded
+func (j *TorrentSearchJob) SetSearchRunRepository(searchRunRepo *repository.SearchRunRepository) {
+	j.searchRunRepo = searchRunRepo
+}
+
+// SetDownloadOptions sets the qBittorrent category and download directory for
+// new torrents. An empty downloadDir leaves the choice to qBittorrent.
+func (j *TorrentSearchJob) SetDownloadOptions(category, downloadDir string) {
+	j.category = category
+	j.downloadDir = downloadDir
+}
+
+// hasDownloadClient reports whether any download client is configured
+func (j *TorrentSearchJob) hasDownloadClient() bool {
+	return j.qbittorrentService != nil || j.blackholeService != nil
+}
+
 // SearchForMovie searches for torrents for a specific movie
-func (j *TorrentSearchJob) SearchForMovie(movieID int) error {
+func (j *TorrentSearchJob) SearchForMovie(ctx context.Context, movieID int) error {
 	log.Printf("Starting torrent search for movie ID: %d", movieID)
+	defer j.active.start(movieID, "searching")()
 
 	// Get the movie from the database
 	movie, err := j.movieRepo.GetByID(movieID)
@@ -55,23 +111,21 @@ func (j *TorrentSearchJob) SearchForMovie(movieID int) error {
 	}
 
 	// Update status to searching
+	previousStatus := movie.Status
 	movie.Status = models.StatusSearching
 	if err := j.movieRepo.Update(movie); err != nil {
 		log.Printf("Failed to update movie status to searching: %v", err)
 	}
 
 	// Log search start event
-	if j.movieEventRepo != nil {
-		if err := j.movieEventRepo.Create(movieID, models.EventSearchStarted,
-			fmt.Sprintf("Starting torrent search for '%s' (%d)", movie.Title, movie.Year), nil); err != nil {
-			log.Printf("Failed to log search start event: %v", err)
-		}
-	}
+	j.bus.Publish(movieID, models.EventSearchStarted,
+		fmt.Sprintf("Starting torrent search for '%s' (%d)", movie.Title, movie.Year), nil)
 
 	// Build search queries
 	queries := j.buildSearchQueries(movie)
 
 	var allResults []TorrentResult
+	timer := newSearchTimer(movieID)
 
 	// Search using each query with movie-specific search
 	for _, query := range queries {
@@ -80,15 +134,17 @@ func (j *TorrentSearchJob) SearchForMovie(movieID int) error {
 		// Use movie-specific search with proper parameters
 		movieCategories := []string{"2000", "2010", "2020", "2030", "2040", "2050", "2060"} // Various movie categories
 		var results []services.JackettSearchResult
+		var response *services.JackettResponse
 		var err error
 
 		// First try with movie-specific search using TMDB ID and IMDB ID if available
 		if movie.TMDBID > 0 || movie.IMDBID != "" {
 			log.Printf("Trying movie search with IDs: TMDB=%d, IMDB=%s", movie.TMDBID, movie.IMDBID)
-			results, err = j.jackettService.SearchMovies("", movie.Year, movie.IMDBID, movie.TMDBID, "2000")
+			response, err = j.jackettService.SearchMovies("", movie.Year, movie.IMDBID, movie.TMDBID, "2000")
+			timer.record(response)
 			if err != nil {
 				log.Printf("Movie search by ID failed: %v", err)
-			} else if len(results) > 0 {
+			} else if results = response.Results; len(results) > 0 {
 				log.Printf("Found %d results using movie IDs", len(results))
 				// Process these results and continue to next query
 				processedResults := j.processResults(results, movie)
@@ -100,11 +156,13 @@ func (j *TorrentSearchJob) SearchForMovie(movieID int) error {
 		// Try movie search with title and year
 		for _, category := range movieCategories {
 			log.Printf("Trying movie search with category %s for query '%s'", category, query)
-			results, err = j.jackettService.SearchMovies(query, movie.Year, "", 0, category)
+			response, err = j.jackettService.SearchMovies(query, movie.Year, "", 0, category)
+			timer.record(response)
 			if err != nil {
 				log.Printf("Movie search failed for category %s: %v", category, err)
 				continue
 			}
+			results = response.Results
 			if len(results) > 0 {
 				log.Printf("Found %d results in category %s", len(results), category)
 				break
@@ -114,11 +172,13 @@ func (j *TorrentSearchJob) SearchForMovie(movieID int) error {
 		// If no results with movie search, try fallback to general search
 		if len(results) == 0 {
 			log.Printf("No results with movie search, trying general search...")
-			results, err = j.jackettService.Search(query, "2000")
+			response, err = j.jackettService.Search(query, "2000")
+			timer.record(response)
 			if err != nil {
 				log.Printf("General search failed for query '%s': %v", query, err)
 				continue
 			}
+			results = response.Results
 			log.Printf("Found %d results with general search", len(results))
 		}
 
@@ -132,31 +192,47 @@ func (j *TorrentSearchJob) SearchForMovie(movieID int) error {
 
 	log.Printf("Found %d potential torrents for '%s' (%d)", len(bestResults), movie.Title, movie.Year)
 
+	// Record how long the search took
+	run := timer.finish(len(queries), len(bestResults))
+	log.Printf("Search for '%s' took %dms over %d requests", movie.Title, run.DurationMs, run.Requests)
+	if j.searchRunRepo != nil {
+		if err := j.searchRunRepo.Create(run); err != nil {
+			log.Printf("Failed to record search run: %v", err)
+		}
+	}
+
 	// Log search completion and update status
-	if j.movieEventRepo != nil {
-		if len(bestResults) > 0 {
-			if err := j.movieEventRepo.Create(movieID, models.EventSearchCompleted,
-				fmt.Sprintf("Found %d torrents for '%s'", len(bestResults), movie.Title),
-				map[string]interface{}{"torrent_count": len(bestResults)}); err != nil {
-				log.Printf("Failed to log search completion: %v", err)
-			}
-		} else {
-			// No torrents found - set status to not_found with detailed reason
-			movie.Status = models.StatusNotFound
-			if err := j.movieRepo.Update(movie); err != nil {
-				log.Printf("Failed to update movie status to not_found: %v", err)
-			}
+	if len(bestResults) > 0 {
+		j.bus.Publish(movieID, models.EventSearchCompleted,
+			fmt.Sprintf("Found %d torrents for '%s'", len(bestResults), movie.Title),
+			models.SearchDetails{
+				TorrentCount:      len(bestResults),
+				SearchQueries:     len(queries),
+				TotalResultsFound: len(allResults),
+				DurationMs:        run.DurationMs,
+			})
+	} else {
+		// No torrents found - set status to not_found with detailed reason
+		movie.Status = models.StatusNotFound
+		if err := j.movieRepo.Update(movie); err != nil {
+			log.Printf("Failed to update movie status to not_found: %v", err)
+		}
 
-			if err := j.movieEventRepo.Create(movieID, models.EventSearchFailed,
-				fmt.Sprintf("No suitable torrents found for '%s' (%d)", movie.Title, movie.Year),
-				map[string]interface{}{
-					"search_queries":      len(queries),
-					"total_results_found": len(allResults),
-					"reason":              "no_quality_torrents_after_filtering",
-				}); err != nil {
-				log.Printf("Failed to log search failure: %v", err)
-			}
+		// Retries of a movie that was not found before change nothing
+		if previousStatus != models.StatusNotFound {
+			j.bus.Publish(movieID, models.EventStatusChanged,
+				fmt.Sprintf("Status changed to: %s", models.StatusNotFound),
+				models.StatusChangeDetails{OldStatus: previousStatus, NewStatus: models.StatusNotFound})
 		}
+
+		j.bus.Publish(movieID, models.EventSearchFailed,
+			fmt.Sprintf("No suitable torrents found for '%s' (%d)", movie.Title, movie.Year),
+			models.SearchDetails{
+				SearchQueries:     len(queries),
+				TotalResultsFound: len(allResults),
+				DurationMs:        run.DurationMs,
+				Reason:            "no_quality_torrents_after_filtering",
+			})
 	}
 
 	if len(bestResults) > 0 {
@@ -165,62 +241,79 @@ func (j *TorrentSearchJob) SearchForMovie(movieID int) error {
 			best.Title, best.Seeders, float64(best.Size)/(1024*1024*1024), best.Score)
 
 		// Log best torrent found
-		if j.movieEventRepo != nil {
-			torrentDetails := map[string]interface{}{
-				"title":   best.Title,
-				"seeders": best.Seeders,
-				"size_gb": float64(best.Size) / (1024 * 1024 * 1024),
-				"score":   best.Score,
-				"quality": best.Quality,
-			}
-			if err := j.movieEventRepo.Create(movieID, models.EventTorrentFound,
-				fmt.Sprintf("Best torrent: %s (Score: %d)", best.Title, best.Score), torrentDetails); err != nil {
-				log.Printf("Failed to log torrent found: %v", err)
-			}
+		torrentDetails := models.TorrentFoundDetails{
+			Title:   best.Title,
+			Indexer: best.Indexer,
+			Seeders: best.Seeders,
+			SizeGB:  float64(best.Size) / (1024 * 1024 * 1024),
+			Score:   best.Score,
+			Quality: best.Quality,
 		}
+		j.bus.Publish(movieID, models.EventTorrentFound,
+			fmt.Sprintf("Best torrent: %s (Score: %d)", best.Title, best.Score), torrentDetails)
+
+		// Download the torrent if a download client is available
+		if j.hasDownloadClient() {
+			// Fall through to the next candidate whenever a release is rejected after inspection
+			var grabbed *TorrentResult
+			var err error
+			for i := range bestResults {
+				candidate := bestResults[i]
+
+				if err = j.checkFreeSpace(movie, candidate); err != nil {
+					log.Printf("Skipping '%s': %v", candidate.Title, err)
+					continue
+				}
 
-		// Download the torrent if qBittorrent is available
-		if j.qbittorrentService != nil {
-			// Log download start
-			if j.movieEventRepo != nil {
-				if err := j.movieEventRepo.Create(movieID, models.EventDownloadStarted,
-					fmt.Sprintf("Starting download of '%s'", best.Title), nil); err != nil {
-					log.Printf("Failed to log download start: %v", err)
+				// Rejected releases are logged as failed downloads by rejectRelease;
+				// the download only starts once a release is accepted
+				err = j.downloadTorrent(ctx, candidate, movie)
+				if errors.Is(err, errNoFileList) {
+					j.bus.Publish(movieID, models.EventDownloadFailed,
+						fmt.Sprintf("Skipped '%s': %v", candidate.Title, err),
+						models.DownloadDetails{Title: candidate.Title, Indexer: candidate.Indexer,
+							Reason: "file list unavailable"})
 				}
+				var rejection *releaseRejection
+				if errors.As(err, &rejection) || errors.Is(err, errNoFileList) {
+					log.Printf("Trying next candidate for '%s' after rejecting '%s'", movie.Title, candidate.Title)
+					continue
+				}
+				if err == nil {
+					grabbed = &candidate
+				}
+				break
 			}
 
-			if err := j.downloadTorrent(best, movie); err != nil {
+			if grabbed == nil {
+				if err == nil {
+					err = fmt.Errorf("all %d candidate releases were rejected", len(bestResults))
+				}
 				log.Printf("Failed to download torrent for '%s': %v", movie.Title, err)
 				// Log download failure
-				if j.movieEventRepo != nil {
-					if err := j.movieEventRepo.Create(movieID, models.EventDownloadFailed,
-						fmt.Sprintf("Download failed: %v", err), nil); err != nil {
-						log.Printf("Failed to log download failure: %v", err)
-					}
-				}
+				j.bus.Publish(movieID, models.EventDownloadFailed,
+					fmt.Sprintf("Download failed: %v", err), models.DownloadDetails{Error: err.Error()})
 			} else {
 				// Update movie status to downloading
+				oldStatus := movie.Status
 				movie.Status = models.StatusDownloading
+				movie.Indexer = grabbed.Indexer
 				if err := j.movieRepo.Update(movie); err != nil {
 					log.Printf("Failed to update movie status: %v", err)
 				}
 				log.Printf("Successfully initiated download for '%s'", movie.Title)
 
 				// Log status change and download success
-				if j.movieEventRepo != nil {
-					if err := j.movieEventRepo.Create(movieID, models.EventStatusChanged,
-						fmt.Sprintf("Status changed to: %s", models.StatusDownloading),
-						map[string]interface{}{"old_status": movie.Status, "new_status": models.StatusDownloading}); err != nil {
-						log.Printf("Failed to log status change: %v", err)
-					}
-					if err := j.movieEventRepo.Create(movieID, models.EventDownloadStarted,
-						fmt.Sprintf("Download initiated for '%s'", best.Title), nil); err != nil {
-						log.Printf("Failed to log download success: %v", err)
-					}
-				}
+				j.bus.Publish(movieID, models.EventStatusChanged,
+					fmt.Sprintf("Status changed to: %s", models.StatusDownloading),
+					models.StatusChangeDetails{OldStatus: oldStatus, NewStatus: models.StatusDownloading,
+						Indexer: grabbed.Indexer})
+				j.bus.Publish(movieID, models.EventDownloadStarted,
+					fmt.Sprintf("Download initiated for '%s'", grabbed.Title),
+					models.DownloadDetails{Title: grabbed.Title, Indexer: grabbed.Indexer})
 			}
 		} else {
-			log.Printf("qBittorrent service not available - skipping download")
+			log.Printf("No download client available - skipping download")
 		}
 	} else {
 		log.Printf("No suitable torrents found for '%s' (%d)", movie.Title, movie.Year)
@@ -326,6 +419,12 @@ func (j *TorrentSearchJob) processResults(results []services.JackettSearchResult
 			continue
 		}
 
+		// Skip releases that were rejected before
+		if j.isBlocklisted(movie.ID, result) {
+			filteredCount["blocklisted"]++
+			continue
+		}
+
 		// Basic title relevance check
 		movieTitle := strings.ToUpper(movie.Title)
 		if !j.isRelevantTitle(title, movieTitle, movie.Year) {
@@ -342,7 +441,8 @@ func (j *TorrentSearchJob) processResults(results []services.JackettSearchResult
 			MagnetURI:   result.MagnetURI,
 			DownloadURL: result.Link,
 			InfoHash:    result.InfoHash,
-			Quality:     j.extractQuality(result.Title),
+			Indexer:     indexerName(result),
+			Quality:     extractQuality(result.Title),
 			Score:       j.scoreResult(result, movie),
 		}
 
@@ -353,6 +453,36 @@ func (j *TorrentSearchJob) processResults(results []services.JackettSearchResult
 	return processed
 }
 
+// indexerName identifies the indexer a result came from, preferring Jackett's stable tracker ID
+func indexerName(result services.JackettSearchResult) string {
+	if result.TrackerID != "" {
+		return result.TrackerID
+	}
+	return result.Tracker
+}
+
+// isBlocklisted reports whether a result matches a release previously rejected
+// for the movie
+func (j *TorrentSearchJob) isBlocklisted(movieID int, result services.JackettSearchResult) bool {
+	if j.blocklistRepo == nil {
+		return false
+	}
+
+	infoHash := result.InfoHash
+	if infoHash == "" {
+		if hash, err := services.MagnetInfoHash(result.MagnetURI); err == nil {
+			infoHash = hash
+		}
+	}
+
+	blocked, err := j.blocklistRepo.IsBlocklisted(movieID, infoHash, result.Title)
+	if err != nil {
+		log.Printf("Failed to check blocklist for '%s': %v", result.Title, err)
+		return false
+	}
+	return blocked
+}
+
 // isLowQualityRelease checks if a release is obviously low quality
 func (j *TorrentSearchJob) isLowQualityRelease(title string) bool {
 	lowQualityMarkers := []string{
@@ -433,8 +563,8 @@ func max(a, b int) int {
 	return b
 }
 
-// extractQuality attempts to extract quality information from torrent title
-func (j *TorrentSearchJob) extractQuality(title string) string {
+// extractQuality attempts to extract quality information from a torrent or file name
+func extractQuality(title string) string {
 	title = strings.ToUpper(title)
 
 	if strings.Contains(title, "2160P") || strings.Contains(title, "4K") {
@@ -862,7 +992,7 @@ func (j *TorrentSearchJob) selectBestResults(results []TorrentResult) []TorrentR
 }
 
 // ProcessMovieQueue processes movies that need torrent searches
-func (j *TorrentSearchJob) ProcessMovieQueue() error {
+func (j *TorrentSearchJob) ProcessMovieQueue(ctx context.Context) error {
 	// Get movies that need searching
 	movies, err := j.movieRepo.GetAll()
 	if err != nil {
@@ -875,17 +1005,13 @@ func (j *TorrentSearchJob) ProcessMovieQueue() error {
 			// Add a small delay between searches to be respectful to Jackett
 			time.Sleep(2 * time.Second)
 
-			if err := j.SearchForMovie(movie.ID); err != nil {
+			if err := j.SearchForMovie(ctx, movie.ID); err != nil {
 				log.Printf("Failed to search for movie %d (%s): %v", movie.ID, movie.Title, err)
 
 				// Log the search error
-				if j.movieEventRepo != nil {
-					if err := j.movieEventRepo.Create(movie.ID, models.EventSearchFailed,
-						fmt.Sprintf("Search error: %v", err),
-						map[string]interface{}{"error": err.Error()}); err != nil {
-						log.Printf("Failed to log search error: %v", err)
-					}
-				}
+				j.bus.Publish(movie.ID, models.EventSearchFailed,
+					fmt.Sprintf("Search error: %v", err),
+					models.SearchDetails{Error: err.Error()})
 				continue
 			}
 		}
@@ -894,38 +1020,252 @@ func (j *TorrentSearchJob) ProcessMovieQueue() error {
 	return nil
 }
 
-// downloadTorrent downloads a torrent using the best available method
-func (j *TorrentSearchJob) downloadTorrent(result TorrentResult, movie *models.Movie) error {
-	category := "movies"
-	downloadPath := "" // Use qBittorrent default path
-	
+// downloadTorrent downloads a torrent using the best available method. The
+// torrent is added paused and only resumed once its file list passes
+// inspection; rejected releases are returned as a *releaseRejection.
+func (j *TorrentSearchJob) downloadTorrent(ctx context.Context, result TorrentResult, movie *models.Movie) error {
+	if j.qbittorrentService == nil && j.blackholeService != nil {
+		return j.sendToBlackhole(ctx, result, movie)
+	}
+
+	opts := services.AddTorrentOptions{
+		Category: j.category,
+		SavePath: j.downloadDir, // Empty uses the qBittorrent default path
+		Paused:   true,
+	}
+
 	var torrentHash string
+	var files []releaseFile // Known up front for .torrent files, fetched from qBittorrent for magnets
 	var err error
 
 	// Try magnet URI first (preferred method)
 	if result.MagnetURI != "" && result.MagnetURI != "null" {
 		log.Printf("Downloading torrent via magnet URI for '%s'", movie.Title)
-		torrentHash, err = j.qbittorrentService.AddTorrent(result.MagnetURI, category, downloadPath)
+		torrentHash, err = j.qbittorrentService.AddTorrent(ctx, result.MagnetURI, opts)
 	} else if result.DownloadURL != "" {
 		// Fall back to download URL and use torrent file method
 		log.Printf("Downloading torrent via torrent file for '%s'", movie.Title)
-		torrentHash, err = j.qbittorrentService.AddTorrentFile(result.DownloadURL, category, downloadPath)
+		var download *services.TorrentDownload
+		download, err = services.FetchTorrent(ctx, j.qbittorrentService.Client, result.DownloadURL)
+		if err != nil {
+			return err
+		}
+		if download.Meta != nil {
+			for _, file := range download.Meta.Files {
+				files = append(files, releaseFile{Name: file.Path, Size: file.Length})
+			}
+		}
+		torrentHash, err = j.qbittorrentService.AddTorrentDownload(ctx, download, opts)
 	} else {
 		return fmt.Errorf("no magnet URI or download URL available")
 	}
-	
+
 	if err != nil {
 		return err
 	}
-	
+
+	if err := j.inspectTorrent(ctx, result, movie, torrentHash, files); err != nil {
+		return err
+	}
+
 	// Store the torrent hash in the movie record
-	if torrentHash != "" {
-		movie.TorrentHash = torrentHash
-		if err := j.movieRepo.Update(movie); err != nil {
-			log.Printf("Warning: failed to update movie with torrent hash: %v", err)
+	movie.TorrentHash = torrentHash
+	if err := j.movieRepo.Update(movie); err != nil {
+		log.Printf("Warning: failed to update movie with torrent hash: %v", err)
+	}
+
+	return nil
+}
+
+// inspectTorrent checks the file list of a paused torrent, removing and
+// blocklisting fake releases and resuming genuine ones. Without files it asks
+// qBittorrent for them; a torrent whose file list never arrives is removed
+// instead of being downloaded uninspected.
+func (j *TorrentSearchJob) inspectTorrent(ctx context.Context, result TorrentResult, movie *models.Movie, hash string, files []releaseFile) error {
+	if len(files) == 0 {
+		torrentFiles, err := j.waitForTorrentFiles(ctx, hash)
+		if err != nil {
+			log.Printf("Warning: could not inspect files of '%s': %v", result.Title, err)
+		}
+		for _, file := range torrentFiles {
+			files = append(files, releaseFile{Name: file.Name, Size: file.Size})
+		}
+	}
+
+	if len(files) == 0 {
+		log.Printf("No file list for '%s', removing it rather than downloading it uninspected", result.Title)
+		j.removePausedTorrent(ctx, result, hash)
+		return errNoFileList
+	}
+
+	if rejection := inspectReleaseFiles(files, result.Size); rejection != nil {
+		j.removePausedTorrent(ctx, result, hash)
+		j.rejectRelease(result, movie, hash, rejection)
+		return rejection
+	}
+
+	// qBittorrent numbers files by their position in the file list
+	if samples := sampleFileIndexes(files); len(samples) > 0 {
+		if err := j.qbittorrentService.SetFilePriority(ctx, hash, samples,
+			services.FilePriorityDoNotDownload); err != nil {
+			log.Printf("Warning: failed to skip sample files of '%s': %v", result.Title, err)
+		} else {
+			log.Printf("Skipping %d sample file(s) of '%s'", len(samples), result.Title)
+		}
+	}
+
+	if err := j.qbittorrentService.ResumeTorrent(ctx, hash); err != nil {
+		// Nothing tracks the torrent until its hash is saved, so don't leave it behind
+		j.removePausedTorrent(ctx, result, hash)
+		return fmt.Errorf("failed to start torrent: %w", err)
+	}
+	return nil
+}
+
+// removePausedTorrent removes a torrent that was added paused and will not be
+// downloaded
+func (j *TorrentSearchJob) removePausedTorrent(ctx context.Context, result TorrentResult, hash string) {
+	if err := j.qbittorrentService.RemoveTorrent(ctx, hash, true); err != nil {
+		log.Printf("Warning: failed to remove torrent '%s': %v", result.Title, err)
+	}
+}
+
+// waitForTorrentFiles polls qBittorrent until a torrent's file list is known
+func (j *TorrentSearchJob) waitForTorrentFiles(ctx context.Context, hash string) ([]services.QBTorrentFile, error) {
+	deadline := time.Now().Add(metadataTimeout)
+	for {
+		files, err := j.qbittorrentService.GetTorrentFiles(ctx, hash)
+		if err != nil || len(files) > 0 || time.Now().After(deadline) {
+			return files, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(metadataPollInterval):
+		}
+	}
+}
+
+// rejectRelease blocklists a fake release and records why it was rejected
+func (j *TorrentSearchJob) rejectRelease(result TorrentResult, movie *models.Movie, hash string, rejection *releaseRejection) {
+	log.Printf("Rejected '%s' for '%s': %s %v", result.Title, movie.Title, rejection.Reason, rejection.Files)
+
+	if j.blocklistRepo != nil {
+		entry := &models.BlocklistEntry{
+			MovieID:  movie.ID,
+			Title:    result.Title,
+			InfoHash: hash,
+			Reason:   rejection.Reason,
+		}
+		if err := j.blocklistRepo.Create(entry); err != nil {
+			log.Printf("Failed to blocklist release: %v", err)
+		}
+	}
+
+	j.bus.Publish(movie.ID, models.EventDownloadFailed,
+		fmt.Sprintf("Rejected '%s': %s", result.Title, rejection.Reason),
+		models.DownloadDetails{
+			Title:    result.Title,
+			Indexer:  result.Indexer,
+			InfoHash: hash,
+			Reason:   rejection.Reason,
+			Files:    rejection.Files,
+		})
+}
+
+// sendToBlackhole drops the release into the blackhole watch folder and
+// remembers the name the finished download is expected to have. Torrent files
+// are inspected before they are written; magnets cannot be.
+func (j *TorrentSearchJob) sendToBlackhole(ctx context.Context, result TorrentResult, movie *models.Movie) error {
+	name := result.Title
+	magnetURI := ""
+	if result.MagnetURI != "" && result.MagnetURI != "null" {
+		magnetURI = result.MagnetURI
+	}
+
+	var torrentHash string
+	if magnetURI == "" && result.DownloadURL != "" {
+		log.Printf("Fetching torrent file for blackhole for '%s'", movie.Title)
+		download, err := services.FetchTorrent(ctx, j.blackholeService.Client, result.DownloadURL)
+		if err != nil {
+			return err
+		}
+
+		if download.MagnetURI != "" {
+			magnetURI = download.MagnetURI
+		} else {
+			meta := download.Meta
+			files := make([]releaseFile, 0, len(meta.Files))
+			for _, file := range meta.Files {
+				files = append(files, releaseFile{Name: file.Path, Size: file.Length})
+			}
+			if rejection := inspectReleaseFiles(files, result.Size); rejection != nil {
+				j.rejectRelease(result, movie, meta.InfoHash, rejection)
+				return rejection
+			}
+
+			if err := j.blackholeService.WriteTorrent(download.Data, meta.Name); err != nil {
+				return err
+			}
+			// The client names the finished download after the torrent's own name
+			torrentHash, name = meta.InfoHash, meta.Name
 		}
 	}
-	
+
+	if magnetURI != "" {
+		if displayName := services.MagnetDisplayName(magnetURI); displayName != "" {
+			name = displayName
+		}
+		log.Printf("Sending magnet to blackhole for '%s'", movie.Title)
+		hash, err := j.blackholeService.AddMagnet(magnetURI, name)
+		if err != nil {
+			return err
+		}
+		torrentHash = hash
+	}
+
+	if torrentHash == "" {
+		return fmt.Errorf("no magnet URI or download URL available")
+	}
+
+	movie.DownloadName = name
+	movie.TorrentHash = torrentHash
+	if err := j.movieRepo.Update(movie); err != nil {
+		log.Printf("Warning: failed to update movie with download name: %v", err)
+	}
+
+	return nil
+}
+
+// checkFreeSpace refuses a release that would leave the movie's root folder,
+// or the download directory when it is visible locally, with less free space
+// than the root folder's minimum
+func (j *TorrentSearchJob) checkFreeSpace(movie *models.Movie, result TorrentResult) error {
+	folder := rootFolderFor(j.rootFolderRepo, movie)
+	if folder == nil {
+		return nil
+	}
+
+	paths := []string{folder.Path}
+	if j.downloadDir != "" {
+		if _, err := os.Stat(j.downloadDir); err == nil {
+			paths = append(paths, j.downloadDir)
+		}
+	}
+
+	for _, path := range paths {
+		free, _, err := services.DiskSpace(path)
+		if err != nil {
+			return err
+		}
+		if free-result.Size < folder.MinFreeSpace {
+			return fmt.Errorf("not enough free space in %s: %.2f GB free, release is %.2f GB and %.2f GB must stay free",
+				path, float64(free)/(1024*1024*1024), float64(result.Size)/(1024*1024*1024),
+				float64(folder.MinFreeSpace)/(1024*1024*1024))
+		}
+	}
+
 	return nil
 }
 
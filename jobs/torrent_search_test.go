@@ -0,0 +1,162 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"media/database"
+	"media/models"
+	"media/notifications"
+	"media/repository"
+	"media/services"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countingProvider counts the notifications of each kind it is sent
+type countingProvider map[notifications.Kind]int
+
+func (p countingProvider) Name() string { return "counter" }
+
+func (p countingProvider) Send(_ context.Context, notification notifications.Notification) error {
+	p[notification.Kind]++
+	return nil
+}
+
+func TestTorrentSearchJob_RepeatSearchDoesNotNotifyNotFoundAgain(t *testing.T) {
+	testDB, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	defer func() {
+		if err := testDB.Close(); err != nil {
+			t.Logf("Failed to close test database: %v", err)
+		}
+	}()
+	testDB.SetMaxOpenConns(1)
+	if err := testDB.InitSchema(); err != nil {
+		t.Fatalf("Failed to initialize test schema: %v", err)
+	}
+
+	// Jackett never has anything
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"Results":[],"Indexers":[]}`))
+	}))
+	defer server.Close()
+
+	movieRepo := repository.NewMovieRepository(testDB)
+	movieEventRepo := repository.NewMovieEventRepository(testDB)
+	movie := &models.Movie{Title: "Heat", Year: 1995, Status: models.StatusWanted}
+	assert.NoError(t, movieRepo.Create(movie))
+
+	sent := countingProvider{}
+	notifier := notifications.NewNotifier(movieRepo)
+	notifier.AddProvider(sent, nil)
+	bus := newTestBus(movieEventRepo)
+	bus.Subscribe("notifications", func(event *models.MovieEvent) error {
+		notifier.Notify(context.Background(), *event)
+		return nil
+	})
+
+	job := NewTorrentSearchJob(movieRepo, bus, services.NewJackettService(server.URL, "key"), nil)
+	assert.NoError(t, job.SearchForMovie(context.Background(), movie.ID))
+	assert.NoError(t, job.SearchForMovie(context.Background(), movie.ID))
+
+	stored, err := movieRepo.GetByID(movie.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.StatusNotFound, stored.Status)
+	assert.Equal(t, 1, sent[notifications.KindNotFound])
+}
+
+func TestTorrentSearchJob_StartsDownloadOnceAfterRejections(t *testing.T) {
+	const fakeHash = "1111111111111111111111111111111111111111"
+	const genuineHash = "2222222222222222222222222222222222222222"
+
+	testDB, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	defer func() {
+		if err := testDB.Close(); err != nil {
+			t.Logf("Failed to close test database: %v", err)
+		}
+	}()
+	testDB.SetMaxOpenConns(1)
+	if err := testDB.InitSchema(); err != nil {
+		t.Fatalf("Failed to initialize test schema: %v", err)
+	}
+
+	// The best release only holds an executable, the next one is genuine
+	jackett := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(services.JackettResponse{Results: []services.JackettSearchResult{
+			{Title: "Heat.1995.1080p.BluRay.x264", Size: 8 << 30, Seeders: 500, Tracker: "1337x",
+				MagnetURI: "magnet:?xt=urn:btih:" + fakeHash},
+			{Title: "Heat.1995.1080p.WEB-DL.x264", Size: 6 << 30, Seeders: 50, Tracker: "YTS",
+				MagnetURI: "magnet:?xt=urn:btih:" + genuineHash},
+		}})
+	}))
+	defer jackett.Close()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/auth/login", func(w http.ResponseWriter, _ *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "SID", Value: "session", Path: "/"})
+		_, _ = w.Write([]byte("Ok."))
+	})
+	mux.HandleFunc("/api/v2/app/version", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("v4.6.2"))
+	})
+	mux.HandleFunc("/api/v2/torrents/info", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode([]services.QBTorrent{{Hash: r.FormValue("hashes"), Name: "Heat.1995"}})
+	})
+	mux.HandleFunc("/api/v2/torrents/files", func(w http.ResponseWriter, r *http.Request) {
+		files := []services.QBTorrentFile{{Name: "Heat.1995/Heat.1995.mkv", Size: 6 << 30}}
+		if r.FormValue("hash") == fakeHash {
+			files = []services.QBTorrentFile{{Name: "Heat.1995/Heat.1995.exe", Size: 8 << 30}}
+		}
+		_ = json.NewEncoder(w).Encode(files)
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("Ok."))
+	})
+	qbittorrent := httptest.NewServer(mux)
+	defer qbittorrent.Close()
+
+	movieRepo := repository.NewMovieRepository(testDB)
+	movieEventRepo := repository.NewMovieEventRepository(testDB)
+	movie := &models.Movie{Title: "Heat", Year: 1995, Status: models.StatusWanted}
+	assert.NoError(t, movieRepo.Create(movie))
+
+	job := NewTorrentSearchJob(movieRepo, newTestBus(movieEventRepo),
+		services.NewJackettService(jackett.URL, "key"),
+		services.NewQBittorrentService(qbittorrent.URL, "admin", "secret"))
+	assert.NoError(t, job.SearchForMovie(context.Background(), movie.ID))
+
+	stored, err := movieRepo.GetByID(movie.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.StatusDownloading, stored.Status)
+	assert.Equal(t, genuineHash, stored.TorrentHash)
+
+	// The rejected release is a failed download, and only the genuine one
+	// started
+	started, err := movieEventRepo.Search(models.EventFilter{MovieID: movie.ID,
+		Types: []models.MovieEventType{models.EventDownloadStarted}})
+	assert.NoError(t, err)
+	if assert.Len(t, started, 1) {
+		assert.Contains(t, started[0].Message, "Heat.1995.1080p.WEB-DL.x264")
+	}
+	failed, err := movieEventRepo.Search(models.EventFilter{MovieID: movie.ID,
+		Types: []models.MovieEventType{models.EventDownloadFailed}})
+	assert.NoError(t, err)
+	if assert.Len(t, failed, 1) {
+		var details models.DownloadDetails
+		assert.NoError(t, failed[0].DecodeDetails(&details))
+		assert.Equal(t, "Heat.1995.1080p.BluRay.x264", details.Title)
+		assert.NotEmpty(t, details.Reason)
+		assert.Equal(t, []string{"Heat.1995/Heat.1995.exe"}, details.Files)
+	}
+}
@@ -0,0 +1,240 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"media/models"
+	"media/repository"
+)
+
+// webhookRetryDelays is how long a failed delivery waits before each retry.
+// A delivery is given up on once they are used up.
+var webhookRetryDelays = []time.Duration{
+	time.Minute,
+	5 * time.Minute,
+	30 * time.Minute,
+	2 * time.Hour,
+	6 * time.Hour,
+}
+
+// WebhookPayload is the JSON body posted to webhooks for a movie event
+type WebhookPayload struct {
+	EventID        int                   `json:"event_id"`
+	Event          models.MovieEventType `json:"event"`
+	Message        string                `json:"message"`
+	Details        json.RawMessage       `json:"details,omitempty"`
+	DetailsVersion int                   `json:"details_version,omitempty"`
+	Timestamp      time.Time             `json:"timestamp"`
+	Movie          *models.Movie         `json:"movie,omitempty"`
+}
+
+// SignWebhookPayload returns the X-Media-Signature header for a body: the
+// hex HMAC-SHA256 of the body keyed with the webhook secret
+func SignWebhookPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// WebhookDispatcher posts movie events to webhooks, logging every delivery
+// and retrying failed ones with backoff
+type WebhookDispatcher struct {
+	webhookRepo *repository.WebhookRepository
+	movieRepo   *repository.MovieRepository
+	client      *http.Client
+	now         func() time.Time
+}
+
+// NewWebhookDispatcher creates a new webhook dispatcher
+func NewWebhookDispatcher(webhookRepo *repository.WebhookRepository, movieRepo *repository.MovieRepository) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		webhookRepo: webhookRepo,
+		movieRepo:   movieRepo,
+		client:      &http.Client{Timeout: 30 * time.Second},
+		now:         time.Now,
+	}
+}
+
+// HandleEvent queues deliveries of a stored movie event and makes their first
+// attempt in the background, so it can subscribe to the event bus. A nil
+// dispatcher does nothing.
+func (d *WebhookDispatcher) HandleEvent(event *models.MovieEvent) error {
+	if d == nil {
+		return nil
+	}
+
+	deliveries, err := d.Enqueue(*event)
+	for i := range deliveries {
+		go func(delivery models.WebhookDelivery) {
+			if err := d.Attempt(context.Background(), &delivery); err != nil {
+				log.Printf("Failed to attempt webhook delivery %d: %v", delivery.ID, err)
+			}
+		}(deliveries[i])
+	}
+	return err
+}
+
+// Enqueue records a pending delivery of an event for every enabled webhook
+// that wants it. The payload carries the movie as it is now.
+func (d *WebhookDispatcher) Enqueue(event models.MovieEvent) ([]models.WebhookDelivery, error) {
+	webhooks, err := d.webhookRepo.GetEnabled()
+	if err != nil {
+		return nil, err
+	}
+
+	var deliveries []models.WebhookDelivery
+	var body []byte
+	for _, webhook := range webhooks {
+		if !webhook.Wants(event.Type) {
+			continue
+		}
+		if body == nil {
+			if body, err = d.payload(event); err != nil {
+				return nil, err
+			}
+		}
+
+		nextAttemptAt := d.now().Add(webhookRetryDelays[0])
+		delivery := models.WebhookDelivery{
+			WebhookID:     webhook.ID,
+			EventID:       event.ID,
+			EventType:     event.Type,
+			Payload:       string(body),
+			NextAttemptAt: &nextAttemptAt,
+		}
+		if err := d.webhookRepo.CreateDelivery(&delivery); err != nil {
+			return deliveries, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, nil
+}
+
+// payload builds the JSON body of an event
+func (d *WebhookDispatcher) payload(event models.MovieEvent) ([]byte, error) {
+	payload := WebhookPayload{
+		EventID:        event.ID,
+		Event:          event.Type,
+		Message:        event.Message,
+		Details:        event.Details,
+		DetailsVersion: event.DetailsVersion,
+		Timestamp:      event.CreatedAt,
+	}
+	if movie, err := d.movieRepo.GetByID(event.MovieID); err == nil {
+		payload.Movie = movie
+	}
+	return json.Marshal(payload)
+}
+
+// Attempt posts a delivery to its webhook and saves the outcome. A failed
+// attempt is scheduled for a retry until the retries run out.
+func (d *WebhookDispatcher) Attempt(ctx context.Context, delivery *models.WebhookDelivery) error {
+	webhook, err := d.webhookRepo.GetByID(delivery.WebhookID)
+	if err != nil {
+		return err
+	}
+
+	delivery.Attempts++
+	status, body, err := d.post(ctx, webhook, delivery)
+	delivery.ResponseStatus = status
+	delivery.ResponseBody = body
+	now := d.now()
+
+	if err == nil {
+		delivery.Status = models.DeliverySucceeded
+		delivery.Error = ""
+		delivery.NextAttemptAt = nil
+		delivery.DeliveredAt = &now
+	} else {
+		delivery.Error = err.Error()
+		if delivery.Attempts > len(webhookRetryDelays) {
+			delivery.Status = models.DeliveryFailed
+			delivery.NextAttemptAt = nil
+			log.Printf("Giving up on webhook delivery %d to '%s' after %d attempts: %v",
+				delivery.ID, webhook.Name, delivery.Attempts, err)
+		} else {
+			delivery.Status = models.DeliveryPending
+			nextAttemptAt := now.Add(webhookRetryDelays[delivery.Attempts-1])
+			delivery.NextAttemptAt = &nextAttemptAt
+		}
+	}
+
+	return d.webhookRepo.UpdateDelivery(delivery)
+}
+
+// post sends a delivery's payload, returning the response status and the
+// start of the response body
+func (d *WebhookDispatcher) post(ctx context.Context, webhook *models.Webhook, delivery *models.WebhookDelivery) (int, string, error) {
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "media-webhooks")
+	req.Header.Set("X-Media-Event", string(delivery.EventType))
+	req.Header.Set("X-Media-Delivery", fmt.Sprint(delivery.ID))
+	req.Header.Set("X-Media-Signature", SignWebhookPayload(webhook.Secret, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to reach webhook: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Printf("Failed to close response body: %v", err)
+		}
+	}()
+
+	responseBody, _ := io.ReadAll(io.LimitReader(resp.Body, 2048))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, string(responseBody), fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, string(responseBody), nil
+}
+
+// RetryDue attempts the pending deliveries whose retry is due
+func (d *WebhookDispatcher) RetryDue(ctx context.Context) error {
+	deliveries, err := d.webhookRepo.GetDueDeliveries(d.now())
+	if err != nil {
+		return err
+	}
+
+	for i := range deliveries {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := d.Attempt(ctx, &deliveries[i]); err != nil {
+			log.Printf("Failed to retry webhook delivery %d: %v", deliveries[i].ID, err)
+		}
+	}
+	return nil
+}
+
+// Redeliver sends the payload of an earlier delivery again as a new delivery,
+// which is retried like any other if it fails
+func (d *WebhookDispatcher) Redeliver(ctx context.Context, original *models.WebhookDelivery) (*models.WebhookDelivery, error) {
+	delivery := models.WebhookDelivery{
+		WebhookID: original.WebhookID,
+		EventID:   original.EventID,
+		EventType: original.EventType,
+		Payload:   original.Payload,
+	}
+	if err := d.webhookRepo.CreateDelivery(&delivery); err != nil {
+		return nil, err
+	}
+	if err := d.Attempt(ctx, &delivery); err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
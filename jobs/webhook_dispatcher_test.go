@@ -0,0 +1,174 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"media/database"
+	"media/models"
+	"media/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookDispatcher(t *testing.T) {
+	testDB, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	defer func() {
+		if err := testDB.Close(); err != nil {
+			t.Logf("Failed to close test database: %v", err)
+		}
+	}()
+	if err := testDB.InitSchema(); err != nil {
+		t.Fatalf("Failed to initialize test schema: %v", err)
+	}
+
+	// The endpoint is down for its first two requests
+	var received []WebhookPayload
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, SignWebhookPayload("s3cret", body), r.Header.Get("X-Media-Signature"))
+		assert.Equal(t, "imported", r.Header.Get("X-Media-Event"))
+
+		requests++
+		if requests <= 2 {
+			http.Error(w, "try again later", http.StatusServiceUnavailable)
+			return
+		}
+		var payload WebhookPayload
+		assert.NoError(t, json.Unmarshal(body, &payload))
+		received = append(received, payload)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	movieRepo := repository.NewMovieRepository(testDB)
+	webhookRepo := repository.NewWebhookRepository(testDB)
+	movie := createReadyMovie(t, movieRepo, "Heat", "/movies/Heat (1995)/Heat (1995).mkv", 1024, 0)
+	webhook := &models.Webhook{Name: "automation", URL: server.URL, Secret: "s3cret",
+		Events: []models.MovieEventType{models.EventImported}, Enabled: true}
+	assert.NoError(t, webhookRepo.Create(webhook))
+
+	now := time.Now()
+	dispatcher := NewWebhookDispatcher(webhookRepo, movieRepo)
+	dispatcher.now = func() time.Time { return now }
+
+	// Events the webhook does not want are not queued
+	deliveries, err := dispatcher.Enqueue(models.MovieEvent{ID: 1, MovieID: movie.ID, Type: models.EventDownloadStarted})
+	assert.NoError(t, err)
+	assert.Empty(t, deliveries)
+
+	deliveries, err = dispatcher.Enqueue(models.MovieEvent{ID: 2, MovieID: movie.ID, Type: models.EventImported,
+		Message: "Imported Heat", Details: json.RawMessage(`{"path":"/movies/Heat (1995)/Heat (1995).mkv"}`), DetailsVersion: 1, CreatedAt: now})
+	assert.NoError(t, err)
+	if !assert.Len(t, deliveries, 1) {
+		return
+	}
+	delivery := deliveries[0]
+
+	// A failed attempt is retried once its backoff has passed
+	assert.NoError(t, dispatcher.Attempt(context.Background(), &delivery))
+	stored, err := webhookRepo.GetDelivery(delivery.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.DeliveryPending, stored.Status)
+	assert.Equal(t, 1, stored.Attempts)
+	assert.Equal(t, http.StatusServiceUnavailable, stored.ResponseStatus)
+	assert.Contains(t, stored.ResponseBody, "try again later")
+
+	assert.NoError(t, dispatcher.RetryDue(context.Background()))
+	stored, _ = webhookRepo.GetDelivery(delivery.ID)
+	assert.Equal(t, 1, stored.Attempts)
+
+	now = now.Add(2 * time.Minute)
+	assert.NoError(t, dispatcher.RetryDue(context.Background()))
+	now = now.Add(10 * time.Minute)
+	assert.NoError(t, dispatcher.RetryDue(context.Background()))
+	stored, _ = webhookRepo.GetDelivery(delivery.ID)
+	assert.Equal(t, models.DeliverySucceeded, stored.Status)
+	assert.Equal(t, 3, stored.Attempts)
+	assert.Nil(t, stored.NextAttemptAt)
+	assert.NotNil(t, stored.DeliveredAt)
+
+	if assert.Len(t, received, 1) {
+		assert.Equal(t, models.EventImported, received[0].Event)
+		assert.Equal(t, 2, received[0].EventID)
+		assert.JSONEq(t, `{"path":"/movies/Heat (1995)/Heat (1995).mkv"}`, string(received[0].Details))
+		assert.Equal(t, 1, received[0].DetailsVersion)
+		if assert.NotNil(t, received[0].Movie) {
+			assert.Equal(t, "Heat", received[0].Movie.Title)
+			assert.Equal(t, models.StatusReady, received[0].Movie.Status)
+		}
+	}
+
+	// Redelivery sends the same payload as a new delivery
+	redelivered, err := dispatcher.Redeliver(context.Background(), stored)
+	assert.NoError(t, err)
+	assert.NotEqual(t, stored.ID, redelivered.ID)
+	assert.Equal(t, models.DeliverySucceeded, redelivered.Status)
+	assert.Len(t, received, 2)
+
+	logged, err := webhookRepo.GetDeliveries(webhook.ID, 10)
+	assert.NoError(t, err)
+	assert.Len(t, logged, 2)
+
+	// Nothing happens without a dispatcher
+	var none *WebhookDispatcher
+	assert.NoError(t, none.HandleEvent(&models.MovieEvent{ID: 3, MovieID: movie.ID, Type: models.EventImported}))
+}
+
+func TestWebhookDispatcher_GivesUp(t *testing.T) {
+	testDB, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	defer func() {
+		if err := testDB.Close(); err != nil {
+			t.Logf("Failed to close test database: %v", err)
+		}
+	}()
+	if err := testDB.InitSchema(); err != nil {
+		t.Fatalf("Failed to initialize test schema: %v", err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	webhookRepo := repository.NewWebhookRepository(testDB)
+	webhook := &models.Webhook{Name: "broken", URL: server.URL, Secret: "s3cret", Enabled: true}
+	assert.NoError(t, webhookRepo.Create(webhook))
+
+	now := time.Now()
+	dispatcher := NewWebhookDispatcher(webhookRepo, repository.NewMovieRepository(testDB))
+	dispatcher.now = func() time.Time { return now }
+
+	deliveries, err := dispatcher.Enqueue(models.MovieEvent{ID: 1, MovieID: 1, Type: models.EventSearchFailed})
+	assert.NoError(t, err)
+	if !assert.Len(t, deliveries, 1) {
+		return
+	}
+	delivery := deliveries[0]
+
+	for attempt := 1; attempt <= len(webhookRetryDelays); attempt++ {
+		assert.NoError(t, dispatcher.Attempt(context.Background(), &delivery))
+		assert.Equal(t, models.DeliveryPending, delivery.Status)
+	}
+	assert.NoError(t, dispatcher.Attempt(context.Background(), &delivery))
+
+	stored, err := webhookRepo.GetDelivery(delivery.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.DeliveryFailed, stored.Status)
+	assert.Equal(t, len(webhookRetryDelays)+1, stored.Attempts)
+	assert.Nil(t, stored.NextAttemptAt)
+	assert.Contains(t, stored.Error, "status 500")
+}
@@ -2,7 +2,9 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -12,8 +14,10 @@ import (
 	"time"
 
 	"media/database"
+	"media/events"
 	"media/jobs"
 	"media/models"
+	"media/notifications"
 	"media/repository"
 	"media/services"
 
@@ -25,10 +29,29 @@ import (
 type App struct {
 	movieRepo          *repository.MovieRepository
 	movieEventRepo     *repository.MovieEventRepository
+	blocklistRepo      *repository.BlocklistRepository
+	seedingPolicyRepo  *repository.SeedingPolicyRepository
+	rootFolderRepo     *repository.RootFolderRepository
+	pathMappingRepo    *repository.RemotePathMappingRepository
+	mediaFileRepo      *repository.MediaFileRepository
+	mediaServerRepo    *repository.MediaServerRepository
+	webhookRepo        *repository.WebhookRepository
+	searchRunRepo      *repository.SearchRunRepository
+	statsRepo          *repository.StatsRepository
 	tmdbService        *services.TMDBService
 	jackettService     *services.JackettService
 	qbittorrentService *services.QBittorrentService
 	jobManager         *jobs.JobManager
+	libraryScanner     *jobs.LibraryScanner
+	mediaAnalyzer      *jobs.MediaAnalyzer
+	metadataWriter     *jobs.MetadataWriter
+	artworkCache       *services.ArtworkCache
+	mediaServers       *jobs.MediaServerNotifier
+	notifier           *notifications.Notifier
+	webhooks           *jobs.WebhookDispatcher
+	eventHub           *eventHub
+	bus                *events.Bus
+	eventRetention     *jobs.EventRetentionJob
 }
 
 func main() {
@@ -56,6 +79,33 @@ func main() {
 	// Initialize repositories
 	movieRepo := repository.NewMovieRepository(db)
 	movieEventRepo := repository.NewMovieEventRepository(db)
+	blocklistRepo := repository.NewBlocklistRepository(db)
+	seedingPolicyRepo := repository.NewSeedingPolicyRepository(db)
+	rootFolderRepo := repository.NewRootFolderRepository(db)
+	pathMappingRepo := repository.NewRemotePathMappingRepository(db)
+	mediaFileRepo := repository.NewMediaFileRepository(db)
+	mediaServerRepo := repository.NewMediaServerRepository(db)
+	webhookRepo := repository.NewWebhookRepository(db)
+	searchRunRepo := repository.NewSearchRunRepository(db)
+	statsRepo := repository.NewStatsRepository(db)
+
+	// Movie events are published once on the bus. They are stored first, so
+	// the subscribers after persistence see their IDs.
+	bus := events.NewBus(4)
+	bus.Subscribe("persistence", movieEventRepo.Save)
+
+	// Tell people about grabbed, failed and ready movies
+	notifier := notifierFromEnv(movieRepo)
+	bus.Subscribe("notifications", notifier.HandleEvent)
+
+	// Push movie events and status changes to live event streams
+	hub := newEventHub()
+	bus.Subscribe("event stream", hub.publishEvent)
+	movieRepo.AddStatusListener(hub.publishStatus)
+
+	// Post signed movie events to webhooks, keeping a log of every delivery
+	webhooks := jobs.NewWebhookDispatcher(webhookRepo, movieRepo)
+	bus.Subscribe("webhooks", webhooks.HandleEvent)
 
 	// Initialize TMDB service
 	tmdbAPIKey := os.Getenv("TMDB_API_KEY")
@@ -64,6 +114,13 @@ func main() {
 	}
 	tmdbService := services.NewTMDBService(tmdbAPIKey)
 
+	// Keep local copies of posters and backdrops
+	artworkDir := os.Getenv("ARTWORK_CACHE_DIR")
+	if artworkDir == "" {
+		artworkDir = "artwork"
+	}
+	artworkCache := services.NewArtworkCache(artworkDir)
+
 	// Initialize Jackett service
 	jackettURL := os.Getenv("JACKETT_URL")
 	if jackettURL == "" {
@@ -81,45 +138,130 @@ func main() {
 		log.Println("Warning: JACKETT_API_KEY not set - torrent search will be disabled")
 	}
 
-	// Initialize qBittorrent service
-	qbittorrentURL := os.Getenv("QBITTORRENT_URL")
-	if qbittorrentURL == "" {
-		qbittorrentURL = "http://localhost:8081" // Default qBittorrent WebUI URL
+	// Initialize download client
+	var blackholeService *services.BlackholeService
+	downloadClient := os.Getenv("DOWNLOAD_CLIENT")
+	if downloadClient == "" {
+		downloadClient = "qbittorrent"
 	}
-	qbittorrentUsername := os.Getenv("QBITTORRENT_USERNAME")
-	qbittorrentPassword := os.Getenv("QBITTORRENT_PASSWORD")
 
-	if qbittorrentUsername != "" && qbittorrentPassword != "" {
-		qbittorrentService = services.NewQBittorrentService(qbittorrentURL, qbittorrentUsername, qbittorrentPassword)
+	switch downloadClient {
+	case "blackhole":
+		watchDir := os.Getenv("BLACKHOLE_WATCH_DIR")
+		completedDir := os.Getenv("BLACKHOLE_COMPLETED_DIR")
+		if watchDir == "" || completedDir == "" {
+			log.Println("Warning: BLACKHOLE_WATCH_DIR and BLACKHOLE_COMPLETED_DIR are required for blackhole mode")
+			break
+		}
 
-		// Test qBittorrent connection
-		if err := qbittorrentService.TestConnection(); err != nil {
-			log.Printf("Warning: qBittorrent connection failed: %v", err)
+		blackholeService = services.NewBlackholeService(watchDir, completedDir)
+		if err := blackholeService.TestConnection(); err != nil {
+			log.Printf("Warning: blackhole folders unavailable: %v", err)
 			log.Println("Torrents will be found but not automatically downloaded")
-			qbittorrentService = nil
+			blackholeService = nil
 		} else {
-			log.Println("qBittorrent integration enabled")
+			log.Printf("Blackhole download client enabled (watch: %s, completed: %s)", watchDir, completedDir)
 		}
-	} else {
-		log.Println("Warning: qBittorrent credentials not set - torrents will not be downloaded automatically")
+	case "qbittorrent":
+		qbittorrentService = initQBittorrent()
+	default:
+		log.Printf("Warning: unknown DOWNLOAD_CLIENT %q - torrents will not be downloaded automatically", downloadClient)
 	}
 
-	// Initialize job system if Jackett is available
+	// Initialize job system
+	var torrentSearchJob *jobs.TorrentSearchJob
 	if jackettService != nil {
-		torrentSearchJob := jobs.NewTorrentSearchJob(movieRepo, movieEventRepo, jackettService, qbittorrentService)
-		jobManager = jobs.NewJobManager(torrentSearchJob)
+		torrentSearchJob = jobs.NewTorrentSearchJob(movieRepo, bus, jackettService, qbittorrentService)
+		torrentSearchJob.SetBlackholeService(blackholeService)
+		torrentSearchJob.SetBlocklistRepository(blocklistRepo)
+		torrentSearchJob.SetRootFolderRepository(rootFolderRepo)
+		torrentSearchJob.SetSearchRunRepository(searchRunRepo)
+		category := os.Getenv("QBITTORRENT_CATEGORY")
+		if category == "" {
+			category = "movies"
+		}
+		torrentSearchJob.SetDownloadOptions(category, os.Getenv("QBITTORRENT_DOWNLOAD_DIR"))
+	}
+	jobManager = jobs.NewJobManager(torrentSearchJob)
+
+	// Probe library files for their real resolution, codecs and duration
+	mediaAnalyzer := jobs.NewMediaAnalyzer(movieRepo, bus, mediaFileRepo)
+
+	// Write NFO files and artwork for media servers into root folders that want them
+	metadataWriter := jobs.NewMetadataWriter(rootFolderRepo, tmdbService)
+
+	// Tell Jellyfin, Emby, Plex and Kodi about changed movie files
+	mediaServers := jobs.NewMediaServerNotifier(mediaServerRepo)
+
+	// Watch active downloads and import them once they finish
+	if qbittorrentService != nil || blackholeService != nil {
+		importer := jobs.NewImporter(movieRepo, bus)
+		importer.SetRootFolderRepository(rootFolderRepo)
+		importer.SetOptions(importOptionsFromEnv())
+		importer.SetMediaAnalyzer(mediaAnalyzer)
+		importer.SetMetadataWriter(metadataWriter)
+		importer.SetMediaServerNotifier(mediaServers)
+		downloadMonitor := jobs.NewDownloadMonitorJob(movieRepo, qbittorrentService, blackholeService, importer)
+		downloadMonitor.SetRemotePathMappings(pathMappingRepo)
+		jobManager.AddPeriodicTask("download monitor", time.Minute, downloadMonitor.CheckDownloads)
+	}
+
+	// Remove torrents of imported movies once their seeding goals are met
+	if qbittorrentService != nil {
+		seedingCleanup := jobs.NewSeedingCleanupJob(movieRepo, bus, seedingPolicyRepo, qbittorrentService)
+		seedingCleanup.SetRemotePathMappings(pathMappingRepo)
+		jobManager.AddPeriodicTask("seeding cleanup", 15*time.Minute, seedingCleanup.CheckSeeding)
+	}
 
-		// Start job manager
-		jobManager.Start()
+	// Notice movies whose files were deleted or moved outside the app
+	integrityCheck := jobs.NewIntegrityCheckJob(movieRepo, bus)
+	integrityCheck.SetRootFolderRepository(rootFolderRepo)
+	integrityCheck.SetMediaServerNotifier(mediaServers)
+	if requeue, err := strconv.ParseBool(os.Getenv("REQUEUE_MISSING_MOVIES")); err == nil {
+		integrityCheck.SetRequeue(requeue)
 	}
+	jobManager.AddPeriodicTask("integrity check", time.Hour, integrityCheck.CheckFiles)
+
+	// Retry webhook deliveries that failed
+	jobManager.AddPeriodicTask("webhook retries", time.Minute, webhooks.RetryDue)
+
+	// Keep movie_events from growing without bound
+	eventRetention := jobs.NewEventRetentionJob(movieEventRepo, eventRetentionFromEnv())
+	eventRetention.SetSearchRunRepository(searchRunRepo)
+	jobManager.AddPeriodicTask("event retention", 6*time.Hour, eventRetention.Enforce)
+
+	// Start job manager
+	jobManager.Start()
+
+	libraryScanner := jobs.NewLibraryScanner(movieRepo, bus, tmdbService)
+	libraryScanner.SetMediaAnalyzer(mediaAnalyzer)
 
 	app := &App{
 		movieRepo:          movieRepo,
 		movieEventRepo:     movieEventRepo,
+		blocklistRepo:      blocklistRepo,
+		seedingPolicyRepo:  seedingPolicyRepo,
+		rootFolderRepo:     rootFolderRepo,
+		pathMappingRepo:    pathMappingRepo,
+		mediaFileRepo:      mediaFileRepo,
+		mediaServerRepo:    mediaServerRepo,
+		webhookRepo:        webhookRepo,
+		searchRunRepo:      searchRunRepo,
+		statsRepo:          statsRepo,
 		tmdbService:        tmdbService,
 		jackettService:     jackettService,
 		qbittorrentService: qbittorrentService,
 		jobManager:         jobManager,
+		libraryScanner:     libraryScanner,
+		mediaAnalyzer:      mediaAnalyzer,
+		metadataWriter:     metadataWriter,
+		artworkCache:       artworkCache,
+		mediaServers:       mediaServers,
+		notifier:           notifier,
+		webhooks:           webhooks,
+		eventHub:           hub,
+		bus:                bus,
+		eventRetention:     eventRetention,
 	}
 
 	r := mux.NewRouter()
@@ -136,10 +278,66 @@ func main() {
 	api.HandleFunc("/movies/{id}/details", app.getMovieDetailsHandler).Methods("GET")
 	api.HandleFunc("/movies/{id}/restart-job", app.restartMovieJobHandler).Methods("POST")
 	api.HandleFunc("/movies/{id}/cancel-job", app.cancelMovieJobHandler).Methods("POST")
+	api.HandleFunc("/movies/{id}/mediainfo", app.getMovieMediaInfoHandler).Methods("GET")
+	api.HandleFunc("/movies/{id}/refresh", app.refreshMovieHandler).Methods("POST")
+	api.HandleFunc("/movies/{id}/poster", app.getMoviePosterHandler).Methods("GET")
+	api.HandleFunc("/movies/{id}/backdrop", app.getMovieBackdropHandler).Methods("GET")
 	api.HandleFunc("/movies/{id}", app.deleteMovieHandler).Methods("DELETE")
 	api.HandleFunc("/movies", app.createMovieHandler).Methods("POST")
 	api.HandleFunc("/movies/tmdb/{tmdb_id}", app.addMovieFromTMDBHandler).Methods("POST")
 
+	// Blocklist endpoints
+	api.HandleFunc("/blocklist", app.getBlocklistHandler).Methods("GET")
+	api.HandleFunc("/blocklist/{id}", app.deleteBlocklistEntryHandler).Methods("DELETE")
+
+	// Root folder endpoints
+	api.HandleFunc("/rootfolders", app.getRootFoldersHandler).Methods("GET")
+	api.HandleFunc("/rootfolders", app.createRootFolderHandler).Methods("POST")
+	api.HandleFunc("/rootfolders/{id}", app.updateRootFolderHandler).Methods("PUT")
+	api.HandleFunc("/rootfolders/{id}", app.deleteRootFolderHandler).Methods("DELETE")
+	api.HandleFunc("/rootfolders/{id}/scan", app.scanRootFolderHandler).Methods("POST")
+	api.HandleFunc("/rootfolders/{id}/scan", app.getRootFolderScanHandler).Methods("GET")
+	api.HandleFunc("/movies/{id}/rootfolder", app.setMovieRootFolderHandler).Methods("PUT")
+
+	// Remote path mapping endpoints
+	api.HandleFunc("/remotepathmappings", app.getRemotePathMappingsHandler).Methods("GET")
+	api.HandleFunc("/remotepathmappings", app.createRemotePathMappingHandler).Methods("POST")
+	api.HandleFunc("/remotepathmappings/{id}", app.deleteRemotePathMappingHandler).Methods("DELETE")
+
+	// Media server endpoints
+	api.HandleFunc("/mediaservers", app.getMediaServersHandler).Methods("GET")
+	api.HandleFunc("/mediaservers", app.createMediaServerHandler).Methods("POST")
+	api.HandleFunc("/mediaservers/test", app.testNewMediaServerHandler).Methods("POST")
+	api.HandleFunc("/mediaservers/{id}", app.updateMediaServerHandler).Methods("PUT")
+	api.HandleFunc("/mediaservers/{id}", app.deleteMediaServerHandler).Methods("DELETE")
+	api.HandleFunc("/mediaservers/{id}/test", app.testMediaServerHandler).Methods("POST")
+
+	// Event endpoints
+	api.HandleFunc("/events", app.getEventsHandler).Methods("GET")
+	api.HandleFunc("/events/stream", app.streamEventsHandler).Methods("GET")
+	api.HandleFunc("/events/compact", app.compactEventsHandler).Methods("POST")
+
+	// Statistics endpoints
+	api.HandleFunc("/stats", app.getLibraryStatsHandler).Methods("GET")
+	api.HandleFunc("/stats/search", app.getSearchStatsHandler).Methods("GET")
+
+	// Notification endpoints
+	api.HandleFunc("/notifications", app.getNotificationsHandler).Methods("GET")
+	api.HandleFunc("/notifications/test", app.testNotificationsHandler).Methods("POST")
+
+	// Webhook endpoints
+	api.HandleFunc("/webhooks", app.getWebhooksHandler).Methods("GET")
+	api.HandleFunc("/webhooks", app.createWebhookHandler).Methods("POST")
+	api.HandleFunc("/webhooks/{id}", app.updateWebhookHandler).Methods("PUT")
+	api.HandleFunc("/webhooks/{id}", app.deleteWebhookHandler).Methods("DELETE")
+	api.HandleFunc("/webhooks/{id}/deliveries", app.getWebhookDeliveriesHandler).Methods("GET")
+	api.HandleFunc("/webhooks/{id}/deliveries/{delivery_id}/redeliver", app.redeliverWebhookHandler).Methods("POST")
+
+	// Seeding policy endpoints
+	api.HandleFunc("/seeding-policies", app.getSeedingPoliciesHandler).Methods("GET")
+	api.HandleFunc("/seeding-policies", app.saveSeedingPolicyHandler).Methods("PUT")
+	api.HandleFunc("/seeding-policies/{id}", app.deleteSeedingPolicyHandler).Methods("DELETE")
+
 	// Generic media endpoints (still stubbed)
 	api.HandleFunc("/media", getMediaHandler).Methods("GET")
 	api.HandleFunc("/media", createMediaHandler).Methods("POST")
@@ -166,11 +364,191 @@ func main() {
 		if jobManager != nil {
 			jobManager.Stop()
 		}
+		bus.Close()
 	}()
 
 	log.Fatal(server.ListenAndServe())
 }
 
+// initQBittorrent creates the qBittorrent client from the environment, returning
+// nil if it is not configured or cannot be reached
+func initQBittorrent() *services.QBittorrentService {
+	qbittorrentURL := os.Getenv("QBITTORRENT_URL")
+	if qbittorrentURL == "" {
+		qbittorrentURL = "http://localhost:8081" // Default qBittorrent WebUI URL
+	}
+	qbittorrentUsername := os.Getenv("QBITTORRENT_USERNAME")
+	qbittorrentPassword := os.Getenv("QBITTORRENT_PASSWORD")
+
+	if qbittorrentUsername == "" || qbittorrentPassword == "" {
+		log.Println("Warning: qBittorrent credentials not set - torrents will not be downloaded automatically")
+		return nil
+	}
+
+	qbittorrentService := services.NewQBittorrentService(qbittorrentURL, qbittorrentUsername, qbittorrentPassword)
+
+	// Test qBittorrent connection
+	if err := qbittorrentService.TestConnection(context.Background()); err != nil {
+		log.Printf("Warning: qBittorrent connection failed: %v", err)
+		log.Println("Torrents will be found but not automatically downloaded")
+		return nil
+	}
+
+	log.Println("qBittorrent integration enabled")
+	return qbittorrentService
+}
+
+// importOptionsFromEnv reads how downloads are imported into the library,
+// ignoring invalid settings with a warning
+func importOptionsFromEnv() jobs.ImportOptions {
+	options := jobs.DefaultImportOptions()
+
+	if value := os.Getenv("IMPORT_MODE"); value != "" {
+		mode, err := jobs.ParseImportMode(value)
+		if err != nil {
+			log.Printf("Warning: %v - using %s", err, options.Mode)
+		} else {
+			options.Mode = mode
+		}
+	}
+
+	for name, target := range map[string]*os.FileMode{
+		"IMPORT_FILE_MODE": &options.FileMode,
+		"IMPORT_DIR_MODE":  &options.DirMode,
+	} {
+		if value := os.Getenv(name); value != "" {
+			mode, err := strconv.ParseUint(value, 8, 32)
+			if err != nil || mode > 0o777 {
+				log.Printf("Warning: invalid %s %q - expected an octal mode such as 0644", name, value)
+				continue
+			}
+			*target = os.FileMode(mode)
+		}
+	}
+
+	for name, target := range map[string]*int{
+		"IMPORT_UID": &options.UID,
+		"IMPORT_GID": &options.GID,
+	} {
+		if value := os.Getenv(name); value != "" {
+			id, err := strconv.Atoi(value)
+			if err != nil || id < 0 {
+				log.Printf("Warning: invalid %s %q", name, value)
+				continue
+			}
+			*target = id
+		}
+	}
+
+	if value := os.Getenv("IMPORT_EXTRAS"); value != "" {
+		extras, err := strconv.ParseBool(value)
+		if err != nil {
+			log.Printf("Warning: invalid IMPORT_EXTRAS %q", value)
+		} else {
+			options.Extras = extras
+		}
+	}
+
+	log.Printf("Importing downloads with mode %s", options.Mode)
+	return options
+}
+
+// eventRetentionFromEnv reads how long and how many movie events are kept,
+// falling back to the defaults for missing or invalid values
+func eventRetentionFromEnv() models.EventRetention {
+	retention := jobs.DefaultEventRetention()
+
+	if value := os.Getenv("EVENT_RETENTION_DAYS"); value != "" {
+		days, err := strconv.Atoi(value)
+		if err != nil || days < 0 {
+			log.Printf("Warning: invalid EVENT_RETENTION_DAYS %q", value)
+		} else {
+			retention.MaxAge = time.Duration(days) * 24 * time.Hour
+		}
+	}
+
+	for name, target := range map[string]*int{
+		"EVENT_RETENTION_MAX_PER_MOVIE": &retention.MaxPerMovie,
+		"EVENT_RETENTION_KEEP_PER_TYPE": &retention.KeepPerType,
+	} {
+		if value := os.Getenv(name); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				log.Printf("Warning: invalid %s %q", name, value)
+				continue
+			}
+			*target = n
+		}
+	}
+
+	return retention
+}
+
+// notifierFromEnv sets up the notification providers configured in the
+// environment, skipping invalid ones with a warning
+func notifierFromEnv(movieRepo *repository.MovieRepository) *notifications.Notifier {
+	notifier := notifications.NewNotifier(movieRepo)
+
+	providerKinds := func(name string) []notifications.Kind {
+		kinds, err := notifications.ParseKinds(os.Getenv(name))
+		if err != nil {
+			log.Printf("Warning: invalid %s: %v - sending every event", name, err)
+			return nil
+		}
+		return kinds
+	}
+
+	if webhookURL := os.Getenv("NOTIFICATION_WEBHOOK_URL"); webhookURL != "" {
+		notifier.AddProvider(notifications.NewWebhookProvider(webhookURL), providerKinds("NOTIFICATION_WEBHOOK_EVENTS"))
+	}
+	if slackURL := os.Getenv("NOTIFICATION_SLACK_WEBHOOK_URL"); slackURL != "" {
+		notifier.AddProvider(notifications.NewSlackProvider(slackURL), providerKinds("NOTIFICATION_SLACK_EVENTS"))
+	}
+
+	if host := os.Getenv("EMAIL_SMTP_HOST"); host != "" {
+		config := notifications.EmailConfig{
+			Host:     host,
+			Username: os.Getenv("EMAIL_USERNAME"),
+			Password: os.Getenv("EMAIL_PASSWORD"),
+			From:     os.Getenv("EMAIL_FROM"),
+		}
+		if value := os.Getenv("EMAIL_SMTP_PORT"); value != "" {
+			port, err := strconv.Atoi(value)
+			if err != nil {
+				log.Printf("Warning: invalid EMAIL_SMTP_PORT %q", value)
+			} else {
+				config.Port = port
+			}
+		}
+		for _, recipient := range strings.Split(os.Getenv("EMAIL_TO"), ",") {
+			if recipient = strings.TrimSpace(recipient); recipient != "" {
+				config.To = append(config.To, recipient)
+			}
+		}
+
+		provider, err := notifications.NewEmailProvider(config)
+		if err != nil {
+			log.Printf("Warning: email notifications disabled: %v", err)
+		} else {
+			notifier.AddProvider(provider, providerKinds("EMAIL_EVENTS"))
+		}
+	}
+
+	for _, kind := range notifications.Kinds {
+		name := "NOTIFICATION_TEMPLATE_" + strings.ToUpper(string(kind))
+		if text := os.Getenv(name); text != "" {
+			if err := notifier.SetTemplate(kind, strings.ReplaceAll(text, `\n`, "\n")); err != nil {
+				log.Printf("Warning: %s: %v", name, err)
+			}
+		}
+	}
+
+	for _, provider := range notifier.Providers() {
+		log.Printf("Sending %s notifications for %v", provider.Name, provider.Events)
+	}
+	return notifier
+}
+
 func healthHandler(w http.ResponseWriter, _ *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	if _, err := w.Write([]byte("OK")); err != nil {
@@ -235,6 +613,14 @@ func (app *App) createMovieHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// A requested root folder must exist
+	if movie.RootFolderID != 0 && app.rootFolderRepo != nil {
+		if _, err := app.rootFolderRepo.GetByID(movie.RootFolderID); err != nil {
+			http.Error(w, "Root folder not found", http.StatusBadRequest)
+			return
+		}
+	}
+
 	// Set default status if not provided
 	if movie.Status == "" {
 		movie.Status = models.StatusWanted
@@ -301,6 +687,8 @@ func (app *App) addMovieFromTMDBHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	app.cacheArtwork(movie)
+
 	// Trigger torrent search if Jackett is available
 	if app.jobManager != nil {
 		log.Printf("Triggering torrent search for newly added movie: %s (%d)", movie.Title, movie.Year)
@@ -314,6 +702,82 @@ func (app *App) addMovieFromTMDBHandler(w http.ResponseWriter, r *http.Request)
 	}
 }
 
+// refreshMovieHandler updates a movie's metadata from TMDB and rewrites its
+// NFO file and artwork if its root folder wants them
+func (app *App) refreshMovieHandler(w http.ResponseWriter, r *http.Request) {
+	movieID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid movie ID", http.StatusBadRequest)
+		return
+	}
+
+	movie, err := app.movieRepo.GetByID(movieID)
+	if err != nil {
+		http.Error(w, "Movie not found", http.StatusNotFound)
+		return
+	}
+	if movie.TMDBID == 0 {
+		http.Error(w, "Movie has no TMDB ID", http.StatusBadRequest)
+		return
+	}
+
+	fresh, err := app.tmdbService.GetMovie(movie.TMDBID)
+	if err != nil {
+		log.Printf("Error fetching movie from TMDB: %v", err)
+		http.Error(w, "Failed to fetch movie from TMDB", http.StatusBadGateway)
+		return
+	}
+
+	movie.Title = fresh.Title
+	movie.Year = fresh.Year
+	movie.Genre = fresh.Genre
+	movie.Description = fresh.Description
+	movie.Poster = fresh.Poster
+	movie.Backdrop = fresh.Backdrop
+	movie.Rating = fresh.Rating
+	movie.Runtime = fresh.Runtime
+	movie.Director = fresh.Director
+	movie.IMDBID = fresh.IMDBID
+	if err := app.movieRepo.Update(movie); err != nil {
+		log.Printf("Error updating movie: %v", err)
+		http.Error(w, "Failed to save movie", http.StatusInternalServerError)
+		return
+	}
+
+	app.writeMetadata(movie)
+	app.cacheArtwork(movie)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(movie); err != nil {
+		log.Printf("Error encoding movie response: %v", err)
+	}
+}
+
+// writeMetadata rewrites a movie's NFO and artwork in the background, since
+// fetching the artwork from TMDB can take minutes
+func (app *App) writeMetadata(movie *models.Movie) {
+	if app.metadataWriter == nil {
+		return
+	}
+
+	snapshot := *movie
+	write := func(context.Context) error {
+		if _, err := app.metadataWriter.Write(&snapshot); err != nil {
+			return fmt.Errorf("failed to write metadata for '%s': %w", snapshot.Title, err)
+		}
+		return nil
+	}
+	if app.jobManager != nil {
+		app.jobManager.RunTask("metadata", write)
+		return
+	}
+	go func() {
+		if err := write(context.Background()); err != nil {
+			log.Print(err)
+		}
+	}()
+}
+
 // Generic media handlers (still stubbed)
 func getMediaHandler(w http.ResponseWriter, _ *http.Request) {
 	w.WriteHeader(http.StatusNotImplemented)
@@ -413,28 +877,20 @@ func (app *App) getMovieDetailsHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Build job control
 	jobControl := &models.JobControl{
-		CanCancel:  movie.Status == models.StatusDownloading || movie.Status == models.StatusSearching,
-		CanRestart: movie.Status == models.StatusNotFound || movie.Status == models.StatusWanted || movie.Status == models.StatusFailed,
+		CanCancel: movie.Status == models.StatusDownloading || movie.Status == models.StatusSearching,
+		CanRestart: movie.Status == models.StatusNotFound || movie.Status == models.StatusWanted ||
+			movie.Status == models.StatusFailed || movie.Status == models.StatusMissing,
 		CancelURL:  fmt.Sprintf("/api/v1/movies/%d/cancel-job", movieID),
 		RestartURL: fmt.Sprintf("/api/v1/movies/%d/restart-job", movieID),
 		CurrentJob: string(movie.Status),
 	}
 
-	// Determine if there's actually an active job based on recent events
-	if len(events) > 0 {
-		recentEvent := events[0] // Most recent event
-		// If the most recent event was within the last 5 minutes and indicates active work
-		if time.Since(recentEvent.CreatedAt) < 5*time.Minute {
-			switch recentEvent.Type {
-			case models.EventSearchStarted:
-				jobControl.CurrentJob = "searching"
-				jobControl.CanCancel = true // Allow canceling active search
-			case models.EventDownloadStarted:
-				jobControl.CurrentJob = "downloading"
-				jobControl.CanCancel = true
-				jobControl.CanRestart = false
-			}
-		}
+	// Report the job actually running for the movie, if any
+	if job, ok := app.jobManager.ActiveJob(movieID); ok {
+		jobControl.CurrentJob = job.Name
+		jobControl.JobStartedAt = job.StartedAt.Format(time.RFC3339)
+		jobControl.CanCancel = true
+		jobControl.CanRestart = false
 	}
 
 	// Add last job time if available
@@ -456,7 +912,9 @@ func (app *App) getMovieDetailsHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// restartMovieJobHandler restarts the torrent search job for a movie
+// restartMovieJobHandler restarts the torrent search job for a movie. The
+// status change event is stored in the background, so it may be missing from
+// the movie's history for a moment after the response.
 func (app *App) restartMovieJobHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	idStr := vars["id"]
@@ -475,12 +933,12 @@ func (app *App) restartMovieJobHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Log job restart event
-	if app.movieEventRepo != nil {
-		if err := app.movieEventRepo.Create(movieID, models.EventStatusChanged,
-			"Job restarted manually", map[string]interface{}{"action": "manual_restart"}); err != nil {
-			log.Printf("Failed to log job restart: %v", err)
-		}
-	}
+	app.bus.Publish(movieID, models.EventStatusChanged,
+		"Job restarted manually", models.StatusChangeDetails{
+			OldStatus: movie.Status,
+			NewStatus: models.StatusWanted,
+			Action:    "manual_restart",
+		})
 
 	// Update movie status to wanted to trigger new search
 	movie.Status = models.StatusWanted
@@ -506,7 +964,9 @@ func (app *App) restartMovieJobHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// cancelMovieJobHandler cancels any active job for a movie
+// cancelMovieJobHandler cancels any active job for a movie. Like the restart,
+// its cancellation event shows up in the movie's history shortly after the
+// response rather than before it.
 func (app *App) cancelMovieJobHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	idStr := vars["id"]
@@ -531,15 +991,11 @@ func (app *App) cancelMovieJobHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Log job cancellation
-	if app.movieEventRepo != nil {
-		if err := app.movieEventRepo.Create(movieID, models.EventJobCancelled,
-			"Download cancelled manually", map[string]interface{}{
-				"action":           "manual_cancel",
-				"cancelled_status": movie.Status,
-			}); err != nil {
-			log.Printf("Failed to log job cancellation: %v", err)
-		}
-	}
+	app.bus.Publish(movieID, models.EventJobCancelled,
+		"Download cancelled manually", models.CancellationDetails{
+			Action:          "manual_cancel",
+			CancelledStatus: movie.Status,
+		})
 
 	// Update movie status to indicate cancellation
 	oldStatus := movie.Status
@@ -588,22 +1044,18 @@ func (app *App) deleteMovieHandler(w http.ResponseWriter, r *http.Request) {
 		app.jobManager.CancelJobsForMovie(movieID)
 
 		// Log job cancellation
-		if app.movieEventRepo != nil {
-			if err := app.movieEventRepo.Create(movieID, models.EventJobCancelled,
-				"Job cancelled due to movie deletion", map[string]interface{}{
-					"action":           "delete_movie",
-					"cancelled_status": movie.Status,
-				}); err != nil {
-				log.Printf("Failed to log job cancellation during deletion: %v", err)
-			}
-		}
+		app.bus.Publish(movieID, models.EventJobCancelled,
+			"Job cancelled due to movie deletion", models.CancellationDetails{
+				Action:          "delete_movie",
+				CancelledStatus: movie.Status,
+			})
 	}
 
 	// Handle torrent deletion if requested and torrent hash exists
 	torrentDeleted := false
 	if deleteTorrent && movie.TorrentHash != "" && app.qbittorrentService != nil {
 		log.Printf("Deleting torrent %s for movie %s", movie.TorrentHash, movie.Title)
-		if err := app.qbittorrentService.RemoveTorrent(movie.TorrentHash, true); err != nil {
+		if err := app.qbittorrentService.RemoveTorrent(r.Context(), movie.TorrentHash, true); err != nil {
 			log.Printf("Warning: failed to delete torrent: %v", err)
 			// Continue with movie deletion even if torrent deletion fails
 		} else {
@@ -618,20 +1070,38 @@ func (app *App) deleteMovieHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Log deletion event
-	if app.movieEventRepo != nil {
-		details := map[string]interface{}{"action": "delete"}
-		if torrentDeleted {
-			details["torrent_deleted"] = true
-			details["torrent_hash"] = movie.TorrentHash
+	// Deleting the torrent's files removes a movie file used where it was downloaded
+	if torrentDeleted && movie.FilePath != "" && app.mediaServers != nil {
+		if _, err := os.Stat(movie.FilePath); errors.Is(err, os.ErrNotExist) {
+			path := movie.FilePath
+			notify := func(ctx context.Context) error {
+				app.mediaServers.Notify(ctx, path, services.MediaDeleted)
+				return nil
+			}
+			if app.jobManager != nil {
+				app.jobManager.RunTask("media server refresh", notify)
+			} else {
+				go notify(context.Background())
+			}
 		}
-		if err := app.movieEventRepo.Create(movieID, models.EventStatusChanged,
-			fmt.Sprintf("Movie '%s' deleted from library", movie.Title),
-			details); err != nil {
-			log.Printf("Failed to log movie deletion: %v", err)
+	}
+
+	if app.artworkCache != nil {
+		if err := app.artworkCache.Remove(movieID); err != nil {
+			log.Printf("Failed to remove cached artwork: %v", err)
 		}
 	}
 
+	// Log deletion event
+	details := models.StatusChangeDetails{OldStatus: movie.Status, Action: "delete"}
+	if torrentDeleted {
+		details.TorrentDeleted = true
+		details.TorrentHash = movie.TorrentHash
+	}
+	app.bus.Publish(movieID, models.EventStatusChanged,
+		fmt.Sprintf("Movie '%s' deleted from library", movie.Title),
+		details)
+
 	w.Header().Set("Content-Type", "application/json")
 	response := map[string]interface{}{
 		"message":    "Movie deleted successfully",
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+
+	"media/models"
+	"media/services"
+
+	"github.com/gorilla/mux"
+)
+
+// getMovieMediaInfoHandler returns what a movie's file actually contains. The
+// file is probed on first request, or again with ?refresh=true.
+func (app *App) getMovieMediaInfoHandler(w http.ResponseWriter, r *http.Request) {
+	movieID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid movie ID", http.StatusBadRequest)
+		return
+	}
+
+	movie, err := app.movieRepo.GetByID(movieID)
+	if err != nil {
+		http.Error(w, "Movie not found", http.StatusNotFound)
+		return
+	}
+	if movie.FilePath == "" {
+		http.Error(w, "Movie has no file", http.StatusNotFound)
+		return
+	}
+
+	var media *models.MediaFile
+	if r.URL.Query().Get("refresh") != "true" {
+		media, err = app.mediaFileRepo.GetByMovieID(movie.ID)
+		if err != nil {
+			log.Printf("Error getting media info: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if media == nil || media.Path != movie.FilePath {
+		media, err = app.mediaAnalyzer.Analyze(movie)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			http.Error(w, "Movie file not found", http.StatusNotFound)
+			return
+		case errors.Is(err, services.ErrUnknownContainer):
+			http.Error(w, "Unsupported media container", http.StatusUnprocessableEntity)
+			return
+		case err != nil:
+			log.Printf("Error probing %s: %v", movie.FilePath, err)
+			http.Error(w, "Failed to read media file", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(media); err != nil {
+		log.Printf("Error encoding media info: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
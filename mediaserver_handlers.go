@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"media/models"
+	"media/services"
+
+	"github.com/gorilla/mux"
+)
+
+// validateMediaServer normalizes a media server connection, returning a
+// message for the client if it is incomplete
+func validateMediaServer(server *models.MediaServer) string {
+	server.Name = strings.TrimSpace(server.Name)
+	server.URL = strings.TrimRight(strings.TrimSpace(server.URL), "/")
+	server.Type = models.MediaServerType(strings.ToLower(string(server.Type)))
+
+	if server.Name == "" {
+		return "name is required"
+	}
+	if parsed, err := url.Parse(server.URL); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "url must be an http or https URL"
+	}
+	switch server.Type {
+	case models.MediaServerJellyfin, models.MediaServerEmby, models.MediaServerPlex:
+		if server.APIKey == "" {
+			return "api_key is required"
+		}
+	case models.MediaServerKodi:
+	default:
+		return "type must be one of jellyfin, emby, plex or kodi"
+	}
+	return ""
+}
+
+func (app *App) getMediaServersHandler(w http.ResponseWriter, _ *http.Request) {
+	servers, err := app.mediaServerRepo.GetAll()
+	if err != nil {
+		log.Printf("Error getting media servers: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(servers); err != nil {
+		log.Printf("Error encoding media servers: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (app *App) createMediaServerHandler(w http.ResponseWriter, r *http.Request) {
+	server := models.MediaServer{Enabled: true}
+	if err := json.NewDecoder(r.Body).Decode(&server); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if message := validateMediaServer(&server); message != "" {
+		http.Error(w, message, http.StatusBadRequest)
+		return
+	}
+
+	if err := app.mediaServerRepo.Create(&server); err != nil {
+		log.Printf("Error creating media server: %v", err)
+		http.Error(w, "Failed to create media server", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(server); err != nil {
+		log.Printf("Error encoding media server response: %v", err)
+	}
+}
+
+func (app *App) updateMediaServerHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid media server ID", http.StatusBadRequest)
+		return
+	}
+
+	server, err := app.mediaServerRepo.GetByID(id)
+	if err != nil {
+		http.Error(w, "Media server not found", http.StatusNotFound)
+		return
+	}
+
+	// Fields left out of the request keep their current values
+	if err := json.NewDecoder(r.Body).Decode(server); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	server.ID = id
+	if message := validateMediaServer(server); message != "" {
+		http.Error(w, message, http.StatusBadRequest)
+		return
+	}
+
+	if err := app.mediaServerRepo.Update(server); err != nil {
+		log.Printf("Error updating media server: %v", err)
+		http.Error(w, "Failed to update media server", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(server); err != nil {
+		log.Printf("Error encoding media server response: %v", err)
+	}
+}
+
+func (app *App) deleteMediaServerHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid media server ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := app.mediaServerRepo.Delete(id); err != nil {
+		http.Error(w, "Media server not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// testMediaServerHandler checks that a saved media server connection works
+func (app *App) testMediaServerHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid media server ID", http.StatusBadRequest)
+		return
+	}
+
+	server, err := app.mediaServerRepo.GetByID(id)
+	if err != nil {
+		http.Error(w, "Media server not found", http.StatusNotFound)
+		return
+	}
+
+	app.writeMediaServerTest(w, r, *server)
+}
+
+// testNewMediaServerHandler checks a media server connection before it is saved
+func (app *App) testNewMediaServerHandler(w http.ResponseWriter, r *http.Request) {
+	var server models.MediaServer
+	if err := json.NewDecoder(r.Body).Decode(&server); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if message := validateMediaServer(&server); message != "" {
+		http.Error(w, message, http.StatusBadRequest)
+		return
+	}
+
+	app.writeMediaServerTest(w, r, server)
+}
+
+// writeMediaServerTest connects to a media server and reports the outcome
+func (app *App) writeMediaServerTest(w http.ResponseWriter, r *http.Request, server models.MediaServer) {
+	client, err := services.NewMediaServerClient(server)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response := map[string]interface{}{"success": true}
+	status := http.StatusOK
+	if err := client.Test(r.Context()); err != nil {
+		response = map[string]interface{}{"success": false, "error": err.Error()}
+		status = http.StatusBadGateway
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Error encoding media server test response: %v", err)
+	}
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// BlocklistEntry is a release that was rejected and must not be grabbed again
+type BlocklistEntry struct {
+	ID        int       `json:"id"`
+	MovieID   int       `json:"movie_id"`
+	Title     string    `json:"title"`
+	InfoHash  string    `json:"info_hash,omitempty"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,139 @@
+package models
+
+// EventDetailsVersion is the schema version of the typed event details below.
+// It is stored with every event that has details; events stored before the
+// details were typed have version 0 and free-form details, though most of
+// their fields have the same names.
+const EventDetailsVersion = 1
+
+// EventDetails is the typed details of a movie event. Only the types in this
+// file implement it, so every event's details have a known shape.
+type EventDetails interface {
+	eventDetails()
+}
+
+// SearchDetails describes a finished search (search_completed) or one that
+// found nothing or failed (search_failed)
+type SearchDetails struct {
+	TorrentCount      int    `json:"torrent_count"`
+	SearchQueries     int    `json:"search_queries"`
+	TotalResultsFound int    `json:"total_results_found"`
+	DurationMs        int64  `json:"duration_ms,omitempty"`
+	Reason            string `json:"reason,omitempty"`
+	Error             string `json:"error,omitempty"`
+}
+
+// TorrentFoundDetails describes the best release a search found (torrent_found)
+type TorrentFoundDetails struct {
+	Title   string  `json:"title"`
+	Indexer string  `json:"indexer,omitempty"`
+	Seeders int     `json:"seeders"`
+	SizeGB  float64 `json:"size_gb"`
+	Score   int     `json:"score"`
+	Quality string  `json:"quality"`
+}
+
+// DownloadDetails describes a download that started, completed or failed
+// (download_started, download_completed, download_failed)
+type DownloadDetails struct {
+	Title    string   `json:"title,omitempty"`
+	Indexer  string   `json:"indexer,omitempty"`
+	InfoHash string   `json:"info_hash,omitempty"`
+	Path     string   `json:"path,omitempty"`
+	Reason   string   `json:"reason,omitempty"` // Why the release was rejected
+	Files    []string `json:"files,omitempty"`  // The files that got it rejected
+	Error    string   `json:"error,omitempty"`
+}
+
+// ImportDetails describes an imported file (imported) or a failed import
+// (import_failed)
+type ImportDetails struct {
+	Path      string   `json:"path"`
+	Size      int64    `json:"size,omitempty"`
+	Source    string   `json:"source,omitempty"` // The downloaded file
+	Mode      string   `json:"mode,omitempty"`
+	Adopted   bool     `json:"adopted,omitempty"` // Found in the library by a scan rather than downloaded
+	Subtitles []string `json:"subtitles,omitempty"`
+	Extras    []string `json:"extras,omitempty"`
+	Metadata  []string `json:"metadata,omitempty"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// StatusChangeDetails describes a change of a movie's status, or its removal
+// from the library (status_changed). Action is set for changes made by hand.
+type StatusChangeDetails struct {
+	OldStatus      MediaStatus `json:"old_status,omitempty"`
+	NewStatus      MediaStatus `json:"new_status,omitempty"`
+	Action         string      `json:"action,omitempty"`
+	Indexer        string      `json:"indexer,omitempty"` // Set when a release was grabbed
+	Path           string      `json:"path,omitempty"`
+	TorrentDeleted bool        `json:"torrent_deleted,omitempty"`
+	TorrentHash    string      `json:"torrent_hash,omitempty"`
+}
+
+// CancellationDetails describes a job cancelled by hand or by deleting its
+// movie (job_cancelled)
+type CancellationDetails struct {
+	Action          string      `json:"action"`
+	CancelledStatus MediaStatus `json:"cancelled_status"`
+}
+
+// TorrentRemovedDetails describes a torrent removed once its seeding goals
+// were met (torrent_removed)
+type TorrentRemovedDetails struct {
+	TorrentHash    string  `json:"torrent_hash"`
+	Ratio          float64 `json:"ratio"`
+	SeedingTime    int64   `json:"seeding_time"` // in seconds
+	PolicyIndexer  string  `json:"policy_indexer"`
+	PolicyRatio    float64 `json:"policy_ratio"`
+	PolicySeedTime int     `json:"policy_seed_time"` // in minutes
+	FilesDeleted   bool    `json:"files_deleted"`
+}
+
+// FileMissingDetails describes a ready movie whose file disappeared
+// (file_missing)
+type FileMissingDetails struct {
+	Path     string `json:"path"`
+	Size     int64  `json:"size"`
+	Requeued bool   `json:"requeued"`
+}
+
+// MediaMismatchDetails describes a file that is not what its release claimed
+// (media_mismatch)
+type MediaMismatchDetails struct {
+	Path       string          `json:"path"`
+	Mismatches MediaMismatches `json:"mismatches"`
+}
+
+// MediaMismatches holds the properties of a file that differ from the movie's
+type MediaMismatches struct {
+	Resolution *ResolutionMismatch `json:"resolution,omitempty"`
+	Runtime    *RuntimeMismatch    `json:"runtime,omitempty"`
+}
+
+// Any reports whether any property differs
+func (m MediaMismatches) Any() bool {
+	return m.Resolution != nil || m.Runtime != nil
+}
+
+// ResolutionMismatch is a resolution other than the release's quality
+type ResolutionMismatch struct {
+	Claimed string `json:"claimed"`
+	Actual  string `json:"actual"`
+}
+
+// RuntimeMismatch is a duration far from the movie's runtime, in minutes
+type RuntimeMismatch struct {
+	Claimed int `json:"claimed"`
+	Actual  int `json:"actual"`
+}
+
+func (SearchDetails) eventDetails()         {}
+func (TorrentFoundDetails) eventDetails()   {}
+func (DownloadDetails) eventDetails()       {}
+func (ImportDetails) eventDetails()         {}
+func (StatusChangeDetails) eventDetails()   {}
+func (CancellationDetails) eventDetails()   {}
+func (TorrentRemovedDetails) eventDetails() {}
+func (FileMissingDetails) eventDetails()    {}
+func (MediaMismatchDetails) eventDetails()  {}
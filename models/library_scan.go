@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// LibraryScanStatus represents the state of a root folder scan
+type LibraryScanStatus string
+
+// Library scan status constants
+const (
+	LibraryScanRunning   LibraryScanStatus = "running"
+	LibraryScanCompleted LibraryScanStatus = "completed"
+	LibraryScanFailed    LibraryScanStatus = "failed"
+)
+
+// LibraryScan is the progress and outcome of adopting the movies already in a
+// root folder
+type LibraryScan struct {
+	RootFolderID int               `json:"root_folder_id"`
+	Status       LibraryScanStatus `json:"status"`
+	StartedAt    time.Time         `json:"started_at"`
+	FinishedAt   *time.Time        `json:"finished_at,omitempty"`
+	Scanned      int               `json:"scanned"`  // movie folders and files looked at
+	Added        int               `json:"added"`    // new movies created
+	Adopted      int               `json:"adopted"`  // existing movies given the file found
+	Existing     int               `json:"existing"` // files already in the library
+	Unmatched    []UnmatchedPath   `json:"unmatched"`
+	Error        string            `json:"error,omitempty"`
+}
+
+// UnmatchedPath is a file found by a library scan that could not be matched
+// to a movie and needs to be added by hand
+type UnmatchedPath struct {
+	Path   string `json:"path"`
+	Title  string `json:"title,omitempty"` // title parsed from the name
+	Year   int    `json:"year,omitempty"`
+	Reason string `json:"reason"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// LibraryStats is an overview of the library and of its activity since a time
+type LibraryStats struct {
+	Since       time.Time           `json:"since"`
+	Movies      int                 `json:"movies"`
+	ByStatus    map[MediaStatus]int `json:"by_status"`
+	TotalSize   int64               `json:"total_size"`   // in bytes, of the movies with a file
+	AverageSize int64               `json:"average_size"` // in bytes, of the movies with a file
+	Qualities   []StatsCount        `json:"qualities"`
+	Genres      []StatsCount        `json:"genres"`
+	Decades     []StatsCount        `json:"decades"`
+	GrabsPerDay []StatsCount        `json:"grabs_per_day"`
+	Indexers    []IndexerGrabStats  `json:"indexers"`
+
+	// MedianTimeToReady is how long movies imported since took from being
+	// added to being ready, in seconds
+	MedianTimeToReady float64 `json:"median_time_to_ready_seconds"`
+
+	// Searches is the number of searches finished since; NotFoundRate is the
+	// share of them that found nothing to download
+	Searches     int     `json:"searches"`
+	NotFoundRate float64 `json:"not_found_rate"`
+}
+
+// StatsCount is the number of movies or events in a bucket, like a quality,
+// a genre, a decade (1990s) or a day (2006-01-02)
+type StatsCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// IndexerGrabStats is how many releases were grabbed from an indexer, and how
+// many of those were imported
+type IndexerGrabStats struct {
+	Indexer  string `json:"indexer"`
+	Grabs    int    `json:"grabs"`
+	Imported int    `json:"imported"`
+}
@@ -29,7 +29,8 @@ const (
 	StatusDownloaded  MediaStatus = "downloaded"
 	StatusProcessing  MediaStatus = "processing"
 	StatusReady       MediaStatus = "ready"
-	StatusFailed      MediaStatus = "failed" // Permanent failure
+	StatusFailed      MediaStatus = "failed"  // Permanent failure
+	StatusMissing     MediaStatus = "missing" // Was ready, but the file is gone from disk
 )
 
 // Media represents a generic media item
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// MediaFile describes what a movie's file actually contains, as read from its
+// container headers
+type MediaFile struct {
+	ID             int             `json:"id"`
+	MovieID        int             `json:"movie_id"`
+	Path           string          `json:"path"`
+	Size           int64           `json:"size"`
+	Container      string          `json:"container"`          // matroska, mp4
+	Duration       float64         `json:"duration,omitempty"` // in seconds
+	VideoCodec     string          `json:"video_codec,omitempty"`
+	Width          int             `json:"width,omitempty"`
+	Height         int             `json:"height,omitempty"`
+	Resolution     string          `json:"resolution,omitempty"` // 4K, 1080p, etc., comparable to Movie.Quality
+	HDR            string          `json:"hdr,omitempty"`        // HDR10, HLG or Dolby Vision
+	AudioTracks    []AudioTrack    `json:"audio_tracks"`
+	SubtitleTracks []SubtitleTrack `json:"subtitle_tracks"`
+	ProbedAt       time.Time       `json:"probed_at"`
+}
+
+// AudioTrack is an audio stream inside a media file
+type AudioTrack struct {
+	Codec    string `json:"codec"`
+	Language string `json:"language,omitempty"`
+	Channels int    `json:"channels,omitempty"`
+	Default  bool   `json:"default,omitempty"`
+}
+
+// SubtitleTrack is a subtitle stream inside a media file
+type SubtitleTrack struct {
+	Codec    string `json:"codec"`
+	Language string `json:"language,omitempty"`
+	Forced   bool   `json:"forced,omitempty"`
+}
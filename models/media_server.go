@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// MediaServerType names a kind of media server that can be told about library changes
+type MediaServerType string
+
+const (
+	MediaServerJellyfin MediaServerType = "jellyfin"
+	MediaServerEmby     MediaServerType = "emby"
+	MediaServerPlex     MediaServerType = "plex"
+	MediaServerKodi     MediaServerType = "kodi"
+)
+
+// MediaServer is a connection to a media server whose library is refreshed
+// when movie files are imported or removed
+type MediaServer struct {
+	ID        int             `json:"id"`
+	Name      string          `json:"name"`
+	Type      MediaServerType `json:"type"`
+	URL       string          `json:"url"`
+	APIKey    string          `json:"api_key,omitempty"`  // Jellyfin/Emby API key or Plex token
+	Username  string          `json:"username,omitempty"` // Kodi only
+	Password  string          `json:"password,omitempty"` // Kodi only
+	Enabled   bool            `json:"enabled"`
+	CreatedAt time.Time       `json:"created_at"`
+}
@@ -4,22 +4,26 @@ import "time"
 
 // Movie represents a movie in the media library
 type Movie struct {
-	ID          int         `json:"id"`
-	Title       string      `json:"title"`
-	Status      MediaStatus `json:"status"`
-	IMDBID      string      `json:"imdb_id,omitempty"`
-	TMDBID      int         `json:"tmdb_id,omitempty"`
-	Year        int         `json:"year,omitempty"`
-	Genre       string      `json:"genre,omitempty"`
-	Description string      `json:"description,omitempty"`
-	Poster      string      `json:"poster,omitempty"`
-	Rating      float64     `json:"rating,omitempty"`
-	Runtime     int         `json:"runtime,omitempty"` // in minutes
-	Director    string      `json:"director,omitempty"`
-	FilePath    string      `json:"file_path,omitempty"`
-	FileSize    int64       `json:"file_size,omitempty"`
-	Quality     string      `json:"quality,omitempty"` // 1080p, 4K, etc.
-	TorrentHash string      `json:"torrent_hash,omitempty"` // qBittorrent info hash
-	CreatedAt   time.Time   `json:"created_at"`
-	UpdatedAt   time.Time   `json:"updated_at"`
+	ID           int         `json:"id"`
+	Title        string      `json:"title"`
+	Status       MediaStatus `json:"status"`
+	IMDBID       string      `json:"imdb_id,omitempty"`
+	TMDBID       int         `json:"tmdb_id,omitempty"`
+	Year         int         `json:"year,omitempty"`
+	Genre        string      `json:"genre,omitempty"`
+	Description  string      `json:"description,omitempty"`
+	Poster       string      `json:"poster,omitempty"`
+	Backdrop     string      `json:"backdrop,omitempty"`
+	Rating       float64     `json:"rating,omitempty"`
+	Runtime      int         `json:"runtime,omitempty"` // in minutes
+	Director     string      `json:"director,omitempty"`
+	FilePath     string      `json:"file_path,omitempty"`
+	FileSize     int64       `json:"file_size,omitempty"`
+	Quality      string      `json:"quality,omitempty"`       // 1080p, 4K, etc.
+	TorrentHash  string      `json:"torrent_hash,omitempty"`  // qBittorrent info hash
+	DownloadName string      `json:"download_name,omitempty"` // name the download client gives the finished download
+	Indexer      string      `json:"indexer,omitempty"`       // indexer the release was grabbed from
+	RootFolderID int         `json:"root_folder_id,omitempty"`
+	CreatedAt    time.Time   `json:"created_at"`
+	UpdatedAt    time.Time   `json:"updated_at"`
 }
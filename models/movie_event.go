@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // MovieEventType represents the type of movie event
 type MovieEventType string
@@ -13,18 +16,95 @@ const (
 	EventDownloadStarted   MovieEventType = "download_started"
 	EventDownloadCompleted MovieEventType = "download_completed"
 	EventDownloadFailed    MovieEventType = "download_failed"
+	EventImported          MovieEventType = "imported"
+	EventImportFailed      MovieEventType = "import_failed"
+	EventTorrentRemoved    MovieEventType = "torrent_removed"
+	EventFileMissing       MovieEventType = "file_missing"
+	EventMediaMismatch     MovieEventType = "media_mismatch"
 	EventJobCancelled      MovieEventType = "job_cancelled"
 	EventStatusChanged     MovieEventType = "status_changed"
 )
 
+// MovieEventTypes lists every type of movie event
+var MovieEventTypes = []MovieEventType{
+	EventSearchStarted, EventSearchCompleted, EventSearchFailed, EventTorrentFound,
+	EventDownloadStarted, EventDownloadCompleted, EventDownloadFailed,
+	EventImported, EventImportFailed, EventTorrentRemoved, EventFileMissing,
+	EventMediaMismatch, EventJobCancelled, EventStatusChanged,
+}
+
+// Known reports whether the event type is one of MovieEventTypes
+func (t MovieEventType) Known() bool {
+	for _, known := range MovieEventTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
 // MovieEvent represents an event in the movie download process
 type MovieEvent struct {
-	ID        int            `json:"id"`
-	MovieID   int            `json:"movie_id"`
-	Type      MovieEventType `json:"type"`
-	Message   string         `json:"message"`
-	Details   string         `json:"details,omitempty"` // JSON string for additional data
-	CreatedAt time.Time      `json:"created_at"`
+	ID             int             `json:"id"`
+	MovieID        int             `json:"movie_id"`
+	Type           MovieEventType  `json:"type"`
+	Message        string          `json:"message"`
+	Details        json.RawMessage `json:"details,omitempty"`         // EventDetails of the event's type
+	DetailsVersion int             `json:"details_version,omitempty"` // EventDetailsVersion they were written with
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+// DecodeDetails decodes the event's details into v, leaving it unchanged if
+// the event has none
+func (e *MovieEvent) DecodeDetails(v EventDetails) error {
+	if len(e.Details) == 0 {
+		return nil
+	}
+	return json.Unmarshal(e.Details, v)
+}
+
+// EventFilter narrows down the activity feed. Zero fields match everything.
+type EventFilter struct {
+	Types    []MovieEventType
+	MovieID  int
+	Since    time.Time // Inclusive
+	Until    time.Time // Exclusive
+	Query    string    // Matched against the message and the movie's title
+	BeforeID int       // Cursor: only events older than this one
+	Limit    int
+}
+
+// ActivityEvent is a movie event in the activity feed, with its movie's title
+type ActivityEvent struct {
+	MovieEvent
+	MovieTitle string `json:"movie_title,omitempty"` // Empty if the movie was deleted
+	MovieYear  int    `json:"movie_year,omitempty"`
+}
+
+// ActivityPage is a page of the activity feed, newest first. NextCursor is
+// passed as before to get the next page, and is omitted on the last one.
+type ActivityPage struct {
+	Events     []ActivityEvent `json:"events"`
+	NextCursor int             `json:"next_cursor,omitempty"`
+}
+
+// EventRetention limits how many movie events are kept. A zero MaxAge or
+// MaxPerMovie disables that limit. The last KeepPerType events of each type
+// of a movie are kept whatever their age, so its latest search, download and
+// import are never lost.
+type EventRetention struct {
+	MaxAge      time.Duration
+	MaxPerMovie int
+	KeepPerType int
+}
+
+// EventCompaction reports what applying the event retention deleted
+type EventCompaction struct {
+	Deleted           int64 `json:"deleted"`
+	DeletedByAge      int64 `json:"deleted_by_age"`
+	DeletedOverLimit  int64 `json:"deleted_over_limit"`
+	SearchRunsDeleted int64 `json:"search_runs_deleted"` // Search timings older than the max age
+	Vacuumed          bool  `json:"vacuumed"`
 }
 
 // DetailedMovieResponse represents a detailed view of a movie with events and job control
@@ -37,12 +117,13 @@ type DetailedMovieResponse struct {
 
 // JobControl provides job management actions
 type JobControl struct {
-	CanCancel   bool   `json:"can_cancel"`
-	CanRestart  bool   `json:"can_restart"`
-	CancelURL   string `json:"cancel_url,omitempty"`
-	RestartURL  string `json:"restart_url,omitempty"`
-	CurrentJob  string `json:"current_job,omitempty"`
-	LastJobTime string `json:"last_job_time,omitempty"`
+	CanCancel    bool   `json:"can_cancel"`
+	CanRestart   bool   `json:"can_restart"`
+	CancelURL    string `json:"cancel_url,omitempty"`
+	RestartURL   string `json:"restart_url,omitempty"`
+	CurrentJob   string `json:"current_job,omitempty"`
+	JobStartedAt string `json:"job_started_at,omitempty"` // Set while a job is running
+	LastJobTime  string `json:"last_job_time,omitempty"`
 }
 
 // MovieStats provides statistics about the movie's download process
@@ -52,4 +133,6 @@ type MovieStats struct {
 	LastSearchTime   string  `json:"last_search_time,omitempty"`
 	BestTorrentScore int     `json:"best_torrent_score,omitempty"`
 	AvgSearchTime    float64 `json:"avg_search_time_seconds,omitempty"`
+	P50SearchTime    float64 `json:"p50_search_time_seconds,omitempty"`
+	P90SearchTime    float64 `json:"p90_search_time_seconds,omitempty"`
 }
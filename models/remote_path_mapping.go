@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// DownloadClientQBittorrent names the qBittorrent download client in settings
+const DownloadClientQBittorrent = "qbittorrent"
+
+// RemotePathMapping translates a path reported by a download client, e.g. from
+// inside its container, into the path this service sees for the same files
+type RemotePathMapping struct {
+	ID         int       `json:"id"`
+	Client     string    `json:"client"`
+	RemotePath string    `json:"remote_path"`
+	LocalPath  string    `json:"local_path"`
+	CreatedAt  time.Time `json:"created_at"`
+}
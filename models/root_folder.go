@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// RootFolder is a directory the movie library lives in
+type RootFolder struct {
+	ID            int       `json:"id"`
+	Path          string    `json:"path"`
+	Label         string    `json:"label,omitempty"`
+	MinFreeSpace  int64     `json:"min_free_space"` // bytes to keep free after a grab
+	WriteMetadata bool      `json:"write_metadata"` // write movie.nfo and artwork next to movie files
+	FreeSpace     int64     `json:"free_space"`     // reported by the filesystem, not stored
+	TotalSpace    int64     `json:"total_space"`    // reported by the filesystem, not stored
+	Accessible    bool      `json:"accessible"`
+	CreatedAt     time.Time `json:"created_at"`
+}
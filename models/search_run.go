@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// SearchRun records how long one torrent search for a movie took
+type SearchRun struct {
+	ID         int                   `json:"id"`
+	MovieID    int                   `json:"movie_id"`
+	StartedAt  time.Time             `json:"started_at"`
+	FinishedAt time.Time             `json:"finished_at"`
+	DurationMs int64                 `json:"duration_ms"`
+	Queries    int                   `json:"queries"`
+	Requests   int                   `json:"requests"` // Requests made to Jackett
+	Results    int                   `json:"results"`  // Results before filtering
+	Found      int                   `json:"found"`    // Releases left after filtering
+	Indexers   []SearchIndexerTiming `json:"indexers,omitempty"`
+}
+
+// SearchIndexerTiming is how one indexer did during a search run, summed over
+// the run's requests as Jackett reported them
+type SearchIndexerTiming struct {
+	Indexer   string `json:"indexer"`
+	Requests  int    `json:"requests"`
+	Results   int    `json:"results"`
+	Errors    int    `json:"errors"`
+	ElapsedMs int64  `json:"elapsed_ms"`
+}
+
+// SearchTimeStats summarizes how long searches took, in milliseconds
+type SearchTimeStats struct {
+	Count int     `json:"count"`
+	AvgMs float64 `json:"avg_ms"`
+	P50Ms int64   `json:"p50_ms"`
+	P90Ms int64   `json:"p90_ms"`
+	P99Ms int64   `json:"p99_ms"`
+	MaxMs int64   `json:"max_ms"`
+}
+
+// IndexerSearchStats summarizes an indexer's latency per request
+type IndexerSearchStats struct {
+	Indexer string `json:"indexer"`
+	SearchTimeStats
+	Results int `json:"results"`
+	Errors  int `json:"errors"`
+}
+
+// SearchStats summarizes the searches of the whole library since a time
+type SearchStats struct {
+	Since    time.Time            `json:"since"`
+	Runs     SearchTimeStats      `json:"runs"`
+	Indexers []IndexerSearchStats `json:"indexers"`
+}
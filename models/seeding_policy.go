@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// SeedingPolicy sets how long torrents keep seeding after import. A policy
+// with an empty Indexer is the global default; indexer policies override it.
+// A torrent is removed only once every configured goal is met, so trackers
+// that require both a ratio and a seed time get both. A goal of zero is not
+// enforced, and a policy without goals never removes anything.
+type SeedingPolicy struct {
+	ID          int       `json:"id"`
+	Indexer     string    `json:"indexer"`
+	MinRatio    float64   `json:"min_ratio"`
+	MinSeedTime int       `json:"min_seed_time"` // in minutes
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
@@ -0,0 +1,62 @@
+package models
+
+import "time"
+
+// WebhookDeliveryStatus says where a webhook delivery stands
+type WebhookDeliveryStatus string
+
+const (
+	DeliveryPending   WebhookDeliveryStatus = "pending"
+	DeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	DeliveryFailed    WebhookDeliveryStatus = "failed"
+)
+
+// Webhook is an endpoint movie events are posted to, signed with its secret
+type Webhook struct {
+	ID        int              `json:"id"`
+	Name      string           `json:"name"`
+	URL       string           `json:"url"`
+	Secret    string           `json:"secret,omitempty"` // Only returned when the webhook is created
+	HasSecret bool             `json:"has_secret"`
+	Events    []MovieEventType `json:"events"` // Empty for every event
+	Enabled   bool             `json:"enabled"`
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+// Redact clears the secret so the webhook can be returned to clients, noting
+// whether it has one
+func (w *Webhook) Redact() {
+	w.HasSecret = w.Secret != ""
+	w.Secret = ""
+}
+
+// Wants reports whether the webhook is sent events of a type
+func (w *Webhook) Wants(eventType MovieEventType) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, wanted := range w.Events {
+		if wanted == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery is one payload sent to a webhook, with the outcome of its
+// latest attempt
+type WebhookDelivery struct {
+	ID             int                   `json:"id"`
+	WebhookID      int                   `json:"webhook_id"`
+	EventID        int                   `json:"event_id,omitempty"`
+	EventType      MovieEventType        `json:"event_type"`
+	Payload        string                `json:"payload"`
+	Status         WebhookDeliveryStatus `json:"status"`
+	Attempts       int                   `json:"attempts"`
+	ResponseStatus int                   `json:"response_status,omitempty"`
+	ResponseBody   string                `json:"response_body,omitempty"`
+	Error          string                `json:"error,omitempty"`
+	NextAttemptAt  *time.Time            `json:"next_attempt_at,omitempty"`
+	DeliveredAt    *time.Time            `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time             `json:"created_at"`
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+func (app *App) getNotificationsHandler(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(app.notifier.Providers()); err != nil {
+		log.Printf("Error encoding notification providers: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// testNotificationsHandler sends a test notification to every provider, or to
+// the one named in the request body
+func (app *App) testNotificationsHandler(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Provider string `json:"provider"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if app.notifier == nil || len(app.notifier.Providers()) == 0 {
+		http.Error(w, "No notification providers are configured", http.StatusNotFound)
+		return
+	}
+
+	results := app.notifier.Test(r.Context(), request.Provider)
+	if len(results) == 0 {
+		http.Error(w, "Notification provider not found", http.StatusNotFound)
+		return
+	}
+
+	status := http.StatusOK
+	for _, result := range results {
+		if !result.Success {
+			status = http.StatusBadGateway
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(results); err != nil {
+		log.Printf("Error encoding notification test response: %v", err)
+	}
+}
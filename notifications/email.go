@@ -0,0 +1,172 @@
+package notifications
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EmailConfig holds the SMTP server and addresses notifications are mailed with
+type EmailConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+// EmailProvider mails notifications over SMTP. Port 465 uses implicit TLS;
+// other ports upgrade with STARTTLS when the server offers it.
+type EmailProvider struct {
+	config   EmailConfig
+	sendMail func(ctx context.Context, addr string, auth smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewEmailProvider creates an email provider, sending from the username when
+// no from address is set
+func NewEmailProvider(config EmailConfig) (*EmailProvider, error) {
+	if config.Host == "" {
+		return nil, fmt.Errorf("SMTP host is required")
+	}
+	if config.Port == 0 {
+		config.Port = 587
+	}
+	if config.From == "" {
+		config.From = config.Username
+	}
+	if config.From == "" {
+		return nil, fmt.Errorf("a from address is required")
+	}
+	if len(config.To) == 0 {
+		return nil, fmt.Errorf("at least one recipient is required")
+	}
+
+	provider := &EmailProvider{config: config, sendMail: sendMailSTARTTLS}
+	if config.Port == 465 {
+		provider.sendMail = sendMailTLS
+	}
+	return provider, nil
+}
+
+func (p *EmailProvider) Name() string { return "email" }
+
+func (p *EmailProvider) Send(ctx context.Context, notification Notification) error {
+	var auth smtp.Auth
+	if p.config.Username != "" {
+		auth = smtp.PlainAuth("", p.config.Username, p.config.Password, p.config.Host)
+	}
+	addr := net.JoinHostPort(p.config.Host, strconv.Itoa(p.config.Port))
+	if err := p.sendMail(ctx, addr, auth, p.config.From, p.config.To, p.message(notification)); err != nil {
+		return fmt.Errorf("failed to send email via %s: %w", addr, err)
+	}
+	return nil
+}
+
+// message builds a plain text email for a notification
+func (p *EmailProvider) message(notification Notification) []byte {
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", p.config.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(p.config.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", notification.Subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", notification.Timestamp.Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(strings.ReplaceAll(notification.Body, "\n", "\r\n"))
+	msg.WriteString("\r\n")
+	return []byte(msg.String())
+}
+
+// sendMailSTARTTLS is smtp.SendMail bounded by ctx, upgrading to TLS when the
+// server offers STARTTLS
+func sendMailSTARTTLS(ctx context.Context, addr string, auth smtp.Auth, from string, to []string, msg []byte) error {
+	return sendMail(ctx, addr, false, auth, from, to, msg)
+}
+
+// sendMailTLS is smtp.SendMail bounded by ctx, for servers that expect TLS
+// from the start
+func sendMailTLS(ctx context.Context, addr string, auth smtp.Auth, from string, to []string, msg []byte) error {
+	return sendMail(ctx, addr, true, auth, from, to, msg)
+}
+
+// sendMail delivers msg over SMTP. smtp.SendMail has no timeouts, so a stalled
+// server would hang forever; here the dial and every read and write fail once
+// ctx is done.
+func sendMail(ctx context.Context, addr string, implicitTLS bool, auth smtp.Auth, from string, to []string, msg []byte) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			_ = conn.Close()
+			return err
+		}
+	}
+	// Cancellation without a deadline unblocks the connection by closing it
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	if implicitTLS {
+		conn = tls.Client(conn, &tls.Config{ServerName: host})
+	}
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	quit := false
+	defer func() {
+		if quit {
+			return
+		}
+		if err := client.Close(); err != nil {
+			log.Printf("Failed to close SMTP connection: %v", err)
+		}
+	}()
+
+	if !implicitTLS {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+				return err
+			}
+		}
+	}
+	if auth != nil {
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(from); err != nil {
+		return err
+	}
+	for _, recipient := range to {
+		if err := client.Rcpt(recipient); err != nil {
+			return err
+		}
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(msg); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	quit = true
+	return client.Quit()
+}
@@ -0,0 +1,320 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"text/template"
+	"time"
+
+	"media/models"
+)
+
+// Kind is a movie event worth telling someone about
+type Kind string
+
+const (
+	KindGrabbed   Kind = "grabbed"
+	KindCompleted Kind = "completed"
+	KindFailed    Kind = "failed"
+	KindNotFound  Kind = "not_found"
+	KindReady     Kind = "ready"
+	KindTest      Kind = "test"
+)
+
+// Kinds lists the kinds providers can be filtered on
+var Kinds = []Kind{KindGrabbed, KindCompleted, KindFailed, KindNotFound, KindReady}
+
+// eventKinds maps the movie events that are notified to their kind. Grabs and
+// movies not found are status changes, and imports are only notified when the
+// file was downloaded, see eventKind.
+var eventKinds = map[models.MovieEventType]Kind{
+	models.EventDownloadCompleted: KindCompleted,
+	models.EventDownloadFailed:    KindFailed,
+	models.EventImportFailed:      KindFailed,
+}
+
+// eventKind returns the kind of notification an event is, if any. A movie is
+// grabbed when it changes to downloading, after its release was inspected and
+// sent to the download client, and not found when it first becomes not_found,
+// so the periodic searches for a missing movie do not notify again. Files a
+// library scan adopted were already there, so they are not notified as ready.
+func eventKind(event models.MovieEvent) (Kind, bool) {
+	switch event.Type {
+	case models.EventStatusChanged:
+	case models.EventImported:
+		var imported models.ImportDetails
+		if err := event.DecodeDetails(&imported); err != nil || imported.Adopted {
+			return "", false
+		}
+		return KindReady, true
+	default:
+		kind, ok := eventKinds[event.Type]
+		return kind, ok
+	}
+
+	var change models.StatusChangeDetails
+	if err := event.DecodeDetails(&change); err != nil {
+		return "", false
+	}
+	switch {
+	case change.NewStatus == models.StatusDownloading:
+		return KindGrabbed, true
+	case change.NewStatus == models.StatusNotFound && change.OldStatus != models.StatusNotFound:
+		return KindNotFound, true
+	}
+	return "", false
+}
+
+// defaultTemplates render each kind of notification. The first line becomes
+// the subject of emails and the bold title of chat messages.
+var defaultTemplates = map[Kind]string{
+	KindGrabbed:   "Grabbed {{.Title}}{{with .Year}} ({{.}}){{end}}\n{{.Message}}",
+	KindCompleted: "Downloaded {{.Title}}{{with .Year}} ({{.}}){{end}}\n{{.Message}}",
+	KindFailed:    "Failed to get {{.Title}}{{with .Year}} ({{.}}){{end}}\n{{.Message}}",
+	KindNotFound:  "Nothing found for {{.Title}}{{with .Year}} ({{.}}){{end}}\n{{.Message}}",
+	KindReady:     "{{.Title}}{{with .Year}} ({{.}}){{end}} is ready to watch\n{{.Message}}",
+	KindTest:      "Test notification\n{{.Message}}",
+}
+
+// ParseKinds reads a comma separated list of kinds, such as "grabbed,ready"
+func ParseKinds(value string) ([]Kind, error) {
+	var kinds []Kind
+	for _, part := range strings.Split(value, ",") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if part == "" {
+			continue
+		}
+		if _, ok := defaultTemplates[Kind(part)]; !ok || Kind(part) == KindTest {
+			return nil, fmt.Errorf("unknown notification event %q", part)
+		}
+		kinds = append(kinds, Kind(part))
+	}
+	return kinds, nil
+}
+
+// Notification is a rendered message for a movie event
+type Notification struct {
+	Kind      Kind                   `json:"event"`
+	MovieID   int                    `json:"movie_id,omitempty"`
+	Title     string                 `json:"title,omitempty"`
+	Year      int                    `json:"year,omitempty"`
+	Subject   string                 `json:"subject"`
+	Body      string                 `json:"body"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+	Timestamp time.Time              `json:"timestamp"`
+}
+
+// Provider delivers notifications somewhere people will see them
+type Provider interface {
+	// Name identifies the provider in logs and test results
+	Name() string
+	Send(ctx context.Context, notification Notification) error
+}
+
+// movieLookup finds the movie an event belongs to
+type movieLookup interface {
+	GetByID(id int) (*models.Movie, error)
+}
+
+// registeredProvider is a provider and the kinds it is sent
+type registeredProvider struct {
+	provider Provider
+	kinds    map[Kind]bool
+}
+
+// ProviderInfo describes a configured provider
+type ProviderInfo struct {
+	Name   string `json:"name"`
+	Events []Kind `json:"events"`
+}
+
+// TestResult is the outcome of sending a test notification to a provider
+type TestResult struct {
+	Provider string `json:"provider"`
+	Success  bool   `json:"success"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Notifier turns movie events into notifications for its providers
+type Notifier struct {
+	movies    movieLookup
+	providers []registeredProvider
+	templates map[Kind]*template.Template
+	timeout   time.Duration
+}
+
+// NewNotifier creates a notifier without providers, using the default templates
+func NewNotifier(movies movieLookup) *Notifier {
+	n := &Notifier{
+		movies:    movies,
+		templates: make(map[Kind]*template.Template),
+		timeout:   30 * time.Second,
+	}
+	for kind, text := range defaultTemplates {
+		n.templates[kind] = template.Must(template.New(string(kind)).Parse(text))
+	}
+	return n
+}
+
+// AddProvider registers a provider for the given kinds, or for every kind
+// when none are given
+func (n *Notifier) AddProvider(provider Provider, kinds []Kind) {
+	registered := registeredProvider{provider: provider}
+	if len(kinds) > 0 {
+		registered.kinds = make(map[Kind]bool)
+		for _, kind := range kinds {
+			registered.kinds[kind] = true
+		}
+	}
+	n.providers = append(n.providers, registered)
+}
+
+// SetTemplate replaces the template of a kind of notification. Templates see
+// the Title, Year, Quality, Message and Details of the event.
+func (n *Notifier) SetTemplate(kind Kind, text string) error {
+	if _, ok := defaultTemplates[kind]; !ok {
+		return fmt.Errorf("unknown notification event %q", kind)
+	}
+	tmpl, err := template.New(string(kind)).Parse(text)
+	if err != nil {
+		return fmt.Errorf("invalid %s template: %w", kind, err)
+	}
+	n.templates[kind] = tmpl
+	return nil
+}
+
+// Providers describes the configured providers
+func (n *Notifier) Providers() []ProviderInfo {
+	infos := []ProviderInfo{}
+	if n == nil {
+		return infos
+	}
+	for _, registered := range n.providers {
+		info := ProviderInfo{Name: registered.provider.Name(), Events: []Kind{}}
+		for _, kind := range Kinds {
+			if registered.wants(kind) {
+				info.Events = append(info.Events, kind)
+			}
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// HandleEvent notifies about a stored movie event, so it can subscribe to the
+// event bus. Notifications are sent on the bus worker, each provider within
+// the notifier's timeout. A nil notifier does nothing.
+func (n *Notifier) HandleEvent(event *models.MovieEvent) error {
+	if n == nil || len(n.providers) == 0 {
+		return nil
+	}
+	n.Notify(context.Background(), *event)
+	return nil
+}
+
+// Notify sends the notification for a movie event to every provider that
+// wants its kind. Providers that fail are logged and skipped.
+func (n *Notifier) Notify(ctx context.Context, event models.MovieEvent) {
+	kind, ok := eventKind(event)
+	if !ok {
+		return
+	}
+
+	notification, err := n.render(kind, event)
+	if err != nil {
+		log.Printf("Failed to render %s notification for movie %d: %v", kind, event.MovieID, err)
+		return
+	}
+
+	for _, registered := range n.providers {
+		if !registered.wants(kind) {
+			continue
+		}
+		if err := n.send(ctx, registered.provider, notification); err != nil {
+			log.Printf("Failed to send %s notification via %s: %v", kind, registered.provider.Name(), err)
+		}
+	}
+}
+
+// Test sends a test notification to the named provider, or to all of them
+// when name is empty
+func (n *Notifier) Test(ctx context.Context, name string) []TestResult {
+	results := []TestResult{}
+	event := models.MovieEvent{Message: "Notifications from media are working."}
+	notification, err := n.render(KindTest, event)
+	if err != nil {
+		return append(results, TestResult{Provider: name, Error: err.Error()})
+	}
+
+	for _, registered := range n.providers {
+		if name != "" && registered.provider.Name() != name {
+			continue
+		}
+		result := TestResult{Provider: registered.provider.Name(), Success: true}
+		if err := n.send(ctx, registered.provider, notification); err != nil {
+			result.Success = false
+			result.Error = err.Error()
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+func (n *Notifier) send(ctx context.Context, provider Provider, notification Notification) error {
+	ctx, cancel := context.WithTimeout(ctx, n.timeout)
+	defer cancel()
+	return provider.Send(ctx, notification)
+}
+
+// render fills in the template of a kind for an event
+func (n *Notifier) render(kind Kind, event models.MovieEvent) (Notification, error) {
+	notification := Notification{
+		Kind:      kind,
+		MovieID:   event.MovieID,
+		Timestamp: event.CreatedAt,
+	}
+	if notification.Timestamp.IsZero() {
+		notification.Timestamp = time.Now()
+	}
+	if len(event.Details) > 0 {
+		if err := json.Unmarshal(event.Details, &notification.Details); err != nil {
+			log.Printf("Ignoring unreadable details of event %d: %v", event.ID, err)
+		}
+	}
+
+	data := struct {
+		Title   string
+		Year    int
+		Quality string
+		Message string
+		Details map[string]interface{}
+	}{
+		Title:   fmt.Sprintf("movie %d", event.MovieID),
+		Message: event.Message,
+		Details: notification.Details,
+	}
+	if event.MovieID != 0 && n.movies != nil {
+		if movie, err := n.movies.GetByID(event.MovieID); err == nil {
+			data.Title, data.Year, data.Quality = movie.Title, movie.Year, movie.Quality
+			notification.Title, notification.Year = movie.Title, movie.Year
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := n.templates[kind].Execute(&buf, data); err != nil {
+		return notification, err
+	}
+	text := strings.TrimSpace(buf.String())
+	notification.Subject, notification.Body, _ = strings.Cut(text, "\n")
+	notification.Body = strings.TrimSpace(notification.Body)
+	return notification, nil
+}
+
+// wants reports whether the provider is sent notifications of a kind
+func (p registeredProvider) wants(kind Kind) bool {
+	return p.kinds == nil || p.kinds[kind] || kind == KindTest
+}
@@ -0,0 +1,257 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+
+	"media/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeMovies serves movies by ID
+type fakeMovies map[int]*models.Movie
+
+func (f fakeMovies) GetByID(id int) (*models.Movie, error) {
+	if movie, ok := f[id]; ok {
+		return movie, nil
+	}
+	return nil, errors.New("movie not found")
+}
+
+// recordingProvider remembers what it was sent
+type recordingProvider struct {
+	name string
+	sent []Notification
+	fail bool
+}
+
+func (p *recordingProvider) Name() string { return p.name }
+
+func (p *recordingProvider) Send(_ context.Context, notification Notification) error {
+	if p.fail {
+		return errors.New("connection refused")
+	}
+	p.sent = append(p.sent, notification)
+	return nil
+}
+
+func TestParseKinds(t *testing.T) {
+	kinds, err := ParseKinds(" Ready, not_found,,grabbed")
+	assert.NoError(t, err)
+	assert.Equal(t, []Kind{KindReady, KindNotFound, KindGrabbed}, kinds)
+
+	_, err = ParseKinds("ready,test")
+	assert.Error(t, err)
+	_, err = ParseKinds("finished")
+	assert.Error(t, err)
+}
+
+func TestNotifier_Notify(t *testing.T) {
+	notifier := NewNotifier(fakeMovies{1: {ID: 1, Title: "Heat", Year: 1995, Quality: "1080p"}})
+	everything := &recordingProvider{name: "webhook"}
+	readyOnly := &recordingProvider{name: "email"}
+	broken := &recordingProvider{name: "slack", fail: true}
+	notifier.AddProvider(broken, nil)
+	notifier.AddProvider(everything, nil)
+	notifier.AddProvider(readyOnly, []Kind{KindReady})
+
+	// Download attempts are not grabs until the movie changes to downloading
+	notifier.Notify(context.Background(), models.MovieEvent{MovieID: 1, Type: models.EventDownloadStarted,
+		Message: "Starting download of Heat.1995.1080p"})
+	notifier.Notify(context.Background(), models.MovieEvent{MovieID: 1, Type: models.EventStatusChanged,
+		Message: "Started downloading Heat.1995.1080p",
+		Details: json.RawMessage(`{"old_status":"searching","new_status":"downloading","indexer":"yts"}`)})
+	notifier.Notify(context.Background(), models.MovieEvent{MovieID: 1, Type: models.EventImported,
+		Message: "Imported to /movies/Heat (1995)"})
+	notifier.Notify(context.Background(), models.MovieEvent{MovieID: 1, Type: models.EventSearchStarted,
+		Message: "Searching"})
+
+	if assert.Len(t, everything.sent, 2) {
+		grabbed := everything.sent[0]
+		assert.Equal(t, KindGrabbed, grabbed.Kind)
+		assert.Equal(t, "Grabbed Heat (1995)", grabbed.Subject)
+		assert.Equal(t, "Started downloading Heat.1995.1080p", grabbed.Body)
+		assert.Equal(t, "yts", grabbed.Details["indexer"])
+		assert.Equal(t, "Heat", grabbed.Title)
+	}
+	if assert.Len(t, readyOnly.sent, 1) {
+		assert.Equal(t, "Heat (1995) is ready to watch", readyOnly.sent[0].Subject)
+	}
+
+	// Custom templates and movies that cannot be found
+	assert.Error(t, notifier.SetTemplate(KindReady, "{{.Title"))
+	assert.NoError(t, notifier.SetTemplate(KindReady, "Ready: {{.Title}} in {{.Quality}}"))
+	notifier.Notify(context.Background(), models.MovieEvent{MovieID: 1, Type: models.EventImported})
+	notifier.Notify(context.Background(), models.MovieEvent{MovieID: 7, Type: models.EventImported})
+	if assert.Len(t, readyOnly.sent, 3) {
+		assert.Equal(t, "Ready: Heat in 1080p", readyOnly.sent[1].Subject)
+		assert.Equal(t, "", readyOnly.sent[1].Body)
+		assert.Equal(t, "Ready: movie 7 in", readyOnly.sent[2].Subject)
+	}
+
+	assert.Equal(t, []ProviderInfo{
+		{Name: "slack", Events: Kinds},
+		{Name: "webhook", Events: Kinds},
+		{Name: "email", Events: []Kind{KindReady}},
+	}, notifier.Providers())
+}
+
+func TestNotifier_NotifiesNotFoundOnce(t *testing.T) {
+	notifier := NewNotifier(fakeMovies{1: {ID: 1, Title: "Heat", Year: 1995}})
+	provider := &recordingProvider{name: "webhook"}
+	notifier.AddProvider(provider, nil)
+
+	notFound := func(oldStatus models.MediaStatus) models.MovieEvent {
+		details, err := json.Marshal(models.StatusChangeDetails{OldStatus: oldStatus, NewStatus: models.StatusNotFound})
+		assert.NoError(t, err)
+		return models.MovieEvent{MovieID: 1, Type: models.EventStatusChanged,
+			Message: "Status changed to: not_found", Details: details}
+	}
+
+	notifier.Notify(context.Background(), notFound(models.StatusWanted))
+
+	// Searching again for a movie still missing, or failing to search at all,
+	// is not news
+	notifier.Notify(context.Background(), notFound(models.StatusNotFound))
+	notifier.Notify(context.Background(), models.MovieEvent{MovieID: 1, Type: models.EventSearchFailed,
+		Message: "No suitable torrents found for 'Heat' (1995)"})
+	notifier.Notify(context.Background(), models.MovieEvent{MovieID: 1, Type: models.EventSearchFailed,
+		Message: "Search error: connection refused", Details: json.RawMessage(`{"error":"connection refused"}`)})
+
+	if assert.Len(t, provider.sent, 1) {
+		assert.Equal(t, KindNotFound, provider.sent[0].Kind)
+		assert.Equal(t, "Nothing found for Heat (1995)", provider.sent[0].Subject)
+	}
+}
+
+func TestNotifier_SkipsAdoptedFiles(t *testing.T) {
+	notifier := NewNotifier(fakeMovies{1: {ID: 1, Title: "Heat", Year: 1995}})
+	provider := &recordingProvider{name: "webhook"}
+	notifier.AddProvider(provider, nil)
+
+	// Files a library scan found were not just downloaded; HandleEvent sends
+	// before it returns
+	assert.NoError(t, notifier.HandleEvent(&models.MovieEvent{MovieID: 1, Type: models.EventImported,
+		Message: "Found existing file 'Heat.mkv'", Details: json.RawMessage(`{"path":"/movies/Heat.mkv","adopted":true}`)}))
+	assert.Empty(t, provider.sent)
+
+	assert.NoError(t, notifier.HandleEvent(&models.MovieEvent{MovieID: 1, Type: models.EventImported,
+		Message: "Imported to /movies/Heat (1995)", Details: json.RawMessage(`{"path":"/movies/Heat.mkv"}`)}))
+	if assert.Len(t, provider.sent, 1) {
+		assert.Equal(t, KindReady, provider.sent[0].Kind)
+	}
+}
+
+func TestNotifier_Test(t *testing.T) {
+	notifier := NewNotifier(nil)
+	readyOnly := &recordingProvider{name: "email"}
+	notifier.AddProvider(readyOnly, []Kind{KindReady})
+	notifier.AddProvider(&recordingProvider{name: "slack", fail: true}, nil)
+
+	results := notifier.Test(context.Background(), "")
+	assert.Equal(t, []TestResult{
+		{Provider: "email", Success: true},
+		{Provider: "slack", Error: "connection refused"},
+	}, results)
+	if assert.Len(t, readyOnly.sent, 1) {
+		assert.Equal(t, "Test notification", readyOnly.sent[0].Subject)
+	}
+
+	assert.Len(t, notifier.Test(context.Background(), "email"), 1)
+	assert.Empty(t, notifier.Test(context.Background(), "pushover"))
+}
+
+func TestWebhookProviders(t *testing.T) {
+	var bodies []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/down" {
+			http.Error(w, "gone fishing", http.StatusServiceUnavailable)
+			return
+		}
+		var body map[string]interface{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		bodies = append(bodies, body)
+	}))
+	defer server.Close()
+
+	notification := Notification{Kind: KindReady, MovieID: 1, Title: "Heat", Year: 1995,
+		Subject: "Heat (1995) is ready to watch", Body: "Imported to /movies/Heat (1995)"}
+	assert.NoError(t, NewWebhookProvider(server.URL).Send(context.Background(), notification))
+	assert.NoError(t, NewSlackProvider(server.URL).Send(context.Background(), notification))
+	assert.ErrorContains(t, NewWebhookProvider(server.URL+"/down").Send(context.Background(), notification), "gone fishing")
+
+	if assert.Len(t, bodies, 2) {
+		assert.Equal(t, "ready", bodies[0]["event"])
+		assert.Equal(t, "Heat", bodies[0]["title"])
+		assert.Equal(t, float64(1), bodies[0]["movie_id"])
+		assert.Equal(t, map[string]interface{}{
+			"text": "*Heat (1995) is ready to watch*\nImported to /movies/Heat (1995)",
+		}, bodies[1])
+	}
+}
+
+func TestEmailProvider(t *testing.T) {
+	_, err := NewEmailProvider(EmailConfig{Host: "smtp.example.com", Username: "me@example.com"})
+	assert.Error(t, err)
+
+	provider, err := NewEmailProvider(EmailConfig{Host: "smtp.example.com", Username: "me@example.com",
+		Password: "secret", To: []string{"you@example.com", "them@example.com"}})
+	assert.NoError(t, err)
+
+	var addr, from string
+	var to []string
+	var msg []byte
+	provider.sendMail = func(_ context.Context, a string, auth smtp.Auth, f string, t []string, m []byte) error {
+		addr, from, to, msg = a, f, t, m
+		return nil
+	}
+
+	assert.NoError(t, provider.Send(context.Background(), Notification{Subject: "Heat (1995) is ready to watch",
+		Body: "Imported to\n/movies/Heat (1995)"}))
+	assert.Equal(t, "smtp.example.com:587", addr)
+	assert.Equal(t, "me@example.com", from)
+	assert.Equal(t, []string{"you@example.com", "them@example.com"}, to)
+	assert.Contains(t, string(msg), "To: you@example.com, them@example.com\r\n")
+	assert.Contains(t, string(msg), "Subject: Heat (1995) is ready to watch\r\n")
+	assert.True(t, strings.HasSuffix(string(msg), "\r\n\r\nImported to\r\n/movies/Heat (1995)\r\n"))
+}
+
+func TestSendMail_StalledServer(t *testing.T) {
+	// The server accepts connections but never says anything
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer func() { _ = listener.Close() }()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer func() { _ = conn.Close() }()
+		}
+	}()
+
+	for name, send := range map[string]func(context.Context, string, smtp.Auth, string, []string, []byte) error{
+		"starttls": sendMailSTARTTLS,
+		"tls":      sendMailTLS,
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+
+			start := time.Now()
+			err := send(ctx, listener.Addr().String(), nil, "me@example.com", []string{"you@example.com"}, []byte("hi"))
+			assert.Error(t, err)
+			assert.Less(t, time.Since(start), 5*time.Second)
+		})
+	}
+}
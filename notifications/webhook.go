@@ -0,0 +1,81 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+// WebhookProvider posts notifications as JSON to a URL
+type WebhookProvider struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookProvider creates a provider posting the notification itself as JSON
+func NewWebhookProvider(url string) *WebhookProvider {
+	return &WebhookProvider{url: url, client: &http.Client{Timeout: 30 * time.Second}}
+}
+
+func (p *WebhookProvider) Name() string { return "webhook" }
+
+func (p *WebhookProvider) Send(ctx context.Context, notification Notification) error {
+	return postJSON(ctx, p.client, p.url, notification)
+}
+
+// SlackProvider posts notifications to a Slack incoming webhook. Mattermost,
+// Rocket.Chat and Discord's /slack endpoint accept the same payload.
+type SlackProvider struct {
+	url    string
+	client *http.Client
+}
+
+// NewSlackProvider creates a provider for a Slack-compatible incoming webhook
+func NewSlackProvider(url string) *SlackProvider {
+	return &SlackProvider{url: url, client: &http.Client{Timeout: 30 * time.Second}}
+}
+
+func (p *SlackProvider) Name() string { return "slack" }
+
+func (p *SlackProvider) Send(ctx context.Context, notification Notification) error {
+	text := "*" + notification.Subject + "*"
+	if notification.Body != "" {
+		text += "\n" + notification.Body
+	}
+	return postJSON(ctx, p.client, p.url, map[string]string{"text": text})
+}
+
+// postJSON posts payload to url, failing on non-2xx statuses
+func postJSON(ctx context.Context, client *http.Client, url string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post notification: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Printf("Failed to close response body: %v", err)
+		}
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook returned status %d: %s", resp.StatusCode, bytes.TrimSpace(message))
+	}
+	return nil
+}
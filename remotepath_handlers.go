@@ -0,0 +1,103 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"media/models"
+	"media/repository"
+
+	"github.com/gorilla/mux"
+)
+
+func (app *App) getRemotePathMappingsHandler(w http.ResponseWriter, _ *http.Request) {
+	mappings, err := app.pathMappingRepo.GetAll()
+	if err != nil {
+		log.Printf("Error getting remote path mappings: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(mappings); err != nil {
+		log.Printf("Error encoding remote path mappings: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (app *App) createRemotePathMappingHandler(w http.ResponseWriter, r *http.Request) {
+	var mapping models.RemotePathMapping
+	if err := json.NewDecoder(r.Body).Decode(&mapping); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if mapping.Client == "" {
+		mapping.Client = models.DownloadClientQBittorrent
+	}
+	if mapping.Client != models.DownloadClientQBittorrent {
+		http.Error(w, "Unsupported download client", http.StatusBadRequest)
+		return
+	}
+
+	mapping.RemotePath = strings.TrimSpace(mapping.RemotePath)
+	if mapping.RemotePath == "" {
+		http.Error(w, "remote_path is required", http.StatusBadRequest)
+		return
+	}
+
+	mapping.LocalPath = strings.TrimSpace(mapping.LocalPath)
+	if !filepath.IsAbs(mapping.LocalPath) {
+		http.Error(w, "local_path must be an absolute path", http.StatusBadRequest)
+		return
+	}
+	mapping.LocalPath = filepath.Clean(mapping.LocalPath)
+	if info, err := os.Stat(mapping.LocalPath); err != nil || !info.IsDir() {
+		http.Error(w, "local_path does not exist or is not a directory", http.StatusBadRequest)
+		return
+	}
+
+	if err := app.pathMappingRepo.Create(&mapping); err != nil {
+		if errors.Is(err, repository.ErrRemotePathMappingExists) {
+			http.Error(w, "Remote path mapping already exists", http.StatusConflict)
+			return
+		}
+		log.Printf("Error creating remote path mapping: %v", err)
+		http.Error(w, "Failed to create remote path mapping", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(mapping); err != nil {
+		log.Printf("Error encoding remote path mapping response: %v", err)
+	}
+}
+
+func (app *App) deleteRemotePathMappingHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid remote path mapping ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := app.pathMappingRepo.Delete(id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Remote path mapping not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error deleting remote path mapping: %v", err)
+		http.Error(w, "Failed to delete remote path mapping", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"media/database"
+	"media/models"
+	"media/repository"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeleteRemotePathMappingHandler(t *testing.T) {
+	testDB, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	defer func() {
+		if err := testDB.Close(); err != nil {
+			t.Logf("Failed to close test database: %v", err)
+		}
+	}()
+	testDB.SetMaxOpenConns(1)
+	if err := testDB.InitSchema(); err != nil {
+		t.Fatalf("Failed to initialize test schema: %v", err)
+	}
+
+	pathMappingRepo := repository.NewRemotePathMappingRepository(testDB)
+	app := &App{pathMappingRepo: pathMappingRepo}
+	mapping := &models.RemotePathMapping{Client: models.DownloadClientQBittorrent,
+		RemotePath: "/downloads", LocalPath: "/mnt/seedbox/downloads"}
+	assert.NoError(t, pathMappingRepo.Create(mapping))
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/v1/remotepathmappings/{id}", app.deleteRemotePathMappingHandler).Methods("DELETE")
+	remove := func() int {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodDelete,
+			fmt.Sprintf("/api/v1/remotepathmappings/%d", mapping.ID), nil))
+		return rr.Code
+	}
+
+	assert.Equal(t, http.StatusNoContent, remove())
+	assert.Equal(t, http.StatusNotFound, remove())
+
+	// Database failures are not reported as missing mappings
+	assert.NoError(t, testDB.Close())
+	assert.Equal(t, http.StatusInternalServerError, remove())
+}
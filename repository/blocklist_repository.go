@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"media/database"
+	"media/models"
+)
+
+// BlocklistRepository handles database operations for rejected releases
+type BlocklistRepository struct {
+	db *database.DB
+}
+
+// NewBlocklistRepository creates a new blocklist repository
+func NewBlocklistRepository(db *database.DB) *BlocklistRepository {
+	return &BlocklistRepository{db: db}
+}
+
+// Create adds a release to the blocklist
+func (r *BlocklistRepository) Create(entry *models.BlocklistEntry) error {
+	entry.InfoHash = strings.ToLower(entry.InfoHash)
+	entry.CreatedAt = time.Now()
+
+	result, err := r.db.Exec(
+		`INSERT INTO blocklist (movie_id, title, info_hash, reason, created_at) VALUES (?, ?, ?, ?, ?)`,
+		entry.MovieID, entry.Title, nullString(entry.InfoHash), entry.Reason, entry.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create blocklist entry: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	entry.ID = int(id)
+	return nil
+}
+
+// GetAll returns every blocklisted release, newest first
+func (r *BlocklistRepository) GetAll() ([]models.BlocklistEntry, error) {
+	rows, err := r.db.Query(
+		`SELECT id, movie_id, title, info_hash, reason, created_at FROM blocklist ORDER BY created_at DESC, id DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query blocklist: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Failed to close rows: %v", err)
+		}
+	}()
+
+	entries := []models.BlocklistEntry{}
+	for rows.Next() {
+		var entry models.BlocklistEntry
+		var infoHash sql.NullString
+		if err := rows.Scan(&entry.ID, &entry.MovieID, &entry.Title, &infoHash, &entry.Reason, &entry.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan blocklist entry: %w", err)
+		}
+		if infoHash.Valid {
+			entry.InfoHash = infoHash.String
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating blocklist: %w", err)
+	}
+
+	return entries, nil
+}
+
+// IsBlocklisted reports whether a release matches a blocklist entry. A known
+// info hash identifies the release for every movie; without one, the release
+// is matched by title among the entries of the same movie only.
+func (r *BlocklistRepository) IsBlocklisted(movieID int, infoHash, title string) (bool, error) {
+	var count int
+	var err error
+	if infoHash != "" {
+		err = r.db.QueryRow(`SELECT COUNT(*) FROM blocklist WHERE info_hash = ?`,
+			strings.ToLower(infoHash)).Scan(&count)
+	} else {
+		err = r.db.QueryRow(`SELECT COUNT(*) FROM blocklist WHERE movie_id = ? AND title = ?`,
+			movieID, title).Scan(&count)
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check blocklist: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+// Delete removes a release from the blocklist
+func (r *BlocklistRepository) Delete(id int) error {
+	result, err := r.db.Exec(`DELETE FROM blocklist WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete blocklist entry: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("blocklist entry with id %d not found", id)
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"testing"
+
+	"media/database"
+	"media/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupTestBlocklistRepo(t *testing.T) (*BlocklistRepository, func()) {
+	testDB, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+
+	if err := testDB.InitSchema(); err != nil {
+		t.Fatalf("Failed to initialize test schema: %v", err)
+	}
+
+	cleanup := func() {
+		if err := testDB.Close(); err != nil {
+			t.Logf("Failed to close test database: %v", err)
+		}
+	}
+
+	return NewBlocklistRepository(testDB), cleanup
+}
+
+func TestBlocklistRepository_CreateAndLookup(t *testing.T) {
+	repo, cleanup := setupTestBlocklistRepo(t)
+	defer cleanup()
+
+	entry := &models.BlocklistEntry{
+		MovieID:  1,
+		Title:    "Fake.Movie.2023.1080p",
+		InfoHash: "0123456789ABCDEF0123456789ABCDEF01234567",
+		Reason:   "contains executable files",
+	}
+	assert.NoError(t, repo.Create(entry))
+	assert.NotZero(t, entry.ID)
+
+	// Hash lookups are case insensitive
+	blocked, err := repo.IsBlocklisted(2, "0123456789abcdef0123456789abcdef01234567", "Other Title")
+	assert.NoError(t, err)
+	assert.True(t, blocked)
+
+	// A known hash is matched by hash only
+	blocked, err = repo.IsBlocklisted(1, "fedcba9876543210fedcba9876543210fedcba98", "Fake.Movie.2023.1080p")
+	assert.NoError(t, err)
+	assert.False(t, blocked)
+
+	// Releases without a hash are matched by title for the same movie
+	blocked, err = repo.IsBlocklisted(1, "", "Fake.Movie.2023.1080p")
+	assert.NoError(t, err)
+	assert.True(t, blocked)
+
+	blocked, err = repo.IsBlocklisted(2, "", "Fake.Movie.2023.1080p")
+	assert.NoError(t, err)
+	assert.False(t, blocked)
+
+	blocked, err = repo.IsBlocklisted(1, "", "Real.Movie.2023.1080p")
+	assert.NoError(t, err)
+	assert.False(t, blocked)
+
+	entries, err := repo.GetAll()
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "0123456789abcdef0123456789abcdef01234567", entries[0].InfoHash)
+}
+
+func TestBlocklistRepository_Delete(t *testing.T) {
+	repo, cleanup := setupTestBlocklistRepo(t)
+	defer cleanup()
+
+	entry := &models.BlocklistEntry{MovieID: 1, Title: "Fake.Movie", Reason: "contains only archives"}
+	assert.NoError(t, repo.Create(entry))
+
+	assert.NoError(t, repo.Delete(entry.ID))
+	assert.Error(t, repo.Delete(entry.ID))
+
+	blocked, err := repo.IsBlocklisted(1, "", "Fake.Movie")
+	assert.NoError(t, err)
+	assert.False(t, blocked)
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"media/database"
+	"media/models"
+)
+
+// MediaFileRepository handles database operations for probed media files
+type MediaFileRepository struct {
+	db *database.DB
+}
+
+// NewMediaFileRepository creates a new media file repository
+func NewMediaFileRepository(db *database.DB) *MediaFileRepository {
+	return &MediaFileRepository{db: db}
+}
+
+const mediaFileColumns = `id, movie_id, path, size, container, duration, video_codec, width, height,
+	resolution, hdr, audio_tracks, subtitle_tracks, probed_at`
+
+// scanMediaFile reads a single row selected with mediaFileColumns
+func scanMediaFile(row rowScanner) (*models.MediaFile, error) {
+	var media models.MediaFile
+	var duration sql.NullFloat64
+	var videoCodec, resolution, hdr, audioTracks, subtitleTracks sql.NullString
+	var width, height sql.NullInt64
+
+	err := row.Scan(&media.ID, &media.MovieID, &media.Path, &media.Size, &media.Container, &duration,
+		&videoCodec, &width, &height, &resolution, &hdr, &audioTracks, &subtitleTracks, &media.ProbedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	media.Duration = duration.Float64
+	media.VideoCodec = videoCodec.String
+	media.Width = int(width.Int64)
+	media.Height = int(height.Int64)
+	media.Resolution = resolution.String
+	media.HDR = hdr.String
+
+	media.AudioTracks = []models.AudioTrack{}
+	if audioTracks.Valid {
+		if err := json.Unmarshal([]byte(audioTracks.String), &media.AudioTracks); err != nil {
+			return nil, fmt.Errorf("failed to decode audio tracks: %w", err)
+		}
+	}
+	media.SubtitleTracks = []models.SubtitleTrack{}
+	if subtitleTracks.Valid {
+		if err := json.Unmarshal([]byte(subtitleTracks.String), &media.SubtitleTracks); err != nil {
+			return nil, fmt.Errorf("failed to decode subtitle tracks: %w", err)
+		}
+	}
+
+	return &media, nil
+}
+
+// Save stores the probe result for media.MovieID, replacing any earlier one
+func (r *MediaFileRepository) Save(media *models.MediaFile) error {
+	audioTracks, err := json.Marshal(media.AudioTracks)
+	if err != nil {
+		return fmt.Errorf("failed to encode audio tracks: %w", err)
+	}
+	subtitleTracks, err := json.Marshal(media.SubtitleTracks)
+	if err != nil {
+		return fmt.Errorf("failed to encode subtitle tracks: %w", err)
+	}
+
+	_, err = r.db.Exec(`
+		INSERT INTO media_files (movie_id, path, size, container, duration, video_codec, width, height,
+			resolution, hdr, audio_tracks, subtitle_tracks, probed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(movie_id) DO UPDATE SET
+			path = excluded.path,
+			size = excluded.size,
+			container = excluded.container,
+			duration = excluded.duration,
+			video_codec = excluded.video_codec,
+			width = excluded.width,
+			height = excluded.height,
+			resolution = excluded.resolution,
+			hdr = excluded.hdr,
+			audio_tracks = excluded.audio_tracks,
+			subtitle_tracks = excluded.subtitle_tracks,
+			probed_at = excluded.probed_at
+	`, media.MovieID, media.Path, media.Size, media.Container, nullFloat64(media.Duration),
+		nullString(media.VideoCodec), nullInt(media.Width), nullInt(media.Height),
+		nullString(media.Resolution), nullString(media.HDR), string(audioTracks), string(subtitleTracks),
+		media.ProbedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save media file: %w", err)
+	}
+
+	saved, err := scanMediaFile(r.db.QueryRow(
+		`SELECT `+mediaFileColumns+` FROM media_files WHERE movie_id = ?`, media.MovieID))
+	if err != nil {
+		return fmt.Errorf("failed to reload media file: %w", err)
+	}
+
+	*media = *saved
+	return nil
+}
+
+// GetByMovieID returns the probe result for a movie, or nil if its file has
+// not been probed
+func (r *MediaFileRepository) GetByMovieID(movieID int) (*models.MediaFile, error) {
+	media, err := scanMediaFile(r.db.QueryRow(
+		`SELECT `+mediaFileColumns+` FROM media_files WHERE movie_id = ?`, movieID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get media file: %w", err)
+	}
+
+	return media, nil
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"media/database"
+	"media/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupTestMediaFileRepo(t *testing.T) (*MediaFileRepository, func()) {
+	testDB, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+
+	if err := testDB.InitSchema(); err != nil {
+		t.Fatalf("Failed to initialize test schema: %v", err)
+	}
+
+	cleanup := func() {
+		if err := testDB.Close(); err != nil {
+			t.Logf("Failed to close test database: %v", err)
+		}
+	}
+
+	return NewMediaFileRepository(testDB), cleanup
+}
+
+func TestMediaFileRepository_Save(t *testing.T) {
+	repo, cleanup := setupTestMediaFileRepo(t)
+	defer cleanup()
+
+	media, err := repo.GetByMovieID(1)
+	assert.NoError(t, err)
+	assert.Nil(t, media)
+
+	media = &models.MediaFile{
+		MovieID:        1,
+		Path:           "/movies/Inception (2010)/Inception (2010).mkv",
+		Size:           4 << 30,
+		Container:      "matroska",
+		Duration:       8880.5,
+		VideoCodec:     "hevc",
+		Width:          3840,
+		Height:         1600,
+		Resolution:     "4K",
+		HDR:            "HDR10",
+		AudioTracks:    []models.AudioTrack{{Codec: "eac3", Language: "eng", Channels: 6, Default: true}},
+		SubtitleTracks: []models.SubtitleTrack{{Codec: "pgs", Language: "en", Forced: true}},
+		ProbedAt:       time.Now(),
+	}
+	assert.NoError(t, repo.Save(media))
+	assert.NotZero(t, media.ID)
+
+	saved, err := repo.GetByMovieID(1)
+	assert.NoError(t, err)
+	if assert.NotNil(t, saved) {
+		assert.Equal(t, "hevc", saved.VideoCodec)
+		assert.Equal(t, 1600, saved.Height)
+		assert.InDelta(t, 8880.5, saved.Duration, 0.001)
+		assert.Equal(t, media.AudioTracks, saved.AudioTracks)
+		assert.Equal(t, media.SubtitleTracks, saved.SubtitleTracks)
+	}
+
+	// Probing again replaces the earlier result
+	reprobed := &models.MediaFile{MovieID: 1, Path: "/movies/Inception.mp4", Container: "mp4", Resolution: "720p",
+		AudioTracks: []models.AudioTrack{}, SubtitleTracks: []models.SubtitleTrack{}, ProbedAt: time.Now()}
+	assert.NoError(t, repo.Save(reprobed))
+	assert.Equal(t, media.ID, reprobed.ID)
+	assert.Equal(t, "mp4", reprobed.Container)
+	assert.Empty(t, reprobed.VideoCodec)
+	assert.Empty(t, reprobed.AudioTracks)
+}
@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"media/database"
+	"media/models"
+)
+
+// MediaServerRepository handles database operations for media server connections
+type MediaServerRepository struct {
+	db *database.DB
+}
+
+// NewMediaServerRepository creates a new media server repository
+func NewMediaServerRepository(db *database.DB) *MediaServerRepository {
+	return &MediaServerRepository{db: db}
+}
+
+const mediaServerColumns = `id, name, type, url, api_key, username, password, enabled, created_at`
+
+// scanMediaServer reads a single row selected with mediaServerColumns
+func scanMediaServer(row rowScanner) (*models.MediaServer, error) {
+	var server models.MediaServer
+	var apiKey, username, password sql.NullString
+	err := row.Scan(&server.ID, &server.Name, &server.Type, &server.URL, &apiKey, &username, &password,
+		&server.Enabled, &server.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	server.APIKey = apiKey.String
+	server.Username = username.String
+	server.Password = password.String
+	return &server, nil
+}
+
+// Create adds a media server connection
+func (r *MediaServerRepository) Create(server *models.MediaServer) error {
+	server.CreatedAt = time.Now()
+
+	result, err := r.db.Exec(`
+		INSERT INTO media_servers (name, type, url, api_key, username, password, enabled, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, server.Name, server.Type, server.URL, nullString(server.APIKey), nullString(server.Username),
+		nullString(server.Password), server.Enabled, server.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create media server: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	server.ID = int(id)
+	return nil
+}
+
+// GetAll returns every media server connection
+func (r *MediaServerRepository) GetAll() ([]models.MediaServer, error) {
+	return r.query(`SELECT ` + mediaServerColumns + ` FROM media_servers ORDER BY id`)
+}
+
+// GetEnabled returns the media server connections that want notifications
+func (r *MediaServerRepository) GetEnabled() ([]models.MediaServer, error) {
+	return r.query(`SELECT ` + mediaServerColumns + ` FROM media_servers WHERE enabled = 1 ORDER BY id`)
+}
+
+// GetByID retrieves a media server connection by its ID
+func (r *MediaServerRepository) GetByID(id int) (*models.MediaServer, error) {
+	server, err := scanMediaServer(r.db.QueryRow(`SELECT `+mediaServerColumns+` FROM media_servers WHERE id = ?`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("media server with id %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get media server: %w", err)
+	}
+
+	return server, nil
+}
+
+// Update changes a media server connection
+func (r *MediaServerRepository) Update(server *models.MediaServer) error {
+	result, err := r.db.Exec(`
+		UPDATE media_servers
+		SET name = ?, type = ?, url = ?, api_key = ?, username = ?, password = ?, enabled = ?
+		WHERE id = ?
+	`, server.Name, server.Type, server.URL, nullString(server.APIKey), nullString(server.Username),
+		nullString(server.Password), server.Enabled, server.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update media server: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("media server with id %d not found", server.ID)
+	}
+
+	return nil
+}
+
+// Delete removes a media server connection
+func (r *MediaServerRepository) Delete(id int) error {
+	result, err := r.db.Exec(`DELETE FROM media_servers WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete media server: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("media server with id %d not found", id)
+	}
+
+	return nil
+}
+
+// query runs a media server query and scans every returned row
+func (r *MediaServerRepository) query(query string, args ...interface{}) ([]models.MediaServer, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query media servers: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Failed to close rows: %v", err)
+		}
+	}()
+
+	servers := []models.MediaServer{}
+	for rows.Next() {
+		server, err := scanMediaServer(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan media server: %w", err)
+		}
+		servers = append(servers, *server)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating media servers: %w", err)
+	}
+
+	return servers, nil
+}
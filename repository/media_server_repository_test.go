@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"testing"
+
+	"media/database"
+	"media/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMediaServerRepository(t *testing.T) {
+	testDB, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	defer func() {
+		if err := testDB.Close(); err != nil {
+			t.Logf("Failed to close test database: %v", err)
+		}
+	}()
+	if err := testDB.InitSchema(); err != nil {
+		t.Fatalf("Failed to initialize test schema: %v", err)
+	}
+
+	repo := NewMediaServerRepository(testDB)
+
+	jellyfin := &models.MediaServer{Name: "Living room", Type: models.MediaServerJellyfin,
+		URL: "http://jellyfin:8096", APIKey: "secret", Enabled: true}
+	kodi := &models.MediaServer{Name: "Bedroom", Type: models.MediaServerKodi,
+		URL: "http://kodi:8080", Username: "kodi", Password: "kodi"}
+	assert.NoError(t, repo.Create(jellyfin))
+	assert.NoError(t, repo.Create(kodi))
+
+	servers, err := repo.GetAll()
+	assert.NoError(t, err)
+	assert.Len(t, servers, 2)
+
+	enabled, err := repo.GetEnabled()
+	assert.NoError(t, err)
+	if assert.Len(t, enabled, 1) {
+		assert.Equal(t, "secret", enabled[0].APIKey)
+	}
+
+	kodi.Enabled = true
+	kodi.Password = "changed"
+	assert.NoError(t, repo.Update(kodi))
+	saved, err := repo.GetByID(kodi.ID)
+	assert.NoError(t, err)
+	assert.True(t, saved.Enabled)
+	assert.Equal(t, "changed", saved.Password)
+
+	assert.NoError(t, repo.Delete(jellyfin.ID))
+	assert.Error(t, repo.Delete(jellyfin.ID))
+	_, err = repo.GetByID(jellyfin.ID)
+	assert.Error(t, err)
+	assert.Error(t, repo.Update(jellyfin))
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"media/database"
 	"media/models"
+	"strings"
 	"time"
 )
 
@@ -20,33 +21,171 @@ func NewMovieEventRepository(db *database.DB) *MovieEventRepository {
 }
 
 // Create adds a new movie event
-func (r *MovieEventRepository) Create(movieID int, eventType models.MovieEventType, message string, details interface{}) error {
-	var detailsJSON string
+func (r *MovieEventRepository) Create(movieID int, eventType models.MovieEventType, message string, details models.EventDetails) error {
+	event := &models.MovieEvent{
+		MovieID: movieID,
+		Type:    eventType,
+		Message: message,
+	}
 	if details != nil {
-		detailsBytes, err := json.Marshal(details)
+		detailsJSON, err := json.Marshal(details)
 		if err != nil {
 			return fmt.Errorf("failed to marshal event details: %w", err)
 		}
-		detailsJSON = string(detailsBytes)
+		event.Details = detailsJSON
+		event.DetailsVersion = models.EventDetailsVersion
+	}
+
+	return r.Save(event)
+}
+
+// Save stores an event whose details are already encoded, setting its ID and,
+// if it has none, its creation time
+func (r *MovieEventRepository) Save(event *models.MovieEvent) error {
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
 	}
+	// Stored like CURRENT_TIMESTAMP, so created_at sorts and compares the same for every row
+	event.CreatedAt = event.CreatedAt.UTC().Truncate(time.Second)
 
-	query := `INSERT INTO movie_events (movie_id, type, message, details) VALUES (?, ?, ?, ?)`
-	_, err := r.db.Exec(query, movieID, string(eventType), message, detailsJSON)
+	var details sql.NullString
+	if len(event.Details) > 0 {
+		details = sql.NullString{String: string(event.Details), Valid: true}
+	}
+
+	query := `INSERT INTO movie_events (movie_id, type, message, details, details_version, created_at)
+			  VALUES (?, ?, ?, ?, ?, ?)`
+	result, err := r.db.Exec(query, event.MovieID, string(event.Type), event.Message, details,
+		event.DetailsVersion, event.CreatedAt.Format("2006-01-02 15:04:05"))
 	if err != nil {
 		return fmt.Errorf("failed to create movie event: %w", err)
 	}
 
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get movie event ID: %w", err)
+	}
+
+	event.ID = int(id)
 	return nil
 }
 
 // GetByMovieID returns all events for a specific movie
 func (r *MovieEventRepository) GetByMovieID(movieID int) ([]models.MovieEvent, error) {
-	query := `SELECT id, movie_id, type, message, details, created_at 
+	query := `SELECT id, movie_id, type, message, details, details_version, created_at 
 			  FROM movie_events 
 			  WHERE movie_id = ? 
 			  ORDER BY created_at DESC`
 
-	rows, err := r.db.Query(query, movieID)
+	return r.queryEvents(query, movieID)
+}
+
+// GetAfter returns up to limit events with an ID above afterID, oldest first,
+// optionally only those of one movie. It lets event streams resume where a
+// client left off.
+func (r *MovieEventRepository) GetAfter(afterID, movieID, limit int) ([]models.MovieEvent, error) {
+	query := `SELECT id, movie_id, type, message, details, details_version, created_at
+			  FROM movie_events
+			  WHERE id > ? AND (? = 0 OR movie_id = ?)
+			  ORDER BY id
+			  LIMIT ?`
+
+	return r.queryEvents(query, afterID, movieID, movieID, limit)
+}
+
+// Search returns the events matching a filter across all movies, newest first,
+// with the title of each event's movie
+func (r *MovieEventRepository) Search(filter models.EventFilter) ([]models.ActivityEvent, error) {
+	var conditions []string
+	var args []interface{}
+
+	if len(filter.Types) > 0 {
+		placeholders := make([]string, len(filter.Types))
+		for i, eventType := range filter.Types {
+			placeholders[i] = "?"
+			args = append(args, string(eventType))
+		}
+		conditions = append(conditions, "e.type IN ("+strings.Join(placeholders, ", ")+")")
+	}
+	if filter.MovieID != 0 {
+		conditions = append(conditions, "e.movie_id = ?")
+		args = append(args, filter.MovieID)
+	}
+	if !filter.Since.IsZero() {
+		conditions = append(conditions, "e.created_at >= ?")
+		args = append(args, filter.Since.UTC().Format("2006-01-02 15:04:05"))
+	}
+	if !filter.Until.IsZero() {
+		conditions = append(conditions, "e.created_at < ?")
+		args = append(args, filter.Until.UTC().Format("2006-01-02 15:04:05"))
+	}
+	if text := strings.TrimSpace(filter.Query); text != "" {
+		pattern := "%" + escapeLike(text) + "%"
+		conditions = append(conditions, `(e.message LIKE ? ESCAPE '\' OR m.title LIKE ? ESCAPE '\')`)
+		args = append(args, pattern, pattern)
+	}
+	if filter.BeforeID > 0 {
+		conditions = append(conditions, "e.id < ?")
+		args = append(args, filter.BeforeID)
+	}
+
+	query := `SELECT e.id, e.movie_id, e.type, e.message, e.details, e.details_version, e.created_at, m.title, m.year
+			  FROM movie_events e
+			  LEFT JOIN movies m ON m.id = e.movie_id`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY e.id DESC"
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search movie events: %w", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			fmt.Printf("Failed to close rows: %v\n", cerr)
+		}
+	}()
+
+	events := []models.ActivityEvent{}
+	for rows.Next() {
+		var event models.ActivityEvent
+		var details, title sql.NullString
+		var year sql.NullInt64
+
+		err := rows.Scan(&event.ID, &event.MovieID, &event.Type, &event.Message, &details, &event.DetailsVersion,
+			&event.CreatedAt, &title, &year)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan movie event: %w", err)
+		}
+		if details.Valid && details.String != "" {
+			event.Details = json.RawMessage(details.String)
+		}
+		event.MovieTitle = title.String
+		event.MovieYear = int(year.Int64)
+
+		events = append(events, event)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating movie events: %w", err)
+	}
+
+	return events, nil
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern, using \ as the escape
+func escapeLike(text string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(text)
+}
+
+// queryEvents runs an event query and scans every returned row
+func (r *MovieEventRepository) queryEvents(query string, args ...interface{}) ([]models.MovieEvent, error) {
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query movie events: %w", err)
 	}
@@ -60,19 +199,15 @@ func (r *MovieEventRepository) GetByMovieID(movieID int) ([]models.MovieEvent, e
 	for rows.Next() {
 		var event models.MovieEvent
 		var details sql.NullString
-		var createdAt string
 
-		err := rows.Scan(&event.ID, &event.MovieID, &event.Type, &event.Message, &details, &createdAt)
+		err := rows.Scan(&event.ID, &event.MovieID, &event.Type, &event.Message, &details, &event.DetailsVersion,
+			&event.CreatedAt)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan movie event: %w", err)
 		}
 
-		if details.Valid {
-			event.Details = details.String
-		}
-
-		if parsedTime, err := time.Parse("2006-01-02 15:04:05", createdAt); err == nil {
-			event.CreatedAt = parsedTime
+		if details.Valid && details.String != "" {
+			event.Details = json.RawMessage(details.String)
 		}
 
 		events = append(events, event)
@@ -133,27 +268,74 @@ func (r *MovieEventRepository) GetStatistics(movieID int) (*models.MovieStats, e
 			continue
 		}
 
-		var details map[string]interface{}
-		if err := json.Unmarshal([]byte(detailsJSON), &details); err != nil {
+		event := models.MovieEvent{Details: json.RawMessage(detailsJSON)}
+		var found models.TorrentFoundDetails
+		if err := event.DecodeDetails(&found); err != nil {
 			continue
 		}
 
-		if score, ok := details["score"].(float64); ok && int(score) > bestScore {
-			bestScore = int(score)
+		if found.Score > bestScore {
+			bestScore = found.Score
 		}
 	}
 	stats.BestTorrentScore = bestScore
 
+	// Search times come from the recorded search runs
+	searchTimes, err := searchTimeStats(r.db, movieID)
+	if err != nil {
+		return nil, err
+	}
+	stats.AvgSearchTime = searchTimes.AvgMs / 1000
+	stats.P50SearchTime = float64(searchTimes.P50Ms) / 1000
+	stats.P90SearchTime = float64(searchTimes.P90Ms) / 1000
+
 	return stats, nil
 }
 
-// DeleteOldEvents removes events older than the specified duration
-func (r *MovieEventRepository) DeleteOldEvents(olderThan time.Duration) error {
-	cutoff := time.Now().Add(-olderThan)
-	query := `DELETE FROM movie_events WHERE created_at < ?`
-	_, err := r.db.Exec(query, cutoff.Format("2006-01-02 15:04:05"))
+// DeleteOldEvents removes events older than the specified duration, except
+// the last keepPerType events of each type of a movie. It returns how many
+// events were deleted.
+func (r *MovieEventRepository) DeleteOldEvents(olderThan time.Duration, keepPerType int) (int64, error) {
+	cutoff := time.Now().UTC().Add(-olderThan)
+	return r.deleteRanked("created_at < ?", keepPerType, cutoff.Format("2006-01-02 15:04:05"))
+}
+
+// DeleteExcessEvents removes all but the newest maxPerMovie events of each
+// movie, except the last keepPerType events of each type. It returns how many
+// events were deleted.
+func (r *MovieEventRepository) DeleteExcessEvents(maxPerMovie, keepPerType int) (int64, error) {
+	return r.deleteRanked("movie_rank > ?", keepPerType, maxPerMovie)
+}
+
+// deleteRanked deletes the events matching a condition on their columns and
+// their rank among the movie's events, newest first, sparing those ranked
+// within keepPerType among the movie's events of the same type
+func (r *MovieEventRepository) deleteRanked(condition string, keepPerType int, args ...interface{}) (int64, error) {
+	query := `DELETE FROM movie_events WHERE id IN (
+			  	SELECT id FROM (
+			  		SELECT id, created_at,
+			  			ROW_NUMBER() OVER (PARTITION BY movie_id ORDER BY id DESC) AS movie_rank,
+			  			ROW_NUMBER() OVER (PARTITION BY movie_id, type ORDER BY id DESC) AS type_rank
+			  		FROM movie_events
+			  	) WHERE type_rank > ? AND ` + condition + `
+			  )`
+
+	result, err := r.db.Exec(query, append([]interface{}{keepPerType}, args...)...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete old events: %w", err)
+	}
+	deleted, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("failed to delete old events: %w", err)
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return deleted, nil
+}
+
+// Vacuum rebuilds the database file, returning the space of deleted events to
+// the filesystem
+func (r *MovieEventRepository) Vacuum() error {
+	if _, err := r.db.Exec("VACUUM"); err != nil {
+		return fmt.Errorf("failed to vacuum database: %w", err)
 	}
 	return nil
 }
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"media/database"
+	"media/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMovieEventRepository_SaveAndGetAfter(t *testing.T) {
+	testDB, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	defer func() {
+		if err := testDB.Close(); err != nil {
+			t.Logf("Failed to close test database: %v", err)
+		}
+	}()
+	if err := testDB.InitSchema(); err != nil {
+		t.Fatalf("Failed to initialize test schema: %v", err)
+	}
+
+	repo := NewMovieEventRepository(testDB)
+
+	assert.NoError(t, repo.Create(1, models.EventSearchStarted, "Searching for Heat", nil))
+	assert.NoError(t, repo.Create(2, models.EventSearchStarted, "Searching for Ronin", nil))
+
+	// Saving a published event fills in its ID
+	published := &models.MovieEvent{MovieID: 1, Type: models.EventDownloadStarted, Message: "Downloading Heat",
+		Details: json.RawMessage(`{"indexer":"yts"}`), DetailsVersion: 1, CreatedAt: time.Now().UTC()}
+	assert.NoError(t, repo.Save(published))
+	assert.Equal(t, 3, published.ID)
+
+	events, err := repo.GetAfter(1, 0, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, events, 2) {
+		assert.Equal(t, 2, events[0].ID)
+		assert.Equal(t, 3, events[1].ID)
+		assert.JSONEq(t, `{"indexer":"yts"}`, string(events[1].Details))
+		assert.Equal(t, 1, events[1].DetailsVersion)
+		assert.WithinDuration(t, time.Now(), events[1].CreatedAt, time.Minute)
+	}
+
+	events, err = repo.GetAfter(0, 1, 1)
+	assert.NoError(t, err)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, "Searching for Heat", events[0].Message)
+	}
+}
+
+func TestMovieEventRepository_SearchEscapesWildcards(t *testing.T) {
+	testDB, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	defer func() {
+		if err := testDB.Close(); err != nil {
+			t.Logf("Failed to close test database: %v", err)
+		}
+	}()
+	if err := testDB.InitSchema(); err != nil {
+		t.Fatalf("Failed to initialize test schema: %v", err)
+	}
+
+	repo := NewMovieEventRepository(testDB)
+	assert.NoError(t, repo.Create(1, models.EventDownloadStarted, "Download at 100% speed", nil))
+	assert.NoError(t, repo.Create(1, models.EventDownloadStarted, "Download at 1000 KB/s", nil))
+	assert.NoError(t, repo.Create(1, models.EventImported, "Imported file_name.mkv", nil))
+
+	events, err := repo.Search(models.EventFilter{Query: "100%"})
+	assert.NoError(t, err)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, 1, events[0].ID)
+		assert.Empty(t, events[0].MovieTitle)
+	}
+
+	events, err = repo.Search(models.EventFilter{Query: "e_n"})
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+
+	events, err = repo.Search(models.EventFilter{Types: []models.MovieEventType{models.EventDownloadStarted}, Limit: 1})
+	assert.NoError(t, err)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, 2, events[0].ID)
+	}
+}
+
+func TestMovieEventRepository_GetStatistics(t *testing.T) {
+	testDB, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	defer func() {
+		if err := testDB.Close(); err != nil {
+			t.Logf("Failed to close test database: %v", err)
+		}
+	}()
+	if err := testDB.InitSchema(); err != nil {
+		t.Fatalf("Failed to initialize test schema: %v", err)
+	}
+
+	repo := NewMovieEventRepository(testDB)
+	assert.NoError(t, repo.Create(1, models.EventSearchStarted, "Searching for Heat", nil))
+	assert.NoError(t, repo.Create(1, models.EventTorrentFound, "Best torrent: Heat.1995.720p",
+		models.TorrentFoundDetails{Title: "Heat.1995.720p", Score: 70}))
+	assert.NoError(t, repo.Create(1, models.EventSearchStarted, "Searching for Heat", nil))
+	assert.NoError(t, repo.Create(1, models.EventTorrentFound, "Best torrent: Heat.1995.1080p",
+		models.TorrentFoundDetails{Title: "Heat.1995.1080p", Score: 85}))
+	// Details stored before they were typed are read the same way
+	assert.NoError(t, repo.Save(&models.MovieEvent{MovieID: 2, Type: models.EventTorrentFound,
+		Message: "Best torrent: Ronin.1998", Details: json.RawMessage(`{"title":"Ronin.1998","score":90}`)}))
+
+	stats, err := repo.GetStatistics(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, stats.TotalSearches)
+	assert.Equal(t, 2, stats.TotalTorrents)
+	assert.Equal(t, 85, stats.BestTorrentScore)
+	assert.NotEmpty(t, stats.LastSearchTime)
+
+	stats, err = repo.GetStatistics(2)
+	assert.NoError(t, err)
+	assert.Equal(t, 90, stats.BestTorrentScore)
+
+	events, err := repo.GetByMovieID(2)
+	assert.NoError(t, err)
+	if assert.Len(t, events, 1) {
+		assert.Zero(t, events[0].DetailsVersion)
+	}
+}
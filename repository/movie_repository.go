@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"sync"
 	"time"
 
 	"media/database"
@@ -14,6 +15,9 @@ import (
 // MovieRepository handles database operations for movies
 type MovieRepository struct {
 	db *database.DB
+
+	mu              sync.RWMutex
+	statusListeners []func(movie models.Movie, oldStatus models.MediaStatus)
 }
 
 // NewMovieRepository creates a new movie repository
@@ -21,128 +25,36 @@ func NewMovieRepository(db *database.DB) *MovieRepository {
 	return &MovieRepository{db: db}
 }
 
-// GetAll retrieves all movies from the database
-func (r *MovieRepository) GetAll() ([]models.Movie, error) {
-	query := `
-		SELECT id, title, status, imdb_id, tmdb_id, year, genre, description, 
+// movieColumns lists the columns selected by every movie query, in scanMovie order
+const movieColumns = `id, title, status, imdb_id, tmdb_id, year, genre, description,
 			   poster, rating, runtime, director, file_path, file_size, quality,
-			   torrent_hash, created_at, updated_at
-		FROM movies
-		ORDER BY created_at DESC
-	`
-
-	rows, err := r.db.Query(query)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query movies: %w", err)
-	}
-	defer func() {
-		if err := rows.Close(); err != nil {
-			log.Printf("Failed to close rows: %v", err)
-		}
-	}()
+			   torrent_hash, download_name, indexer, root_folder_id, backdrop, created_at, updated_at`
 
-	var movies []models.Movie
-	for rows.Next() {
-		var movie models.Movie
-		var imdbID, genre, description, poster, director, filePath, quality, torrentHash sql.NullString
-		var tmdbID, year, runtime sql.NullInt64
-		var rating sql.NullFloat64
-		var fileSize sql.NullInt64
-
-		err := rows.Scan(
-			&movie.ID, &movie.Title, &movie.Status,
-			&imdbID, &tmdbID, &year, &genre, &description,
-			&poster, &rating, &runtime, &director,
-			&filePath, &fileSize, &quality, &torrentHash,
-			&movie.CreatedAt, &movie.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan movie: %w", err)
-		}
-
-		// Handle nullable fields
-		if imdbID.Valid {
-			movie.IMDBID = imdbID.String
-		}
-		if tmdbID.Valid {
-			movie.TMDBID = int(tmdbID.Int64)
-		}
-		if year.Valid {
-			movie.Year = int(year.Int64)
-		}
-		if genre.Valid {
-			movie.Genre = genre.String
-		}
-		if description.Valid {
-			movie.Description = description.String
-		}
-		if poster.Valid {
-			movie.Poster = poster.String
-		}
-		if rating.Valid {
-			movie.Rating = rating.Float64
-		}
-		if runtime.Valid {
-			movie.Runtime = int(runtime.Int64)
-		}
-		if director.Valid {
-			movie.Director = director.String
-		}
-		if filePath.Valid {
-			movie.FilePath = filePath.String
-		}
-		if fileSize.Valid {
-			movie.FileSize = fileSize.Int64
-		}
-		if quality.Valid {
-			movie.Quality = quality.String
-		}
-		if torrentHash.Valid {
-			movie.TorrentHash = torrentHash.String
-		}
-
-		movies = append(movies, movie)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating over rows: %w", err)
-	}
-
-	return movies, nil
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
 }
 
-// GetByID retrieves a movie by its ID
-func (r *MovieRepository) GetByID(id int) (*models.Movie, error) {
-	query := `
-		SELECT id, title, status, imdb_id, tmdb_id, year, genre, description, 
-			   poster, rating, runtime, director, file_path, file_size, quality,
-			   torrent_hash, created_at, updated_at
-		FROM movies
-		WHERE id = ?
-	`
-
+// scanMovie reads a single movie row selected with movieColumns
+func scanMovie(row rowScanner) (*models.Movie, error) {
 	var movie models.Movie
-	var imdbID, genre, description, poster, director, filePath, quality, torrentHash sql.NullString
-	var tmdbID, year, runtime sql.NullInt64
+	var imdbID, genre, description, poster, director, filePath, quality, torrentHash, downloadName, indexer, backdrop sql.NullString
+	var tmdbID, year, runtime, rootFolderID sql.NullInt64
 	var rating sql.NullFloat64
 	var fileSize sql.NullInt64
 
-	err := r.db.QueryRow(query, id).Scan(
+	err := row.Scan(
 		&movie.ID, &movie.Title, &movie.Status,
 		&imdbID, &tmdbID, &year, &genre, &description,
 		&poster, &rating, &runtime, &director,
-		&filePath, &fileSize, &quality, &torrentHash,
-		&movie.CreatedAt, &movie.UpdatedAt,
+		&filePath, &fileSize, &quality, &torrentHash, &downloadName, &indexer, &rootFolderID,
+		&backdrop, &movie.CreatedAt, &movie.UpdatedAt,
 	)
-
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("movie with id %d not found", id)
-		}
-		return nil, fmt.Errorf("failed to get movie: %w", err)
+		return nil, err
 	}
 
-	// Handle nullable fields (same as GetAll)
+	// Handle nullable fields
 	if imdbID.Valid {
 		movie.IMDBID = imdbID.String
 	}
@@ -182,16 +94,90 @@ func (r *MovieRepository) GetByID(id int) (*models.Movie, error) {
 	if torrentHash.Valid {
 		movie.TorrentHash = torrentHash.String
 	}
+	if downloadName.Valid {
+		movie.DownloadName = downloadName.String
+	}
+	if indexer.Valid {
+		movie.Indexer = indexer.String
+	}
+	if rootFolderID.Valid {
+		movie.RootFolderID = int(rootFolderID.Int64)
+	}
+	if backdrop.Valid {
+		movie.Backdrop = backdrop.String
+	}
 
 	return &movie, nil
 }
 
+// queryMovies runs a movie query and scans every returned row
+func (r *MovieRepository) queryMovies(query string, args ...interface{}) ([]models.Movie, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Failed to close rows: %v", err)
+		}
+	}()
+
+	var movies []models.Movie
+	for rows.Next() {
+		movie, err := scanMovie(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan movie: %w", err)
+		}
+		movies = append(movies, *movie)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over rows: %w", err)
+	}
+
+	return movies, nil
+}
+
+// GetAll retrieves all movies from the database
+func (r *MovieRepository) GetAll() ([]models.Movie, error) {
+	query := `SELECT ` + movieColumns + `
+		FROM movies
+		ORDER BY created_at DESC
+	`
+
+	movies, err := r.queryMovies(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query movies: %w", err)
+	}
+
+	return movies, nil
+}
+
+// GetByID retrieves a movie by its ID
+func (r *MovieRepository) GetByID(id int) (*models.Movie, error) {
+	query := `SELECT ` + movieColumns + `
+		FROM movies
+		WHERE id = ?
+	`
+
+	movie, err := scanMovie(r.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("movie with id %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get movie: %w", err)
+	}
+
+	return movie, nil
+}
+
 // Create inserts a new movie into the database
 func (r *MovieRepository) Create(movie *models.Movie) error {
 	query := `
 		INSERT INTO movies (title, status, imdb_id, tmdb_id, year, genre, description,
-							poster, rating, runtime, director, file_path, file_size, quality, torrent_hash)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+							poster, rating, runtime, director, file_path, file_size, quality, torrent_hash,
+							download_name, indexer, root_folder_id, backdrop)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	movie.CreatedAt = time.Now()
@@ -202,7 +188,8 @@ func (r *MovieRepository) Create(movie *models.Movie) error {
 		nullInt(movie.Year), nullString(movie.Genre), nullString(movie.Description),
 		nullString(movie.Poster), nullFloat64(movie.Rating), nullInt(movie.Runtime),
 		nullString(movie.Director), nullString(movie.FilePath), nullInt64(movie.FileSize),
-		nullString(movie.Quality), nullString(movie.TorrentHash),
+		nullString(movie.Quality), nullString(movie.TorrentHash), nullString(movie.DownloadName),
+		nullString(movie.Indexer), nullInt(movie.RootFolderID), nullString(movie.Backdrop),
 	)
 
 	if err != nil {
@@ -224,27 +211,54 @@ func (r *MovieRepository) Update(movie *models.Movie) error {
 		UPDATE movies 
 		SET title = ?, status = ?, imdb_id = ?, tmdb_id = ?, year = ?, genre = ?, description = ?,
 			poster = ?, rating = ?, runtime = ?, director = ?, file_path = ?, file_size = ?, quality = ?,
-			torrent_hash = ?, updated_at = ?
+			torrent_hash = ?, download_name = ?, indexer = ?, root_folder_id = ?, backdrop = ?, updated_at = ?
 		WHERE id = ?
 	`
 
 	movie.UpdatedAt = time.Now()
 
+	r.mu.RLock()
+	listeners := r.statusListeners
+	r.mu.RUnlock()
+
+	var oldStatus models.MediaStatus
+	if len(listeners) > 0 {
+		if err := r.db.QueryRow(`SELECT status FROM movies WHERE id = ?`, movie.ID).Scan(&oldStatus); err != nil && err != sql.ErrNoRows {
+			return fmt.Errorf("failed to get movie status: %w", err)
+		}
+	}
+
 	_, err := r.db.Exec(query,
 		movie.Title, movie.Status, nullString(movie.IMDBID), nullInt(movie.TMDBID),
 		nullInt(movie.Year), nullString(movie.Genre), nullString(movie.Description),
 		nullString(movie.Poster), nullFloat64(movie.Rating), nullInt(movie.Runtime),
 		nullString(movie.Director), nullString(movie.FilePath), nullInt64(movie.FileSize),
-		nullString(movie.Quality), nullString(movie.TorrentHash), movie.UpdatedAt, movie.ID,
+		nullString(movie.Quality), nullString(movie.TorrentHash),
+		nullString(movie.DownloadName), nullString(movie.Indexer), nullInt(movie.RootFolderID),
+		nullString(movie.Backdrop), movie.UpdatedAt, movie.ID,
 	)
 
 	if err != nil {
 		return fmt.Errorf("failed to update movie: %w", err)
 	}
 
+	if oldStatus != "" && oldStatus != movie.Status {
+		for _, listener := range listeners {
+			listener(*movie, oldStatus)
+		}
+	}
+
 	return nil
 }
 
+// AddStatusListener registers a function called whenever Update changes a
+// movie's status. Listeners run on the caller's goroutine.
+func (r *MovieRepository) AddStatusListener(listener func(movie models.Movie, oldStatus models.MediaStatus)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.statusListeners = append(r.statusListeners, listener)
+}
+
 // Delete removes a movie from the database
 func (r *MovieRepository) Delete(id int) error {
 	query := `DELETE FROM movies WHERE id = ?`
@@ -268,91 +282,16 @@ func (r *MovieRepository) Delete(id int) error {
 
 // GetByStatus retrieves all movies with a specific status
 func (r *MovieRepository) GetByStatus(status models.MediaStatus) ([]models.Movie, error) {
-	query := `
-		SELECT id, title, status, imdb_id, tmdb_id, year, genre, description, 
-			   poster, rating, runtime, director, file_path, file_size, quality,
-			   torrent_hash, created_at, updated_at
+	query := `SELECT ` + movieColumns + `
 		FROM movies
 		WHERE status = ?
 		ORDER BY created_at DESC
 	`
 
-	rows, err := r.db.Query(query, status)
+	movies, err := r.queryMovies(query, status)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query movies by status: %w", err)
 	}
-	defer func() {
-		if err := rows.Close(); err != nil {
-			log.Printf("Failed to close rows: %v", err)
-		}
-	}()
-
-	var movies []models.Movie
-	for rows.Next() {
-		var movie models.Movie
-		var imdbID, genre, description, poster, director, filePath, quality, torrentHash sql.NullString
-		var tmdbID, year, runtime sql.NullInt64
-		var rating sql.NullFloat64
-		var fileSize sql.NullInt64
-
-		err := rows.Scan(
-			&movie.ID, &movie.Title, &movie.Status,
-			&imdbID, &tmdbID, &year, &genre, &description,
-			&poster, &rating, &runtime, &director,
-			&filePath, &fileSize, &quality, &torrentHash,
-			&movie.CreatedAt, &movie.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan movie: %w", err)
-		}
-
-		// Handle nullable fields
-		if imdbID.Valid {
-			movie.IMDBID = imdbID.String
-		}
-		if tmdbID.Valid {
-			movie.TMDBID = int(tmdbID.Int64)
-		}
-		if year.Valid {
-			movie.Year = int(year.Int64)
-		}
-		if genre.Valid {
-			movie.Genre = genre.String
-		}
-		if description.Valid {
-			movie.Description = description.String
-		}
-		if poster.Valid {
-			movie.Poster = poster.String
-		}
-		if rating.Valid {
-			movie.Rating = rating.Float64
-		}
-		if runtime.Valid {
-			movie.Runtime = int(runtime.Int64)
-		}
-		if director.Valid {
-			movie.Director = director.String
-		}
-		if filePath.Valid {
-			movie.FilePath = filePath.String
-		}
-		if fileSize.Valid {
-			movie.FileSize = fileSize.Int64
-		}
-		if quality.Valid {
-			movie.Quality = quality.String
-		}
-		if torrentHash.Valid {
-			movie.TorrentHash = torrentHash.String
-		}
-
-		movies = append(movies, movie)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating over rows: %w", err)
-	}
 
 	return movies, nil
 }
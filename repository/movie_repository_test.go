@@ -196,6 +196,7 @@ func TestMovieRepository_Delete_ComplexMovie(t *testing.T) {
 		Genre:       "Action/Adventure",
 		Description: "A complex movie with all fields",
 		Poster:      "https://example.com/poster.jpg",
+		Backdrop:    "https://example.com/backdrop.jpg",
 		Rating:      9.0,
 		Runtime:     150,
 		Director:    "Famous Director",
@@ -213,6 +214,7 @@ func TestMovieRepository_Delete_ComplexMovie(t *testing.T) {
 	assert.Equal(t, movie.Title, retrievedMovie.Title)
 	assert.Equal(t, movie.IMDBID, retrievedMovie.IMDBID)
 	assert.Equal(t, movie.FileSize, retrievedMovie.FileSize)
+	assert.Equal(t, movie.Backdrop, retrievedMovie.Backdrop)
 
 	// Delete the complex movie
 	err = repo.Delete(movie.ID)
@@ -364,4 +366,27 @@ func TestMovieRepository_Delete_EdgeCaseIDs(t *testing.T) {
 			assert.Contains(t, err.Error(), "not found")
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestMovieRepository_StatusListener(t *testing.T) {
+	repo, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	movie, err := createTestMovieForRepo(repo, "Heat")
+	assert.NoError(t, err)
+
+	var changes []string
+	repo.AddStatusListener(func(movie models.Movie, oldStatus models.MediaStatus) {
+		changes = append(changes, movie.Title+" "+string(oldStatus)+" -> "+string(movie.Status))
+	})
+
+	// Only updates that change the status are reported
+	movie.Quality = "1080p"
+	assert.NoError(t, repo.Update(movie))
+	movie.Status = models.StatusSearching
+	assert.NoError(t, repo.Update(movie))
+	movie.Status = models.StatusDownloading
+	assert.NoError(t, repo.Update(movie))
+
+	assert.Equal(t, []string{"Heat wanted -> searching", "Heat searching -> downloading"}, changes)
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"media/database"
+	"media/models"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// ErrRemotePathMappingExists is returned by Create when the download client
+// already has a mapping for the remote path
+var ErrRemotePathMappingExists = errors.New("remote path mapping already exists")
+
+// RemotePathMappingRepository handles database operations for remote path mappings
+type RemotePathMappingRepository struct {
+	db *database.DB
+}
+
+// NewRemotePathMappingRepository creates a new remote path mapping repository
+func NewRemotePathMappingRepository(db *database.DB) *RemotePathMappingRepository {
+	return &RemotePathMappingRepository{db: db}
+}
+
+// Create adds a remote path mapping
+func (r *RemotePathMappingRepository) Create(mapping *models.RemotePathMapping) error {
+	mapping.CreatedAt = time.Now()
+
+	result, err := r.db.Exec(
+		`INSERT INTO remote_path_mappings (client, remote_path, local_path, created_at) VALUES (?, ?, ?, ?)`,
+		mapping.Client, mapping.RemotePath, mapping.LocalPath, mapping.CreatedAt,
+	)
+	if err != nil {
+		var sqliteErr sqlite3.Error
+		if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+			return ErrRemotePathMappingExists
+		}
+		return fmt.Errorf("failed to create remote path mapping: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	mapping.ID = int(id)
+	return nil
+}
+
+// GetAll returns every remote path mapping
+func (r *RemotePathMappingRepository) GetAll() ([]models.RemotePathMapping, error) {
+	return r.query(`SELECT id, client, remote_path, local_path, created_at
+		FROM remote_path_mappings ORDER BY client, remote_path`)
+}
+
+// GetByClient returns the mappings configured for one download client
+func (r *RemotePathMappingRepository) GetByClient(client string) ([]models.RemotePathMapping, error) {
+	return r.query(`SELECT id, client, remote_path, local_path, created_at
+		FROM remote_path_mappings WHERE client = ? ORDER BY remote_path`, client)
+}
+
+// query runs a mapping query and scans every returned row
+func (r *RemotePathMappingRepository) query(query string, args ...interface{}) ([]models.RemotePathMapping, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query remote path mappings: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Failed to close rows: %v", err)
+		}
+	}()
+
+	mappings := []models.RemotePathMapping{}
+	for rows.Next() {
+		var mapping models.RemotePathMapping
+		if err := rows.Scan(&mapping.ID, &mapping.Client, &mapping.RemotePath, &mapping.LocalPath,
+			&mapping.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan remote path mapping: %w", err)
+		}
+		mappings = append(mappings, mapping)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating remote path mappings: %w", err)
+	}
+
+	return mappings, nil
+}
+
+// Delete removes a remote path mapping
+func (r *RemotePathMappingRepository) Delete(id int) error {
+	result, err := r.db.Exec(`DELETE FROM remote_path_mappings WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete remote path mapping: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("remote path mapping with id %d not found: %w", id, sql.ErrNoRows)
+	}
+
+	return nil
+}
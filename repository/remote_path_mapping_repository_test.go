@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"media/database"
+	"media/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRemotePathMappingRepository(t *testing.T) {
+	testDB, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	defer func() {
+		if err := testDB.Close(); err != nil {
+			t.Logf("Failed to close test database: %v", err)
+		}
+	}()
+	if err := testDB.InitSchema(); err != nil {
+		t.Fatalf("Failed to initialize test schema: %v", err)
+	}
+
+	repo := NewRemotePathMappingRepository(testDB)
+
+	mappings, err := repo.GetAll()
+	assert.NoError(t, err)
+	assert.Empty(t, mappings)
+
+	downloads := &models.RemotePathMapping{Client: models.DownloadClientQBittorrent,
+		RemotePath: "/downloads", LocalPath: "/mnt/seedbox/downloads"}
+	movies := &models.RemotePathMapping{Client: models.DownloadClientQBittorrent,
+		RemotePath: "/data/movies", LocalPath: "/mnt/seedbox/movies"}
+	assert.NoError(t, repo.Create(downloads))
+	assert.NoError(t, repo.Create(movies))
+	assert.NotZero(t, downloads.ID)
+
+	// A client maps each remote path once
+	duplicate := &models.RemotePathMapping{Client: models.DownloadClientQBittorrent,
+		RemotePath: "/downloads", LocalPath: "/elsewhere"}
+	err = repo.Create(duplicate)
+	assert.True(t, errors.Is(err, ErrRemotePathMappingExists))
+
+	mappings, err = repo.GetByClient(models.DownloadClientQBittorrent)
+	assert.NoError(t, err)
+	if assert.Len(t, mappings, 2) {
+		assert.Equal(t, "/data/movies", mappings[0].RemotePath)
+		assert.Equal(t, "/mnt/seedbox/downloads", mappings[1].LocalPath)
+	}
+
+	mappings, err = repo.GetByClient("transmission")
+	assert.NoError(t, err)
+	assert.Empty(t, mappings)
+
+	assert.NoError(t, repo.Delete(downloads.ID))
+	assert.ErrorIs(t, repo.Delete(downloads.ID), sql.ErrNoRows)
+
+	mappings, err = repo.GetAll()
+	assert.NoError(t, err)
+	assert.Len(t, mappings, 1)
+}
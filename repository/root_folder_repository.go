@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"media/database"
+	"media/models"
+)
+
+// RootFolderRepository handles database operations for library root folders
+type RootFolderRepository struct {
+	db *database.DB
+}
+
+// NewRootFolderRepository creates a new root folder repository
+func NewRootFolderRepository(db *database.DB) *RootFolderRepository {
+	return &RootFolderRepository{db: db}
+}
+
+const rootFolderColumns = `id, path, label, min_free_space, write_metadata, created_at`
+
+// scanRootFolder reads a single row selected with rootFolderColumns
+func scanRootFolder(row rowScanner) (*models.RootFolder, error) {
+	var folder models.RootFolder
+	var label sql.NullString
+	if err := row.Scan(&folder.ID, &folder.Path, &label, &folder.MinFreeSpace, &folder.WriteMetadata,
+		&folder.CreatedAt); err != nil {
+		return nil, err
+	}
+	if label.Valid {
+		folder.Label = label.String
+	}
+	return &folder, nil
+}
+
+// Create adds a root folder
+func (r *RootFolderRepository) Create(folder *models.RootFolder) error {
+	folder.CreatedAt = time.Now()
+
+	result, err := r.db.Exec(
+		`INSERT INTO root_folders (path, label, min_free_space, write_metadata, created_at) VALUES (?, ?, ?, ?, ?)`,
+		folder.Path, nullString(folder.Label), folder.MinFreeSpace, folder.WriteMetadata, folder.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create root folder: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	folder.ID = int(id)
+	return nil
+}
+
+// GetAll returns every root folder in the order they were added
+func (r *RootFolderRepository) GetAll() ([]models.RootFolder, error) {
+	rows, err := r.db.Query(`SELECT ` + rootFolderColumns + ` FROM root_folders ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query root folders: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Failed to close rows: %v", err)
+		}
+	}()
+
+	folders := []models.RootFolder{}
+	for rows.Next() {
+		folder, err := scanRootFolder(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan root folder: %w", err)
+		}
+		folders = append(folders, *folder)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating root folders: %w", err)
+	}
+
+	return folders, nil
+}
+
+// GetByID retrieves a root folder by its ID
+func (r *RootFolderRepository) GetByID(id int) (*models.RootFolder, error) {
+	folder, err := scanRootFolder(r.db.QueryRow(`SELECT `+rootFolderColumns+` FROM root_folders WHERE id = ?`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("root folder with id %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get root folder: %w", err)
+	}
+
+	return folder, nil
+}
+
+// GetDefault returns the first root folder added, or nil if there is none
+func (r *RootFolderRepository) GetDefault() (*models.RootFolder, error) {
+	folder, err := scanRootFolder(r.db.QueryRow(`SELECT ` + rootFolderColumns + ` FROM root_folders ORDER BY id LIMIT 1`))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get default root folder: %w", err)
+	}
+
+	return folder, nil
+}
+
+// Update changes the label, free space reserve and metadata setting of a root
+// folder; its path cannot be changed
+func (r *RootFolderRepository) Update(folder *models.RootFolder) error {
+	result, err := r.db.Exec(
+		`UPDATE root_folders SET label = ?, min_free_space = ?, write_metadata = ? WHERE id = ?`,
+		nullString(folder.Label), folder.MinFreeSpace, folder.WriteMetadata, folder.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update root folder: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("root folder with id %d not found", folder.ID)
+	}
+
+	return nil
+}
+
+// Delete removes a root folder. Movies in it fall back to the default folder.
+func (r *RootFolderRepository) Delete(id int) error {
+	result, err := r.db.Exec(`DELETE FROM root_folders WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete root folder: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("root folder with id %d not found", id)
+	}
+
+	if _, err := r.db.Exec(`UPDATE movies SET root_folder_id = NULL WHERE root_folder_id = ?`, id); err != nil {
+		return fmt.Errorf("failed to detach movies from root folder: %w", err)
+	}
+
+	return nil
+}
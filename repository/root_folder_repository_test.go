@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"testing"
+
+	"media/database"
+	"media/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRootFolderRepository(t *testing.T) {
+	testDB, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	defer func() {
+		if err := testDB.Close(); err != nil {
+			t.Logf("Failed to close test database: %v", err)
+		}
+	}()
+	if err := testDB.InitSchema(); err != nil {
+		t.Fatalf("Failed to initialize test schema: %v", err)
+	}
+
+	repo := NewRootFolderRepository(testDB)
+	movieRepo := NewMovieRepository(testDB)
+
+	// No folders configured yet
+	folder, err := repo.GetDefault()
+	assert.NoError(t, err)
+	assert.Nil(t, folder)
+
+	first := &models.RootFolder{Path: "/media/movies", Label: "Movies", MinFreeSpace: 10 << 30}
+	second := &models.RootFolder{Path: "/media/4k"}
+	assert.NoError(t, repo.Create(first))
+	assert.NoError(t, repo.Create(second))
+
+	folder, err = repo.GetDefault()
+	assert.NoError(t, err)
+	assert.Equal(t, first.ID, folder.ID)
+	assert.Equal(t, int64(10<<30), folder.MinFreeSpace)
+
+	folders, err := repo.GetAll()
+	assert.NoError(t, err)
+	assert.Len(t, folders, 2)
+	assert.False(t, folders[0].WriteMetadata)
+
+	first.Label = "Films"
+	first.WriteMetadata = true
+	assert.NoError(t, repo.Update(first))
+	folder, err = repo.GetByID(first.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Films", folder.Label)
+	assert.True(t, folder.WriteMetadata)
+	assert.Equal(t, "/media/movies", folder.Path)
+
+	// Deleting a folder detaches its movies
+	movie := &models.Movie{Title: "Test Movie", Status: models.StatusWanted, RootFolderID: second.ID}
+	assert.NoError(t, movieRepo.Create(movie))
+
+	assert.NoError(t, repo.Delete(second.ID))
+	assert.Error(t, repo.Delete(second.ID))
+	_, err = repo.GetByID(second.ID)
+	assert.Error(t, err)
+
+	updated, err := movieRepo.GetByID(movie.ID)
+	assert.NoError(t, err)
+	assert.Zero(t, updated.RootFolderID)
+}
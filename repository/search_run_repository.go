@@ -0,0 +1,224 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"media/database"
+	"media/models"
+)
+
+// SearchRunRepository handles database operations for search timings
+type SearchRunRepository struct {
+	db *database.DB
+}
+
+// NewSearchRunRepository creates a new search run repository
+func NewSearchRunRepository(db *database.DB) *SearchRunRepository {
+	return &SearchRunRepository{db: db}
+}
+
+// Create stores a search run together with its indexer timings
+func (r *SearchRunRepository) Create(run *models.SearchRun) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin search run: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			log.Printf("Failed to roll back search run: %v", err)
+		}
+	}()
+
+	result, err := tx.Exec(`
+		INSERT INTO search_runs (movie_id, started_at, finished_at, duration_ms, queries, requests, results, found)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, run.MovieID, run.StartedAt.UTC(), run.FinishedAt.UTC(), run.DurationMs, run.Queries, run.Requests,
+		run.Results, run.Found)
+	if err != nil {
+		return fmt.Errorf("failed to create search run: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	for _, timing := range run.Indexers {
+		_, err := tx.Exec(`
+			INSERT INTO search_indexer_timings (search_run_id, indexer, requests, results, errors, elapsed_ms)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, id, timing.Indexer, timing.Requests, timing.Results, timing.Errors, timing.ElapsedMs)
+		if err != nil {
+			return fmt.Errorf("failed to create indexer timing: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit search run: %w", err)
+	}
+
+	run.ID = int(id)
+	return nil
+}
+
+// GetTimeStats summarizes how long the searches of a movie took
+func (r *SearchRunRepository) GetTimeStats(movieID int) (models.SearchTimeStats, error) {
+	return searchTimeStats(r.db, movieID)
+}
+
+// GetStats summarizes the searches of every movie started since a time, and
+// the latency of each indexer per request
+func (r *SearchRunRepository) GetStats(since time.Time) (*models.SearchStats, error) {
+	stats := &models.SearchStats{Since: since, Indexers: []models.IndexerSearchStats{}}
+
+	durations, err := queryDurations(r.db, `SELECT duration_ms FROM search_runs WHERE started_at >= ?`, since.UTC())
+	if err != nil {
+		return nil, err
+	}
+	stats.Runs = summarizeDurations(durations)
+
+	rows, err := r.db.Query(`
+		SELECT t.indexer, t.requests, t.results, t.errors, t.elapsed_ms
+		FROM search_indexer_timings t
+		JOIN search_runs s ON s.id = t.search_run_id
+		WHERE s.started_at >= ?
+		ORDER BY t.indexer
+	`, since.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query indexer timings: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Failed to close rows: %v", err)
+		}
+	}()
+
+	// An indexer's latencies are its average per request in each run; its
+	// count is the number of requests
+	var current *models.IndexerSearchStats
+	var requests int
+	var latencies []int64
+	flush := func() {
+		if current == nil {
+			return
+		}
+		current.SearchTimeStats = summarizeDurations(latencies)
+		current.Count = requests
+		stats.Indexers = append(stats.Indexers, *current)
+	}
+	for rows.Next() {
+		var timing models.SearchIndexerTiming
+		if err := rows.Scan(&timing.Indexer, &timing.Requests, &timing.Results, &timing.Errors, &timing.ElapsedMs); err != nil {
+			return nil, fmt.Errorf("failed to scan indexer timing: %w", err)
+		}
+		if current == nil || current.Indexer != timing.Indexer {
+			flush()
+			current = &models.IndexerSearchStats{Indexer: timing.Indexer}
+			requests = 0
+			latencies = nil
+		}
+		requests += timing.Requests
+		current.Results += timing.Results
+		current.Errors += timing.Errors
+		if timing.Requests > 0 {
+			latencies = append(latencies, timing.ElapsedMs/int64(timing.Requests))
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating indexer timings: %w", err)
+	}
+	flush()
+
+	return stats, nil
+}
+
+// DeleteOlderThan removes search runs started longer ago than olderThan, with
+// their indexer timings, returning how many runs were deleted
+func (r *SearchRunRepository) DeleteOlderThan(olderThan time.Duration) (int64, error) {
+	cutoff := time.Now().UTC().Add(-olderThan)
+
+	if _, err := r.db.Exec(`
+		DELETE FROM search_indexer_timings
+		WHERE search_run_id IN (SELECT id FROM search_runs WHERE started_at < ?)
+	`, cutoff); err != nil {
+		return 0, fmt.Errorf("failed to delete old indexer timings: %w", err)
+	}
+
+	result, err := r.db.Exec(`DELETE FROM search_runs WHERE started_at < ?`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete old search runs: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return deleted, nil
+}
+
+// searchTimeStats summarizes the search durations of a movie
+func searchTimeStats(db *database.DB, movieID int) (models.SearchTimeStats, error) {
+	durations, err := queryDurations(db, `SELECT duration_ms FROM search_runs WHERE movie_id = ?`, movieID)
+	if err != nil {
+		return models.SearchTimeStats{}, err
+	}
+	return summarizeDurations(durations), nil
+}
+
+// queryDurations returns the durations a query selects
+func queryDurations(db *database.DB, query string, args ...interface{}) ([]int64, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query search durations: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Failed to close rows: %v", err)
+		}
+	}()
+
+	var durations []int64
+	for rows.Next() {
+		var duration int64
+		if err := rows.Scan(&duration); err != nil {
+			return nil, fmt.Errorf("failed to scan search duration: %w", err)
+		}
+		durations = append(durations, duration)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating search durations: %w", err)
+	}
+	return durations, nil
+}
+
+// summarizeDurations computes the average and nearest-rank percentiles of
+// durations, sorting them in place
+func summarizeDurations(durations []int64) models.SearchTimeStats {
+	stats := models.SearchTimeStats{Count: len(durations)}
+	if len(durations) == 0 {
+		return stats
+	}
+
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	var total int64
+	for _, d := range durations {
+		total += d
+	}
+
+	percentile := func(p float64) int64 {
+		rank := int(math.Ceil(p / 100 * float64(len(durations))))
+		return durations[max(rank, 1)-1]
+	}
+
+	stats.AvgMs = float64(total) / float64(len(durations))
+	stats.P50Ms = percentile(50)
+	stats.P90Ms = percentile(90)
+	stats.P99Ms = percentile(99)
+	stats.MaxMs = durations[len(durations)-1]
+	return stats
+}
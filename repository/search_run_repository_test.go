@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"media/database"
+	"media/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchRunRepository(t *testing.T) {
+	testDB, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	defer func() {
+		if err := testDB.Close(); err != nil {
+			t.Logf("Failed to close test database: %v", err)
+		}
+	}()
+	testDB.SetMaxOpenConns(1)
+	if err := testDB.InitSchema(); err != nil {
+		t.Fatalf("Failed to initialize test schema: %v", err)
+	}
+
+	repo := NewSearchRunRepository(testDB)
+	now := time.Now()
+	create := func(movieID int, startedAt time.Time, durationMs, ytsMs int64) {
+		run := &models.SearchRun{MovieID: movieID, StartedAt: startedAt,
+			FinishedAt: startedAt.Add(time.Duration(durationMs) * time.Millisecond), DurationMs: durationMs,
+			Queries: 2, Requests: 2, Indexers: []models.SearchIndexerTiming{
+				{Indexer: "YTS", Requests: 2, Results: 5, ElapsedMs: 2 * ytsMs},
+			}}
+		assert.NoError(t, repo.Create(run))
+		assert.NotZero(t, run.ID)
+	}
+
+	for i, duration := range []int64{1000, 2000, 3000, 4000, 10000} {
+		create(1, now.Add(-time.Duration(i)*time.Minute), duration, duration/2)
+	}
+	create(2, now.Add(-60*24*time.Hour), 60000, 30000)
+
+	movieTimes, err := repo.GetTimeStats(1)
+	assert.NoError(t, err)
+	assert.Equal(t, models.SearchTimeStats{Count: 5, AvgMs: 4000, P50Ms: 3000, P90Ms: 10000, P99Ms: 10000,
+		MaxMs: 10000}, movieTimes)
+
+	// Per-movie search times are part of the movie's statistics
+	movieStats, err := NewMovieEventRepository(testDB).GetStatistics(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 4.0, movieStats.AvgSearchTime)
+	assert.Equal(t, 3.0, movieStats.P50SearchTime)
+	assert.Equal(t, 10.0, movieStats.P90SearchTime)
+
+	// Library-wide stats only cover runs since the given time
+	stats, err := repo.GetStats(now.Add(-30 * 24 * time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 5, stats.Runs.Count)
+	if assert.Len(t, stats.Indexers, 1) {
+		assert.Equal(t, "YTS", stats.Indexers[0].Indexer)
+		assert.Equal(t, 10, stats.Indexers[0].Count)
+		assert.Equal(t, 25, stats.Indexers[0].Results)
+		assert.Equal(t, int64(1500), stats.Indexers[0].P50Ms)
+	}
+
+	deleted, err := repo.DeleteOlderThan(30 * 24 * time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+	stats, err = repo.GetStats(time.Time{})
+	assert.NoError(t, err)
+	assert.Equal(t, 5, stats.Runs.Count)
+	assert.Equal(t, 10, stats.Indexers[0].Count)
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"media/database"
+	"media/models"
+)
+
+// SeedingPolicyRepository handles database operations for seeding policies
+type SeedingPolicyRepository struct {
+	db *database.DB
+}
+
+// NewSeedingPolicyRepository creates a new seeding policy repository
+func NewSeedingPolicyRepository(db *database.DB) *SeedingPolicyRepository {
+	return &SeedingPolicyRepository{db: db}
+}
+
+const seedingPolicyColumns = `id, indexer, min_ratio, min_seed_time, created_at, updated_at`
+
+// scanSeedingPolicy reads a single row selected with seedingPolicyColumns
+func scanSeedingPolicy(row rowScanner) (*models.SeedingPolicy, error) {
+	var policy models.SeedingPolicy
+	err := row.Scan(&policy.ID, &policy.Indexer, &policy.MinRatio, &policy.MinSeedTime,
+		&policy.CreatedAt, &policy.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// Save creates the policy for policy.Indexer, or replaces the existing one
+func (r *SeedingPolicyRepository) Save(policy *models.SeedingPolicy) error {
+	policy.Indexer = strings.ToLower(strings.TrimSpace(policy.Indexer))
+	now := time.Now()
+
+	_, err := r.db.Exec(`
+		INSERT INTO seeding_policies (indexer, min_ratio, min_seed_time, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(indexer) DO UPDATE SET
+			min_ratio = excluded.min_ratio,
+			min_seed_time = excluded.min_seed_time,
+			updated_at = excluded.updated_at
+	`, policy.Indexer, policy.MinRatio, policy.MinSeedTime, now, now)
+	if err != nil {
+		return fmt.Errorf("failed to save seeding policy: %w", err)
+	}
+
+	saved, err := scanSeedingPolicy(r.db.QueryRow(
+		`SELECT `+seedingPolicyColumns+` FROM seeding_policies WHERE indexer = ?`, policy.Indexer))
+	if err != nil {
+		return fmt.Errorf("failed to reload seeding policy: %w", err)
+	}
+
+	*policy = *saved
+	return nil
+}
+
+// GetAll returns every seeding policy, the global one first
+func (r *SeedingPolicyRepository) GetAll() ([]models.SeedingPolicy, error) {
+	rows, err := r.db.Query(`SELECT ` + seedingPolicyColumns + ` FROM seeding_policies ORDER BY indexer`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query seeding policies: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Failed to close rows: %v", err)
+		}
+	}()
+
+	policies := []models.SeedingPolicy{}
+	for rows.Next() {
+		policy, err := scanSeedingPolicy(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan seeding policy: %w", err)
+		}
+		policies = append(policies, *policy)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating seeding policies: %w", err)
+	}
+
+	return policies, nil
+}
+
+// GetForIndexer returns the policy that applies to torrents from indexer:
+// its own policy if there is one, otherwise the global policy. It returns nil
+// if neither exists.
+func (r *SeedingPolicyRepository) GetForIndexer(indexer string) (*models.SeedingPolicy, error) {
+	policy, err := scanSeedingPolicy(r.db.QueryRow(`
+		SELECT `+seedingPolicyColumns+` FROM seeding_policies
+		WHERE indexer = ? OR indexer = ''
+		ORDER BY indexer DESC
+		LIMIT 1
+	`, strings.ToLower(strings.TrimSpace(indexer))))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get seeding policy: %w", err)
+	}
+
+	return policy, nil
+}
+
+// Delete removes a seeding policy
+func (r *SeedingPolicyRepository) Delete(id int) error {
+	result, err := r.db.Exec(`DELETE FROM seeding_policies WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete seeding policy: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("seeding policy with id %d not found", id)
+	}
+
+	return nil
+}
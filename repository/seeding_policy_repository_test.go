@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"testing"
+
+	"media/database"
+	"media/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupTestSeedingPolicyRepo(t *testing.T) (*SeedingPolicyRepository, func()) {
+	testDB, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+
+	if err := testDB.InitSchema(); err != nil {
+		t.Fatalf("Failed to initialize test schema: %v", err)
+	}
+
+	cleanup := func() {
+		if err := testDB.Close(); err != nil {
+			t.Logf("Failed to close test database: %v", err)
+		}
+	}
+
+	return NewSeedingPolicyRepository(testDB), cleanup
+}
+
+func TestSeedingPolicyRepository_GetForIndexer(t *testing.T) {
+	repo, cleanup := setupTestSeedingPolicyRepo(t)
+	defer cleanup()
+
+	// No policies: keep seeding forever
+	policy, err := repo.GetForIndexer("iptorrents")
+	assert.NoError(t, err)
+	assert.Nil(t, policy)
+
+	global := &models.SeedingPolicy{MinRatio: 1.0}
+	assert.NoError(t, repo.Save(global))
+	private := &models.SeedingPolicy{Indexer: "IPTorrents", MinRatio: 2.0, MinSeedTime: 14 * 24 * 60}
+	assert.NoError(t, repo.Save(private))
+
+	policy, err = repo.GetForIndexer("iptorrents")
+	assert.NoError(t, err)
+	assert.Equal(t, private.ID, policy.ID)
+	assert.Equal(t, 2.0, policy.MinRatio)
+
+	// Other indexers fall back to the global policy
+	policy, err = repo.GetForIndexer("1337x")
+	assert.NoError(t, err)
+	assert.Equal(t, global.ID, policy.ID)
+}
+
+func TestSeedingPolicyRepository_SaveReplaces(t *testing.T) {
+	repo, cleanup := setupTestSeedingPolicyRepo(t)
+	defer cleanup()
+
+	first := &models.SeedingPolicy{Indexer: "yts", MinRatio: 1.0}
+	assert.NoError(t, repo.Save(first))
+	second := &models.SeedingPolicy{Indexer: "YTS", MinSeedTime: 60}
+	assert.NoError(t, repo.Save(second))
+
+	assert.Equal(t, first.ID, second.ID)
+	policies, err := repo.GetAll()
+	assert.NoError(t, err)
+	assert.Len(t, policies, 1)
+	assert.Equal(t, 0.0, policies[0].MinRatio)
+	assert.Equal(t, 60, policies[0].MinSeedTime)
+
+	assert.NoError(t, repo.Delete(first.ID))
+	assert.Error(t, repo.Delete(first.ID))
+}
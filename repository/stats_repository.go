@@ -0,0 +1,226 @@
+package repository
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"media/database"
+	"media/models"
+)
+
+// StatsRepository computes library-wide statistics from the movies and their
+// events
+type StatsRepository struct {
+	db *database.DB
+}
+
+// NewStatsRepository creates a new stats repository
+func NewStatsRepository(db *database.DB) *StatsRepository {
+	return &StatsRepository{db: db}
+}
+
+// GetLibraryStats summarizes the library as it is now, and its grabs, imports
+// and searches since a time
+func (r *StatsRepository) GetLibraryStats(since time.Time) (*models.LibraryStats, error) {
+	stats := &models.LibraryStats{Since: since, ByStatus: make(map[models.MediaStatus]int)}
+	sinceArg := since.UTC().Format("2006-01-02 15:04:05")
+
+	statuses, err := r.queryCounts(`SELECT status, COUNT(*) FROM movies GROUP BY status`)
+	if err != nil {
+		return nil, err
+	}
+	for _, status := range statuses {
+		stats.ByStatus[models.MediaStatus(status.Name)] = status.Count
+		stats.Movies += status.Count
+	}
+
+	var averageSize float64
+	err = r.db.QueryRow(`
+		SELECT COALESCE(SUM(file_size), 0), COALESCE(AVG(file_size), 0)
+		FROM movies
+		WHERE file_size > 0
+	`).Scan(&stats.TotalSize, &averageSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum file sizes: %w", err)
+	}
+	stats.AverageSize = int64(averageSize)
+
+	stats.Qualities, err = r.queryCounts(`
+		SELECT quality, COUNT(*) FROM movies
+		WHERE quality IS NOT NULL AND quality != ''
+		GROUP BY quality
+		ORDER BY COUNT(*) DESC, quality
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	// A movie has up to three genres in one column, so each combination is
+	// counted once for every genre in it
+	genreLists, err := r.queryCounts(`
+		SELECT genre, COUNT(*) FROM movies
+		WHERE genre IS NOT NULL AND genre != ''
+		GROUP BY genre
+	`)
+	if err != nil {
+		return nil, err
+	}
+	stats.Genres = splitGenreCounts(genreLists)
+
+	stats.Decades, err = r.queryCounts(`
+		SELECT (year / 10 * 10) || 's', COUNT(*) FROM movies
+		WHERE year > 0
+		GROUP BY year / 10
+		ORDER BY year / 10
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	// A grab is a movie's change to downloading once a release was sent to
+	// the download client
+	stats.GrabsPerDay, err = r.queryCounts(`
+		SELECT date(created_at), COUNT(*) FROM movie_events
+		WHERE type = ? AND json_extract(details, '$.new_status') = ? AND created_at >= ?
+		GROUP BY date(created_at)
+		ORDER BY date(created_at)
+	`, models.EventStatusChanged, models.StatusDownloading, sinceArg)
+	if err != nil {
+		return nil, err
+	}
+
+	if stats.Indexers, err = r.indexerGrabs(sinceArg); err != nil {
+		return nil, err
+	}
+
+	// Movies adopted by a library scan were ready as soon as they were added
+	err = r.db.QueryRow(`
+		WITH durations AS (
+			SELECT (julianday(MIN(e.created_at)) - julianday(m.created_at)) * 86400 AS seconds
+			FROM movie_events e
+			JOIN movies m ON m.id = e.movie_id
+			WHERE e.type = ? AND e.created_at >= ?
+				AND COALESCE(json_extract(e.details, '$.adopted'), 0) = 0
+			GROUP BY e.movie_id
+		)
+		SELECT COALESCE(AVG(seconds), 0) FROM (
+			SELECT seconds FROM durations
+			ORDER BY seconds
+			LIMIT 2 - (SELECT COUNT(*) FROM durations) % 2
+			OFFSET ((SELECT COUNT(*) FROM durations) - 1) / 2
+		)
+	`, models.EventImported, sinceArg).Scan(&stats.MedianTimeToReady)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get median time to ready: %w", err)
+	}
+
+	// A failed search without an error found nothing worth downloading
+	var notFound int
+	err = r.db.QueryRow(`
+		SELECT COUNT(*),
+			COALESCE(SUM(type = ? AND json_extract(details, '$.error') IS NULL), 0)
+		FROM movie_events
+		WHERE type IN (?, ?) AND created_at >= ?
+	`, models.EventSearchFailed, models.EventSearchCompleted, models.EventSearchFailed, sinceArg).
+		Scan(&stats.Searches, &notFound)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count searches: %w", err)
+	}
+	if stats.Searches > 0 {
+		stats.NotFoundRate = float64(notFound) / float64(stats.Searches)
+	}
+
+	return stats, nil
+}
+
+// indexerGrabs counts the grabs from each indexer since a time, and how many
+// of the grabbed movies were imported afterwards
+func (r *StatsRepository) indexerGrabs(since string) ([]models.IndexerGrabStats, error) {
+	rows, err := r.db.Query(`
+		SELECT COALESCE(json_extract(e.details, '$.indexer'), '') AS indexer,
+			COUNT(*),
+			SUM(EXISTS (
+				SELECT 1 FROM movie_events i
+				WHERE i.movie_id = e.movie_id AND i.type = ? AND i.id > e.id
+			))
+		FROM movie_events e
+		WHERE e.type = ? AND json_extract(e.details, '$.new_status') = ? AND e.created_at >= ?
+		GROUP BY indexer
+		ORDER BY COUNT(*) DESC, indexer
+	`, models.EventImported, models.EventStatusChanged, models.StatusDownloading, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query indexer grabs: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Failed to close rows: %v", err)
+		}
+	}()
+
+	indexers := []models.IndexerGrabStats{}
+	for rows.Next() {
+		var indexer models.IndexerGrabStats
+		if err := rows.Scan(&indexer.Indexer, &indexer.Grabs, &indexer.Imported); err != nil {
+			return nil, fmt.Errorf("failed to scan indexer grabs: %w", err)
+		}
+		indexers = append(indexers, indexer)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating indexer grabs: %w", err)
+	}
+	return indexers, nil
+}
+
+// queryCounts returns the name and count pairs a query selects
+func (r *StatsRepository) queryCounts(query string, args ...interface{}) ([]models.StatsCount, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query counts: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Failed to close rows: %v", err)
+		}
+	}()
+
+	counts := []models.StatsCount{}
+	for rows.Next() {
+		var count models.StatsCount
+		if err := rows.Scan(&count.Name, &count.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan count: %w", err)
+		}
+		counts = append(counts, count)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating counts: %w", err)
+	}
+	return counts, nil
+}
+
+// splitGenreCounts turns counts of genre lists ("Action, Drama") into counts
+// of each genre, most common first
+func splitGenreCounts(lists []models.StatsCount) []models.StatsCount {
+	totals := make(map[string]int)
+	for _, list := range lists {
+		for _, genre := range strings.Split(list.Name, ",") {
+			if genre = strings.TrimSpace(genre); genre != "" {
+				totals[genre] += list.Count
+			}
+		}
+	}
+
+	genres := make([]models.StatsCount, 0, len(totals))
+	for name, count := range totals {
+		genres = append(genres, models.StatsCount{Name: name, Count: count})
+	}
+	sort.Slice(genres, func(i, j int) bool {
+		if genres[i].Count != genres[j].Count {
+			return genres[i].Count > genres[j].Count
+		}
+		return genres[i].Name < genres[j].Name
+	})
+	return genres
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"media/database"
+	"media/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatsRepository_GetLibraryStats(t *testing.T) {
+	testDB, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	defer func() {
+		if err := testDB.Close(); err != nil {
+			t.Logf("Failed to close test database: %v", err)
+		}
+	}()
+	testDB.SetMaxOpenConns(1)
+	if err := testDB.InitSchema(); err != nil {
+		t.Fatalf("Failed to initialize test schema: %v", err)
+	}
+
+	movieRepo := NewMovieRepository(testDB)
+	movieEventRepo := NewMovieEventRepository(testDB)
+	repo := NewStatsRepository(testDB)
+
+	movies := []*models.Movie{
+		{Title: "Heat", Year: 1995, Genre: "Action, Crime", Status: models.StatusReady, FileSize: 4000, Quality: "1080p"},
+		{Title: "Ronin", Year: 1998, Genre: "Action", Status: models.StatusReady, FileSize: 2000, Quality: "1080p"},
+		{Title: "Dune", Year: 2021, Genre: "Science Fiction", Status: models.StatusReady, FileSize: 9000, Quality: "2160p"},
+		{Title: "Thief", Year: 1981, Genre: "Action, Crime", Status: models.StatusNotFound},
+	}
+	for _, movie := range movies {
+		assert.NoError(t, movieRepo.Create(movie))
+	}
+	heat, ronin, dune, thief := movies[0], movies[1], movies[2], movies[3]
+
+	now := time.Now()
+	_, err = testDB.Exec(`UPDATE movies SET created_at = ? WHERE id IN (?, ?, ?)`,
+		now.Add(-10*time.Hour).UTC().Format("2006-01-02 15:04:05"), heat.ID, ronin.ID, dune.ID)
+	assert.NoError(t, err)
+
+	save := func(movieID int, eventType models.MovieEventType, at time.Time, details models.EventDetails) {
+		event := &models.MovieEvent{MovieID: movieID, Type: eventType, CreatedAt: at,
+			DetailsVersion: models.EventDetailsVersion}
+		if details != nil {
+			encoded, err := json.Marshal(details)
+			assert.NoError(t, err)
+			event.Details = encoded
+		}
+		assert.NoError(t, movieEventRepo.Save(event))
+	}
+	grab := func(movieID int, indexer string, at time.Time) {
+		save(movieID, models.EventStatusChanged, at, models.StatusChangeDetails{OldStatus: models.StatusSearching,
+			NewStatus: models.StatusDownloading, Indexer: indexer})
+	}
+
+	// Heat and Ronin were grabbed and imported, Dune was grabbed and adopted
+	// by a library scan, Thief was never found
+	grab(heat.ID, "YTS", now.Add(-9*time.Hour))
+	save(heat.ID, models.EventImported, now.Add(-8*time.Hour), models.ImportDetails{Path: "/movies/Heat.mkv"})
+	grab(ronin.ID, "YTS", now.Add(-9*time.Hour))
+	save(ronin.ID, models.EventImported, now.Add(-6*time.Hour), models.ImportDetails{Path: "/movies/Ronin.mkv"})
+	grab(dune.ID, "1337x", now.Add(-9*time.Hour))
+	save(dune.ID, models.EventImported, now.Add(-time.Hour),
+		models.ImportDetails{Path: "/movies/Dune.mkv", Adopted: true})
+	grab(thief.ID, "1337x", now.AddDate(0, 0, -40))
+	save(heat.ID, models.EventSearchCompleted, now.Add(-9*time.Hour), models.SearchDetails{TorrentCount: 3})
+	save(thief.ID, models.EventSearchFailed, now.Add(-2*time.Hour), models.SearchDetails{Reason: "no_results"})
+	save(thief.ID, models.EventSearchFailed, now.Add(-time.Hour), models.SearchDetails{Error: "jackett is down"})
+	save(ronin.ID, models.EventSearchCompleted, now.Add(-9*time.Hour), models.SearchDetails{TorrentCount: 1})
+
+	stats, err := repo.GetLibraryStats(now.AddDate(0, 0, -7))
+	assert.NoError(t, err)
+
+	assert.Equal(t, 4, stats.Movies)
+	assert.Equal(t, map[models.MediaStatus]int{models.StatusReady: 3, models.StatusNotFound: 1}, stats.ByStatus)
+	assert.Equal(t, int64(15000), stats.TotalSize)
+	assert.Equal(t, int64(5000), stats.AverageSize)
+	assert.Equal(t, []models.StatsCount{{Name: "1080p", Count: 2}, {Name: "2160p", Count: 1}}, stats.Qualities)
+	assert.Equal(t, []models.StatsCount{{Name: "Action", Count: 3}, {Name: "Crime", Count: 2},
+		{Name: "Science Fiction", Count: 1}}, stats.Genres)
+	assert.Equal(t, []models.StatsCount{{Name: "1980s", Count: 1}, {Name: "1990s", Count: 2},
+		{Name: "2020s", Count: 1}}, stats.Decades)
+
+	// Only grabs in the last week count
+	total := 0
+	for _, day := range stats.GrabsPerDay {
+		total += day.Count
+	}
+	assert.Equal(t, 3, total)
+	assert.Equal(t, []models.IndexerGrabStats{{Indexer: "YTS", Grabs: 2, Imported: 2},
+		{Indexer: "1337x", Grabs: 1, Imported: 1}}, stats.Indexers)
+
+	// Heat took 2h and Ronin 4h to be ready; Dune was adopted
+	assert.InDelta(t, 3*time.Hour.Seconds(), stats.MedianTimeToReady, 2)
+
+	// Searches that failed with an error did not fail to find anything
+	assert.Equal(t, 4, stats.Searches)
+	assert.Equal(t, 0.25, stats.NotFoundRate)
+
+	// Nothing happened in the future, but the library is as it is now
+	stats, err = repo.GetLibraryStats(now.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 4, stats.Movies)
+	assert.Empty(t, stats.GrabsPerDay)
+	assert.Empty(t, stats.Indexers)
+	assert.Zero(t, stats.MedianTimeToReady)
+	assert.Zero(t, stats.Searches)
+	assert.Zero(t, stats.NotFoundRate)
+}
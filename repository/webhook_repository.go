@@ -0,0 +1,321 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"media/database"
+	"media/models"
+)
+
+// WebhookRepository handles database operations for webhooks and their deliveries
+type WebhookRepository struct {
+	db *database.DB
+}
+
+// NewWebhookRepository creates a new webhook repository
+func NewWebhookRepository(db *database.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+const webhookColumns = `id, name, url, secret, events, enabled, created_at`
+
+// scanWebhook reads a single row selected with webhookColumns
+func scanWebhook(row rowScanner) (*models.Webhook, error) {
+	var webhook models.Webhook
+	var events sql.NullString
+	err := row.Scan(&webhook.ID, &webhook.Name, &webhook.URL, &webhook.Secret, &events, &webhook.Enabled,
+		&webhook.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	webhook.Events = []models.MovieEventType{}
+	if events.String != "" {
+		if err := json.Unmarshal([]byte(events.String), &webhook.Events); err != nil {
+			return nil, fmt.Errorf("failed to decode webhook events: %w", err)
+		}
+	}
+	return &webhook, nil
+}
+
+// encodeEvents stores the event filter of a webhook, with NULL for every event
+func encodeEvents(events []models.MovieEventType) (sql.NullString, error) {
+	if len(events) == 0 {
+		return sql.NullString{}, nil
+	}
+	data, err := json.Marshal(events)
+	if err != nil {
+		return sql.NullString{}, fmt.Errorf("failed to encode webhook events: %w", err)
+	}
+	return nullString(string(data)), nil
+}
+
+// Create adds a webhook
+func (r *WebhookRepository) Create(webhook *models.Webhook) error {
+	events, err := encodeEvents(webhook.Events)
+	if err != nil {
+		return err
+	}
+	webhook.CreatedAt = time.Now()
+
+	result, err := r.db.Exec(`
+		INSERT INTO webhooks (name, url, secret, events, enabled, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, webhook.Name, webhook.URL, webhook.Secret, events, webhook.Enabled, webhook.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	webhook.ID = int(id)
+	return nil
+}
+
+// GetAll returns every webhook
+func (r *WebhookRepository) GetAll() ([]models.Webhook, error) {
+	return r.query(`SELECT ` + webhookColumns + ` FROM webhooks ORDER BY id`)
+}
+
+// GetEnabled returns the webhooks that are sent events
+func (r *WebhookRepository) GetEnabled() ([]models.Webhook, error) {
+	return r.query(`SELECT ` + webhookColumns + ` FROM webhooks WHERE enabled = 1 ORDER BY id`)
+}
+
+// GetByID retrieves a webhook by its ID
+func (r *WebhookRepository) GetByID(id int) (*models.Webhook, error) {
+	webhook, err := scanWebhook(r.db.QueryRow(`SELECT `+webhookColumns+` FROM webhooks WHERE id = ?`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("webhook with id %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get webhook: %w", err)
+	}
+
+	return webhook, nil
+}
+
+// Update changes a webhook
+func (r *WebhookRepository) Update(webhook *models.Webhook) error {
+	events, err := encodeEvents(webhook.Events)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.db.Exec(`
+		UPDATE webhooks SET name = ?, url = ?, secret = ?, events = ?, enabled = ? WHERE id = ?
+	`, webhook.Name, webhook.URL, webhook.Secret, events, webhook.Enabled, webhook.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("webhook with id %d not found", webhook.ID)
+	}
+
+	return nil
+}
+
+// Delete removes a webhook and its delivery log
+func (r *WebhookRepository) Delete(id int) error {
+	result, err := r.db.Exec(`DELETE FROM webhooks WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("webhook with id %d not found: %w", id, sql.ErrNoRows)
+	}
+
+	// Foreign keys are not enforced, so the cascade is done here
+	if _, err := r.db.Exec(`DELETE FROM webhook_deliveries WHERE webhook_id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete webhook deliveries: %w", err)
+	}
+
+	return nil
+}
+
+// query runs a webhook query and scans every returned row
+func (r *WebhookRepository) query(query string, args ...interface{}) ([]models.Webhook, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhooks: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Failed to close rows: %v", err)
+		}
+	}()
+
+	webhooks := []models.Webhook{}
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook: %w", err)
+		}
+		webhooks = append(webhooks, *webhook)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhooks: %w", err)
+	}
+
+	return webhooks, nil
+}
+
+const deliveryColumns = `id, webhook_id, event_id, event_type, payload, status, attempts, response_status,
+	response_body, error, next_attempt_at, delivered_at, created_at`
+
+// scanDelivery reads a single row selected with deliveryColumns
+func scanDelivery(row rowScanner) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	var eventID, responseStatus sql.NullInt64
+	var responseBody, deliveryError sql.NullString
+	var nextAttemptAt, deliveredAt sql.NullTime
+	err := row.Scan(&delivery.ID, &delivery.WebhookID, &eventID, &delivery.EventType, &delivery.Payload,
+		&delivery.Status, &delivery.Attempts, &responseStatus, &responseBody, &deliveryError, &nextAttemptAt,
+		&deliveredAt, &delivery.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	delivery.EventID = int(eventID.Int64)
+	delivery.ResponseStatus = int(responseStatus.Int64)
+	delivery.ResponseBody = responseBody.String
+	delivery.Error = deliveryError.String
+	if nextAttemptAt.Valid {
+		delivery.NextAttemptAt = &nextAttemptAt.Time
+	}
+	if deliveredAt.Valid {
+		delivery.DeliveredAt = &deliveredAt.Time
+	}
+	return &delivery, nil
+}
+
+// nullTime stores an optional time in UTC, so stored times compare as text,
+// with NULL for nil
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
+
+// CreateDelivery records a delivery before it is first attempted
+func (r *WebhookRepository) CreateDelivery(delivery *models.WebhookDelivery) error {
+	delivery.CreatedAt = time.Now()
+	if delivery.Status == "" {
+		delivery.Status = models.DeliveryPending
+	}
+
+	result, err := r.db.Exec(`
+		INSERT INTO webhook_deliveries (webhook_id, event_id, event_type, payload, status, attempts,
+			next_attempt_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, delivery.WebhookID, nullInt(delivery.EventID), delivery.EventType, delivery.Payload, delivery.Status,
+		delivery.Attempts, nullTime(delivery.NextAttemptAt), delivery.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook delivery: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	delivery.ID = int(id)
+	return nil
+}
+
+// UpdateDelivery saves the outcome of a delivery attempt
+func (r *WebhookRepository) UpdateDelivery(delivery *models.WebhookDelivery) error {
+	result, err := r.db.Exec(`
+		UPDATE webhook_deliveries
+		SET status = ?, attempts = ?, response_status = ?, response_body = ?, error = ?,
+			next_attempt_at = ?, delivered_at = ?
+		WHERE id = ?
+	`, delivery.Status, delivery.Attempts, nullInt(delivery.ResponseStatus), nullString(delivery.ResponseBody),
+		nullString(delivery.Error), nullTime(delivery.NextAttemptAt), nullTime(delivery.DeliveredAt), delivery.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook delivery: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("webhook delivery with id %d not found", delivery.ID)
+	}
+
+	return nil
+}
+
+// GetDelivery retrieves a delivery by its ID
+func (r *WebhookRepository) GetDelivery(id int) (*models.WebhookDelivery, error) {
+	delivery, err := scanDelivery(r.db.QueryRow(`SELECT `+deliveryColumns+` FROM webhook_deliveries WHERE id = ?`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("webhook delivery with id %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+
+	return delivery, nil
+}
+
+// GetDeliveries returns the latest deliveries of a webhook, newest first
+func (r *WebhookRepository) GetDeliveries(webhookID, limit int) ([]models.WebhookDelivery, error) {
+	return r.queryDeliveries(`SELECT `+deliveryColumns+` FROM webhook_deliveries
+		WHERE webhook_id = ? ORDER BY id DESC LIMIT ?`, webhookID, limit)
+}
+
+// GetDueDeliveries returns the pending deliveries whose next attempt is due
+func (r *WebhookRepository) GetDueDeliveries(now time.Time) ([]models.WebhookDelivery, error) {
+	return r.queryDeliveries(`SELECT `+deliveryColumns+` FROM webhook_deliveries
+		WHERE status = ? AND next_attempt_at <= ? ORDER BY id`, models.DeliveryPending, now.UTC())
+}
+
+// queryDeliveries runs a delivery query and scans every returned row
+func (r *WebhookRepository) queryDeliveries(query string, args ...interface{}) ([]models.WebhookDelivery, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhook deliveries: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Failed to close rows: %v", err)
+		}
+	}()
+
+	deliveries := []models.WebhookDelivery{}
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, *delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
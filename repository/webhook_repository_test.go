@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"media/database"
+	"media/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookRepository(t *testing.T) {
+	testDB, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	defer func() {
+		if err := testDB.Close(); err != nil {
+			t.Logf("Failed to close test database: %v", err)
+		}
+	}()
+	if err := testDB.InitSchema(); err != nil {
+		t.Fatalf("Failed to initialize test schema: %v", err)
+	}
+
+	repo := NewWebhookRepository(testDB)
+
+	ready := &models.Webhook{Name: "Ready", URL: "https://example.com/ready", Secret: "s3cret",
+		Events: []models.MovieEventType{models.EventImported}, Enabled: true}
+	everything := &models.Webhook{Name: "Everything", URL: "https://example.com/all", Secret: "other"}
+	assert.NoError(t, repo.Create(ready))
+	assert.NoError(t, repo.Create(everything))
+
+	enabled, err := repo.GetEnabled()
+	assert.NoError(t, err)
+	if assert.Len(t, enabled, 1) {
+		assert.Equal(t, []models.MovieEventType{models.EventImported}, enabled[0].Events)
+		assert.True(t, enabled[0].Wants(models.EventImported))
+		assert.False(t, enabled[0].Wants(models.EventDownloadStarted))
+	}
+
+	everything.Enabled = true
+	assert.NoError(t, repo.Update(everything))
+	saved, err := repo.GetByID(everything.ID)
+	assert.NoError(t, err)
+	assert.True(t, saved.Enabled)
+	assert.Equal(t, []models.MovieEventType{}, saved.Events)
+	assert.True(t, saved.Wants(models.EventDownloadStarted))
+
+	// Deliveries are listed newest first and found once their retry is due
+	now := time.Now()
+	due := now.Add(-time.Minute)
+	later := now.Add(time.Hour)
+	first := &models.WebhookDelivery{WebhookID: ready.ID, EventID: 1, EventType: models.EventImported,
+		Payload: `{"event":"imported"}`, NextAttemptAt: &due}
+	second := &models.WebhookDelivery{WebhookID: ready.ID, EventID: 2, EventType: models.EventImported,
+		Payload: `{"event":"imported"}`, NextAttemptAt: &later}
+	assert.NoError(t, repo.CreateDelivery(first))
+	assert.NoError(t, repo.CreateDelivery(second))
+
+	deliveries, err := repo.GetDeliveries(ready.ID, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, deliveries, 2) {
+		assert.Equal(t, second.ID, deliveries[0].ID)
+		assert.Equal(t, models.DeliveryPending, deliveries[0].Status)
+	}
+
+	dueDeliveries, err := repo.GetDueDeliveries(now)
+	assert.NoError(t, err)
+	if assert.Len(t, dueDeliveries, 1) {
+		assert.Equal(t, first.ID, dueDeliveries[0].ID)
+	}
+
+	first.Status = models.DeliverySucceeded
+	first.Attempts = 2
+	first.ResponseStatus = 204
+	first.NextAttemptAt = nil
+	first.DeliveredAt = &now
+	assert.NoError(t, repo.UpdateDelivery(first))
+	stored, err := repo.GetDelivery(first.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.DeliverySucceeded, stored.Status)
+	assert.Equal(t, 2, stored.Attempts)
+	assert.Equal(t, 204, stored.ResponseStatus)
+	assert.Nil(t, stored.NextAttemptAt)
+	assert.NotNil(t, stored.DeliveredAt)
+
+	dueDeliveries, err = repo.GetDueDeliveries(now)
+	assert.NoError(t, err)
+	assert.Empty(t, dueDeliveries)
+
+	// Deleting a webhook removes its delivery log
+	assert.NoError(t, repo.Delete(ready.ID))
+	assert.Error(t, repo.Delete(ready.ID))
+	_, err = repo.GetDelivery(first.ID)
+	assert.Error(t, err)
+	assert.Error(t, repo.Update(ready))
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"media/jobs"
+	"media/models"
+	"media/services"
+
+	"github.com/gorilla/mux"
+)
+
+// withDiskSpace fills in the free and total space of a root folder
+func withDiskSpace(folder *models.RootFolder) {
+	free, total, err := services.DiskSpace(folder.Path)
+	if err != nil {
+		log.Printf("Failed to get disk space for %s: %v", folder.Path, err)
+		return
+	}
+	folder.FreeSpace = free
+	folder.TotalSpace = total
+	folder.Accessible = true
+}
+
+func (app *App) getRootFoldersHandler(w http.ResponseWriter, _ *http.Request) {
+	folders, err := app.rootFolderRepo.GetAll()
+	if err != nil {
+		log.Printf("Error getting root folders: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	for i := range folders {
+		withDiskSpace(&folders[i])
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(folders); err != nil {
+		log.Printf("Error encoding root folders: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (app *App) createRootFolderHandler(w http.ResponseWriter, r *http.Request) {
+	var folder models.RootFolder
+	if err := json.NewDecoder(r.Body).Decode(&folder); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	folder.Path = strings.TrimSpace(folder.Path)
+	if folder.Path == "" || !filepath.IsAbs(folder.Path) {
+		http.Error(w, "An absolute path is required", http.StatusBadRequest)
+		return
+	}
+	folder.Path = filepath.Clean(folder.Path)
+
+	if info, err := os.Stat(folder.Path); err != nil || !info.IsDir() {
+		http.Error(w, "Path does not exist or is not a directory", http.StatusBadRequest)
+		return
+	}
+	if folder.MinFreeSpace < 0 {
+		http.Error(w, "min_free_space must not be negative", http.StatusBadRequest)
+		return
+	}
+
+	folders, err := app.rootFolderRepo.GetAll()
+	if err != nil {
+		log.Printf("Error getting root folders: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	for _, existing := range folders {
+		if existing.Path == folder.Path {
+			http.Error(w, "Root folder already exists", http.StatusConflict)
+			return
+		}
+	}
+
+	if err := app.rootFolderRepo.Create(&folder); err != nil {
+		log.Printf("Error creating root folder: %v", err)
+		http.Error(w, "Failed to create root folder", http.StatusInternalServerError)
+		return
+	}
+	withDiskSpace(&folder)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(folder); err != nil {
+		log.Printf("Error encoding root folder response: %v", err)
+	}
+}
+
+// updateRootFolderHandler changes the label, free space reserve and metadata
+// setting of a root folder
+func (app *App) updateRootFolderHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid root folder ID", http.StatusBadRequest)
+		return
+	}
+
+	folder, err := app.rootFolderRepo.GetByID(id)
+	if err != nil {
+		http.Error(w, "Root folder not found", http.StatusNotFound)
+		return
+	}
+
+	var request struct {
+		Label         *string `json:"label"`
+		MinFreeSpace  *int64  `json:"min_free_space"`
+		WriteMetadata *bool   `json:"write_metadata"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if request.Label != nil {
+		folder.Label = strings.TrimSpace(*request.Label)
+	}
+	if request.MinFreeSpace != nil {
+		if *request.MinFreeSpace < 0 {
+			http.Error(w, "min_free_space must not be negative", http.StatusBadRequest)
+			return
+		}
+		folder.MinFreeSpace = *request.MinFreeSpace
+	}
+	if request.WriteMetadata != nil {
+		folder.WriteMetadata = *request.WriteMetadata
+	}
+
+	if err := app.rootFolderRepo.Update(folder); err != nil {
+		log.Printf("Error updating root folder: %v", err)
+		http.Error(w, "Failed to update root folder", http.StatusInternalServerError)
+		return
+	}
+	withDiskSpace(folder)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(folder); err != nil {
+		log.Printf("Error encoding root folder: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (app *App) deleteRootFolderHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid root folder ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := app.rootFolderRepo.Delete(id); err != nil {
+		http.Error(w, "Root folder not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// setMovieRootFolderHandler moves a movie to another root folder for future imports
+func (app *App) setMovieRootFolderHandler(w http.ResponseWriter, r *http.Request) {
+	movieID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid movie ID", http.StatusBadRequest)
+		return
+	}
+
+	var request struct {
+		RootFolderID int `json:"root_folder_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	movie, err := app.movieRepo.GetByID(movieID)
+	if err != nil {
+		http.Error(w, "Movie not found", http.StatusNotFound)
+		return
+	}
+	if _, err := app.rootFolderRepo.GetByID(request.RootFolderID); err != nil {
+		http.Error(w, "Root folder not found", http.StatusBadRequest)
+		return
+	}
+
+	movie.RootFolderID = request.RootFolderID
+	if err := app.movieRepo.Update(movie); err != nil {
+		log.Printf("Error updating movie root folder: %v", err)
+		http.Error(w, "Failed to update movie", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(movie); err != nil {
+		log.Printf("Error encoding movie: %v", err)
+	}
+}
+
+// scanRootFolderHandler starts adopting the movies already in a root folder.
+// The scan runs in the background; its progress is available from
+// getRootFolderScanHandler.
+func (app *App) scanRootFolderHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid root folder ID", http.StatusBadRequest)
+		return
+	}
+
+	folder, err := app.rootFolderRepo.GetByID(id)
+	if err != nil {
+		http.Error(w, "Root folder not found", http.StatusNotFound)
+		return
+	}
+
+	scan, err := app.libraryScanner.Start(folder.ID)
+	if errors.Is(err, jobs.ErrScanInProgress) {
+		http.Error(w, "A scan of this root folder is already running", http.StatusConflict)
+		return
+	}
+	app.jobManager.RunTask("library scan", func(ctx context.Context) error {
+		return app.libraryScanner.Run(ctx, folder)
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(scan); err != nil {
+		log.Printf("Error encoding library scan: %v", err)
+	}
+}
+
+// getRootFolderScanHandler returns the progress or outcome of the latest scan
+func (app *App) getRootFolderScanHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid root folder ID", http.StatusBadRequest)
+		return
+	}
+
+	scan, ok := app.libraryScanner.LastScan(id)
+	if !ok {
+		http.Error(w, "No scan has run for this root folder", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(scan); err != nil {
+		log.Printf("Error encoding library scan: %v", err)
+	}
+}
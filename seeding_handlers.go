@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"media/models"
+
+	"github.com/gorilla/mux"
+)
+
+func (app *App) getSeedingPoliciesHandler(w http.ResponseWriter, _ *http.Request) {
+	policies, err := app.seedingPolicyRepo.GetAll()
+	if err != nil {
+		log.Printf("Error getting seeding policies: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(policies); err != nil {
+		log.Printf("Error encoding seeding policies: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// saveSeedingPolicyHandler creates or replaces the policy for the given
+// indexer; an empty indexer sets the global policy
+func (app *App) saveSeedingPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	var policy models.SeedingPolicy
+	if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if policy.MinRatio < 0 || policy.MinSeedTime < 0 {
+		http.Error(w, "min_ratio and min_seed_time must not be negative", http.StatusBadRequest)
+		return
+	}
+	if policy.MinRatio == 0 && policy.MinSeedTime == 0 {
+		http.Error(w, "At least one of min_ratio or min_seed_time is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := app.seedingPolicyRepo.Save(&policy); err != nil {
+		log.Printf("Error saving seeding policy: %v", err)
+		http.Error(w, "Failed to save seeding policy", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(policy); err != nil {
+		log.Printf("Error encoding seeding policy: %v", err)
+	}
+}
+
+func (app *App) deleteSeedingPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid seeding policy ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := app.seedingPolicyRepo.Delete(id); err != nil {
+		http.Error(w, "Seeding policy not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
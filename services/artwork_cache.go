@@ -0,0 +1,270 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	_ "image/png" // TMDB serves some artwork as PNG
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"media/models"
+)
+
+// ArtworkKind names the kinds of artwork cached for a movie
+type ArtworkKind string
+
+const (
+	ArtworkPoster   ArtworkKind = "poster"
+	ArtworkBackdrop ArtworkKind = "backdrop"
+)
+
+// ErrArtworkNotCached is returned for artwork that has not been downloaded
+var ErrArtworkNotCached = errors.New("artwork not cached")
+
+// ArtworkWidths are the widths cached artwork is resized to. Requested sizes
+// are rounded up to one of them, so each image has a bounded number of copies.
+var ArtworkWidths = []int{92, 154, 185, 342, 500, 780, 1280}
+
+// maxArtworkSize caps the size of a downloaded image
+const maxArtworkSize = 20 << 20
+
+// maxArtworkPixels caps the dimensions of an image, since decoding allocates
+// memory for every pixel however small the file is. TMDB's largest originals
+// are well under it.
+const maxArtworkPixels = 40_000_000
+
+// ArtworkCache keeps local copies of movie posters and backdrops, so they can
+// be served without TMDB being reachable. Originals are stored as downloaded;
+// resized copies are made as JPEG on first request.
+type ArtworkCache struct {
+	dir    string
+	client *http.Client
+	mu     sync.Mutex // serializes resizing and replacing originals
+}
+
+// NewArtworkCache creates an artwork cache storing images under dir
+func NewArtworkCache(dir string) *ArtworkCache {
+	return &ArtworkCache{
+		dir:    dir,
+		client: &http.Client{Timeout: time.Minute},
+	}
+}
+
+// CacheMovie downloads the poster and backdrop of a movie, replacing the
+// cached ones
+func (c *ArtworkCache) CacheMovie(movie *models.Movie) error {
+	var errs []error
+	for kind, url := range map[ArtworkKind]string{ArtworkPoster: movie.Poster, ArtworkBackdrop: movie.Backdrop} {
+		if url == "" {
+			continue
+		}
+		if err := c.Store(movie.ID, kind, url); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Store downloads the image at url as the original artwork of a movie and
+// drops its resized copies
+func (c *ArtworkCache) Store(movieID int, kind ArtworkKind, url string) error {
+	resp, err := c.client.Get(url)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Printf("Failed to close response body: %v", err)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned status %d", url, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxArtworkSize+1))
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	if len(data) > maxArtworkSize {
+		return fmt.Errorf("%s is larger than %d bytes", url, maxArtworkSize)
+	}
+	if err := checkArtworkDimensions(bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("%s: %w", url, err)
+	}
+
+	dir := c.movieDir(movieID)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create artwork folder: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	resized, _ := filepath.Glob(filepath.Join(dir, string(kind)+"-*.jpg"))
+	for _, path := range resized {
+		if err := os.Remove(path); err != nil {
+			log.Printf("Failed to remove %s: %v", path, err)
+		}
+	}
+	return WriteFileAtomic(filepath.Join(dir, string(kind)), data)
+}
+
+// Path returns the cached artwork of a movie at the given width, resizing the
+// original on first use. A width of 0, or one at least as wide as the
+// original, returns the original.
+func (c *ArtworkCache) Path(movieID int, kind ArtworkKind, width int) (string, error) {
+	original := filepath.Join(c.movieDir(movieID), string(kind))
+	if _, err := os.Stat(original); errors.Is(err, os.ErrNotExist) {
+		return "", ErrArtworkNotCached
+	} else if err != nil {
+		return "", err
+	}
+	if width == 0 {
+		return original, nil
+	}
+
+	resizedPath := filepath.Join(c.movieDir(movieID), fmt.Sprintf("%s-%d.jpg", kind, width))
+	if _, err := os.Stat(resizedPath); err == nil {
+		return resizedPath, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Another request may have made it while this one waited
+	if _, err := os.Stat(resizedPath); err == nil {
+		return resizedPath, nil
+	}
+
+	file, err := os.Open(original) // #nosec G304 -- path is built from the movie ID
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.Printf("Failed to close %s: %v", original, err)
+		}
+	}()
+
+	// Originals cached before the pixel cap may be too large to decode
+	if err := checkArtworkDimensions(file); err != nil {
+		return "", fmt.Errorf("%s: %w", original, err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode %s: %w", original, err)
+	}
+	if img.Bounds().Dx() <= width {
+		return original, nil
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, resizeImage(img, width), &jpeg.Options{Quality: 85}); err != nil {
+		return "", fmt.Errorf("failed to encode resized artwork: %w", err)
+	}
+	if err := WriteFileAtomic(resizedPath, buf.Bytes()); err != nil {
+		return "", err
+	}
+	return resizedPath, nil
+}
+
+// Remove deletes the cached artwork of a movie
+func (c *ArtworkCache) Remove(movieID int) error {
+	return os.RemoveAll(c.movieDir(movieID))
+}
+
+// movieDir is the folder holding a movie's artwork
+func (c *ArtworkCache) movieDir(movieID int) string {
+	return filepath.Join(c.dir, strconv.Itoa(movieID))
+}
+
+// ParseArtworkSize reads a requested artwork size, given as a width in pixels
+// ("342") or the way TMDB names sizes ("w342", "original"), and rounds it up to
+// one of ArtworkWidths. An empty size, "original" or a size wider than every
+// one of ArtworkWidths returns 0, the original.
+func ParseArtworkSize(size string) (int, error) {
+	if size == "" || size == "original" {
+		return 0, nil
+	}
+	width, err := strconv.Atoi(strings.TrimPrefix(size, "w"))
+	if err != nil || width <= 0 {
+		return 0, fmt.Errorf("invalid artwork size %q", size)
+	}
+	for _, allowed := range ArtworkWidths {
+		if width <= allowed {
+			return allowed, nil
+		}
+	}
+	return 0, nil
+}
+
+// checkArtworkDimensions reads an image's header and refuses images with more
+// than maxArtworkPixels pixels
+func checkArtworkDimensions(r io.Reader) error {
+	config, _, err := image.DecodeConfig(r)
+	if err != nil {
+		return fmt.Errorf("not an image: %w", err)
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width > maxArtworkPixels/config.Height {
+		return fmt.Errorf("image of %dx%d pixels is too large", config.Width, config.Height)
+	}
+	return nil
+}
+
+// resizeImage scales img down to width, keeping its aspect ratio, by averaging
+// the source pixels each destination pixel covers. It samples img directly
+// rather than converting it first, so it never holds a second full-size copy.
+func resizeImage(img image.Image, width int) *image.RGBA {
+	bounds := img.Bounds()
+	srcWidth, srcHeight := bounds.Dx(), bounds.Dy()
+	height := int(math.Max(1, math.Round(float64(srcHeight)*float64(width)/float64(srcWidth))))
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0, y1 := y*srcHeight/height, (y+1)*srcHeight/height
+		if y1 == y0 {
+			y1++
+		}
+		for x := 0; x < width; x++ {
+			x0, x1 := x*srcWidth/width, (x+1)*srcWidth/width
+			if x1 == x0 {
+				x1++
+			}
+
+			// Colors are 16-bit alpha-premultiplied, as image.RGBA stores them in 8 bits
+			var sum [4]int
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					r, g, b, a := img.At(bounds.Min.X+sx, bounds.Min.Y+sy).RGBA()
+					sum[0] += int(r >> 8)
+					sum[1] += int(g >> 8)
+					sum[2] += int(b >> 8)
+					sum[3] += int(a >> 8)
+				}
+			}
+
+			count := (x1 - x0) * (y1 - y0)
+			offset := dst.PixOffset(x, y)
+			for i := range sum {
+				dst.Pix[offset+i] = uint8((sum[i] + count/2) / count)
+			}
+		}
+	}
+	return dst
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"media/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testPoster(t *testing.T, width, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 200, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+// hugePNG returns a tiny PNG whose header claims width x height pixels
+func hugePNG(t *testing.T, width, height uint32) []byte {
+	data := testPoster(t, 1, 1)
+	// The IHDR chunk follows the 8-byte signature: length, type, data, CRC
+	binary.BigEndian.PutUint32(data[16:20], width)
+	binary.BigEndian.PutUint32(data[20:24], height)
+	binary.BigEndian.PutUint32(data[29:33], crc32.ChecksumIEEE(data[12:29]))
+	return data
+}
+
+func TestArtworkCache(t *testing.T) {
+	poster := testPoster(t, 600, 900)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/poster.png":
+			_, _ = w.Write(poster)
+		case "/huge.png":
+			_, _ = w.Write(hugePNG(t, 30000, 30000))
+		case "/error.html":
+			_, _ = w.Write([]byte("<html>rate limited</html>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	cache := NewArtworkCache(t.TempDir())
+
+	_, err := cache.Path(1, ArtworkPoster, 0)
+	assert.ErrorIs(t, err, ErrArtworkNotCached)
+
+	movie := &models.Movie{ID: 1, Poster: server.URL + "/poster.png", Backdrop: server.URL + "/missing.jpg"}
+	assert.Error(t, cache.CacheMovie(movie), "the missing backdrop is reported")
+
+	original, err := cache.Path(1, ArtworkPoster, 0)
+	assert.NoError(t, err)
+	data, err := os.ReadFile(original)
+	assert.NoError(t, err)
+	assert.Equal(t, poster, data)
+
+	_, err = cache.Path(1, ArtworkBackdrop, 0)
+	assert.ErrorIs(t, err, ErrArtworkNotCached)
+
+	// Resized copies are JPEGs keeping the aspect ratio
+	resized, err := cache.Path(1, ArtworkPoster, 342)
+	assert.NoError(t, err)
+	file, err := os.Open(resized)
+	assert.NoError(t, err)
+	config, err := jpeg.DecodeConfig(file)
+	assert.NoError(t, file.Close())
+	assert.NoError(t, err)
+	assert.Equal(t, 342, config.Width)
+	assert.Equal(t, 513, config.Height)
+
+	// Images are never scaled up
+	path, err := cache.Path(1, ArtworkPoster, 780)
+	assert.NoError(t, err)
+	assert.Equal(t, original, path)
+
+	// Error pages are not cached as images
+	assert.Error(t, cache.Store(1, ArtworkPoster, server.URL+"/error.html"))
+	path, err = cache.Path(1, ArtworkPoster, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, original, path)
+
+	// Images too large to decode safely are refused before decoding
+	err = cache.Store(1, ArtworkPoster, server.URL+"/huge.png")
+	assert.ErrorContains(t, err, "too large")
+
+	// Storing a new original drops its resized copies
+	assert.NoError(t, cache.Store(1, ArtworkPoster, server.URL+"/poster.png"))
+	assert.NoFileExists(t, resized)
+
+	assert.NoError(t, cache.Remove(1))
+	_, err = cache.Path(1, ArtworkPoster, 0)
+	assert.ErrorIs(t, err, ErrArtworkNotCached)
+}
+
+func TestParseArtworkSize(t *testing.T) {
+	for size, expected := range map[string]int{
+		"":         0,
+		"original": 0,
+		"w342":     342,
+		"300":      342,
+		"w92":      92,
+		"1280":     1280,
+		"4000":     0,
+	} {
+		width, err := ParseArtworkSize(size)
+		assert.NoError(t, err, size)
+		assert.Equal(t, expected, width, size)
+	}
+
+	for _, size := range []string{"large", "w", "-5", "0"} {
+		_, err := ParseArtworkSize(size)
+		assert.Error(t, err, size)
+	}
+}
+
+func TestResizeImage(t *testing.T) {
+	// A 4x2 image of two solid halves averages to one pixel per half
+	img := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 4; x++ {
+			c := color.RGBA{A: 255}
+			if x >= 2 {
+				c.R = 255
+			}
+			img.Set(x, y, c)
+		}
+	}
+
+	resized := resizeImage(img, 2)
+	assert.Equal(t, image.Rect(0, 0, 2, 1), resized.Bounds())
+	assert.Equal(t, color.RGBA{A: 255}, resized.RGBAAt(0, 0))
+	assert.Equal(t, color.RGBA{R: 255, A: 255}, resized.RGBAAt(1, 0))
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"crypto/sha1" // #nosec G505 -- BitTorrent v1 info hashes are defined as SHA-1
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// maxBencodeDepth bounds nesting so malformed input cannot exhaust the stack
+const maxBencodeDepth = 64
+
+// bencodeDecoder decodes bencoded data into strings, int64s,
+// []interface{} lists and map[string]interface{} dictionaries
+type bencodeDecoder struct {
+	data []byte
+	pos  int
+	// infoStart and infoEnd record the raw span of the top-level "info" value
+	infoStart int
+	infoEnd   int
+}
+
+// DecodeBencode decodes a complete bencoded value
+func DecodeBencode(data []byte) (interface{}, error) {
+	d := &bencodeDecoder{data: data, infoStart: -1}
+	value, err := d.decode(0)
+	if err != nil {
+		return nil, err
+	}
+	if d.pos != len(d.data) {
+		return nil, fmt.Errorf("bencode: trailing data at offset %d", d.pos)
+	}
+	return value, nil
+}
+
+// TorrentInfoHash returns the lowercase hex SHA-1 of the info dictionary of a .torrent file
+func TorrentInfoHash(data []byte) (string, error) {
+	d := &bencodeDecoder{data: data, infoStart: -1}
+	value, err := d.decode(0)
+	if err != nil {
+		return "", fmt.Errorf("invalid torrent file: %w", err)
+	}
+	if _, ok := value.(map[string]interface{}); !ok {
+		return "", errors.New("invalid torrent file: top level is not a dictionary")
+	}
+	if d.infoStart < 0 {
+		return "", errors.New("invalid torrent file: missing info dictionary")
+	}
+
+	sum := sha1.Sum(data[d.infoStart:d.infoEnd]) // #nosec G401 -- required by the BitTorrent spec
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func (d *bencodeDecoder) decode(depth int) (interface{}, error) {
+	if depth > maxBencodeDepth {
+		return nil, errors.New("bencode: nesting too deep")
+	}
+	if d.pos >= len(d.data) {
+		return nil, errors.New("bencode: unexpected end of data")
+	}
+
+	switch c := d.data[d.pos]; {
+	case c == 'i':
+		return d.decodeInt()
+	case c == 'l':
+		return d.decodeList(depth)
+	case c == 'd':
+		return d.decodeDict(depth)
+	case c >= '0' && c <= '9':
+		return d.decodeString()
+	default:
+		return nil, fmt.Errorf("bencode: unexpected byte %q at offset %d", c, d.pos)
+	}
+}
+
+func (d *bencodeDecoder) decodeInt() (int64, error) {
+	end := d.indexFrom('e', d.pos+1)
+	if end < 0 {
+		return 0, errors.New("bencode: unterminated integer")
+	}
+	n, err := strconv.ParseInt(string(d.data[d.pos+1:end]), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("bencode: invalid integer at offset %d: %w", d.pos, err)
+	}
+	d.pos = end + 1
+	return n, nil
+}
+
+func (d *bencodeDecoder) decodeString() (string, error) {
+	colon := d.indexFrom(':', d.pos)
+	if colon < 0 {
+		return "", errors.New("bencode: unterminated string length")
+	}
+	length, err := strconv.Atoi(string(d.data[d.pos:colon]))
+	if err != nil || length < 0 {
+		return "", fmt.Errorf("bencode: invalid string length at offset %d", d.pos)
+	}
+	start := colon + 1
+	if length > len(d.data)-start {
+		return "", errors.New("bencode: string exceeds data")
+	}
+	d.pos = start + length
+	return string(d.data[start:d.pos]), nil
+}
+
+func (d *bencodeDecoder) decodeList(depth int) ([]interface{}, error) {
+	d.pos++ // skip 'l'
+	list := []interface{}{}
+	for {
+		if d.pos >= len(d.data) {
+			return nil, errors.New("bencode: unterminated list")
+		}
+		if d.data[d.pos] == 'e' {
+			d.pos++
+			return list, nil
+		}
+		value, err := d.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, value)
+	}
+}
+
+func (d *bencodeDecoder) decodeDict(depth int) (map[string]interface{}, error) {
+	d.pos++ // skip 'd'
+	dict := make(map[string]interface{})
+	for {
+		if d.pos >= len(d.data) {
+			return nil, errors.New("bencode: unterminated dictionary")
+		}
+		if d.data[d.pos] == 'e' {
+			d.pos++
+			return dict, nil
+		}
+		key, err := d.decodeString()
+		if err != nil {
+			return nil, fmt.Errorf("bencode: invalid dictionary key: %w", err)
+		}
+		start := d.pos
+		value, err := d.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if depth == 0 && key == "info" {
+			d.infoStart = start
+			d.infoEnd = d.pos
+		}
+		dict[key] = value
+	}
+}
+
+func (d *bencodeDecoder) indexFrom(b byte, from int) int {
+	for i := from; i < len(d.data); i++ {
+		if d.data[i] == b {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"crypto/sha1" // #nosec G505 -- matches the BitTorrent info hash definition
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeBencode(t *testing.T) {
+	value, err := DecodeBencode([]byte("d4:listli1ei-2e3:abce3:numi42e3:str5:helloe"))
+	assert.NoError(t, err)
+
+	dict, ok := value.(map[string]interface{})
+	assert.True(t, ok)
+	assert.Equal(t, int64(42), dict["num"])
+	assert.Equal(t, "hello", dict["str"])
+	assert.Equal(t, []interface{}{int64(1), int64(-2), "abc"}, dict["list"])
+}
+
+func TestDecodeBencode_Invalid(t *testing.T) {
+	cases := []string{
+		"",
+		"i42",
+		"5:abc",
+		"l1:a",
+		"d3:key",
+		"di1ei2ee",
+		"i1ei2e",
+		"x",
+	}
+	for _, input := range cases {
+		_, err := DecodeBencode([]byte(input))
+		assert.Error(t, err, input)
+	}
+}
+
+func TestTorrentInfoHash(t *testing.T) {
+	info := "d6:lengthi1024e4:name8:test.mkv12:piece lengthi16384e6:pieces20:aaaaaaaaaaaaaaaaaaaae"
+	torrent := []byte("d8:announce14:http://tracker4:info" + info + "e")
+
+	hash, err := TorrentInfoHash(torrent)
+	assert.NoError(t, err)
+
+	expected := sha1.Sum([]byte(info)) // #nosec G401 -- test fixture
+	assert.Equal(t, hex.EncodeToString(expected[:]), hash)
+}
+
+func TestTorrentInfoHash_MissingInfo(t *testing.T) {
+	_, err := TorrentInfoHash([]byte("d8:announce14:http://trackere"))
+	assert.Error(t, err)
+
+	_, err = TorrentInfoHash([]byte("li1ee"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// BlackholeService hands torrents to any client that watches a folder.
+// Magnets and .torrent files are dropped into WatchDir, and finished
+// downloads are picked up from CompletedDir.
+type BlackholeService struct {
+	WatchDir     string
+	CompletedDir string
+	Client       *http.Client
+}
+
+// NewBlackholeService creates a new blackhole download client
+func NewBlackholeService(watchDir, completedDir string) *BlackholeService {
+	return &BlackholeService{
+		WatchDir:     watchDir,
+		CompletedDir: completedDir,
+		Client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// TestConnection checks that the watch and completed folders are usable
+func (b *BlackholeService) TestConnection() error {
+	for _, dir := range []string{b.WatchDir, b.CompletedDir} {
+		info, err := os.Stat(dir)
+		if err != nil {
+			return fmt.Errorf("blackhole folder unavailable: %w", err)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("blackhole path %s is not a directory", dir)
+		}
+	}
+
+	probe, err := os.CreateTemp(b.WatchDir, ".write-test-*")
+	if err != nil {
+		return fmt.Errorf("blackhole watch folder is not writable: %w", err)
+	}
+	if err := probe.Close(); err != nil {
+		log.Printf("Failed to close blackhole probe file: %v", err)
+	}
+	if err := os.Remove(probe.Name()); err != nil {
+		log.Printf("Failed to remove blackhole probe file: %v", err)
+	}
+
+	return nil
+}
+
+// AddMagnet writes the magnet URI to a .magnet file in the watch folder and
+// returns its info hash
+func (b *BlackholeService) AddMagnet(magnetURI, name string) (string, error) {
+	hash, err := MagnetInfoHash(magnetURI)
+	if err != nil {
+		return "", fmt.Errorf("cannot determine info hash: %w", err)
+	}
+
+	log.Printf("Adding magnet to blackhole: %s", name)
+	if err := b.writeWatchFile(name+".magnet", []byte(magnetURI)); err != nil {
+		return "", err
+	}
+	return hash, nil
+}
+
+// AddTorrentFile fetches and validates the .torrent behind torrentURL and
+// writes it to the watch folder. Links that redirect to a magnet URI are
+// written as .magnet files instead. The returned metadata carries the info
+// hash and the name the finished download will have; Files is empty for magnets.
+func (b *BlackholeService) AddTorrentFile(ctx context.Context, torrentURL, name string) (*TorrentMeta, error) {
+	log.Printf("Fetching torrent file for blackhole: %s", name)
+
+	download, err := FetchTorrent(ctx, b.Client, torrentURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if download.MagnetURI != "" {
+		if displayName := MagnetDisplayName(download.MagnetURI); displayName != "" {
+			name = displayName
+		}
+		hash, err := b.AddMagnet(download.MagnetURI, name)
+		if err != nil {
+			return nil, err
+		}
+		return &TorrentMeta{InfoHash: hash, Name: name}, nil
+	}
+
+	if err := b.WriteTorrent(download.Data, name); err != nil {
+		return nil, err
+	}
+	return download.Meta, nil
+}
+
+// WriteTorrent writes .torrent content to the watch folder as "<name>.torrent"
+func (b *BlackholeService) WriteTorrent(data []byte, name string) error {
+	log.Printf("Adding torrent file to blackhole: %s", name)
+	return b.writeWatchFile(name+".torrent", data)
+}
+
+// FindCompleted looks for a finished download matching name in the completed
+// folder and returns its path, or an empty string if it is not there yet
+func (b *BlackholeService) FindCompleted(name string) (string, error) {
+	entries, err := os.ReadDir(b.CompletedDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to read completed folder: %w", err)
+	}
+
+	want := normalizeDownloadName(name)
+	if want == "" {
+		return "", nil
+	}
+
+	for _, entry := range entries {
+		entryName := entry.Name()
+		if strings.HasPrefix(entryName, ".") {
+			continue
+		}
+		matches := normalizeDownloadName(entryName) == want
+		if !matches && !entry.IsDir() {
+			// Single-file torrents complete as "<name>.<ext>"
+			stem := strings.TrimSuffix(entryName, filepath.Ext(entryName))
+			matches = normalizeDownloadName(stem) == want
+		}
+		if matches {
+			return filepath.Join(b.CompletedDir, entryName), nil
+		}
+	}
+
+	return "", nil
+}
+
+// writeWatchFile writes data under the watch folder via a hidden temp file so
+// the client never picks up a partially written torrent
+func (b *BlackholeService) writeWatchFile(fileName string, data []byte) error {
+	target := filepath.Join(b.WatchDir, SanitizeFileName(fileName))
+
+	tmp, err := os.CreateTemp(b.WatchDir, ".blackhole-*")
+	if err != nil {
+		return fmt.Errorf("failed to create watch file: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write watch file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to close watch file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to move watch file into place: %w", err)
+	}
+
+	log.Printf("Wrote %s to blackhole watch folder", target)
+	return nil
+}
+
+// SanitizeFileName replaces characters that are not safe in file names
+func SanitizeFileName(name string) string {
+	replacer := strings.NewReplacer(
+		"/", " ", "\\", " ", ":", " ", "*", " ", "?", " ",
+		"\"", " ", "<", " ", ">", " ", "|", " ",
+	)
+	name = replacer.Replace(name)
+	name = strings.Join(strings.Fields(name), " ")
+	return strings.TrimLeft(name, ".")
+}
+
+// normalizeDownloadName reduces a release name to lowercase letters and digits
+// so that "Movie.Name.2020.1080p" and "Movie Name 2020 1080p" compare equal
+func normalizeDownloadName(name string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupTestBlackhole(t *testing.T) *BlackholeService {
+	root := t.TempDir()
+	watchDir := filepath.Join(root, "watch")
+	completedDir := filepath.Join(root, "completed")
+	assert.NoError(t, os.Mkdir(watchDir, 0o755))
+	assert.NoError(t, os.Mkdir(completedDir, 0o755))
+
+	return NewBlackholeService(watchDir, completedDir)
+}
+
+func TestBlackholeService_TestConnection(t *testing.T) {
+	b := setupTestBlackhole(t)
+	assert.NoError(t, b.TestConnection())
+
+	b.CompletedDir = filepath.Join(b.CompletedDir, "missing")
+	assert.Error(t, b.TestConnection())
+}
+
+func TestBlackholeService_AddMagnet(t *testing.T) {
+	b := setupTestBlackhole(t)
+
+	magnet := "magnet:?xt=urn:btih:0123456789ABCDEF0123456789ABCDEF01234567&dn=Test.Movie.2023.1080p"
+	hash, err := b.AddMagnet(magnet, "Test.Movie.2023.1080p")
+	assert.NoError(t, err)
+	assert.Equal(t, "0123456789abcdef0123456789abcdef01234567", hash)
+
+	data, err := os.ReadFile(filepath.Join(b.WatchDir, "Test.Movie.2023.1080p.magnet"))
+	assert.NoError(t, err)
+	assert.Equal(t, magnet, string(data))
+
+	// No temp files should be left behind
+	entries, err := os.ReadDir(b.WatchDir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestBlackholeService_AddTorrentFile(t *testing.T) {
+	b := setupTestBlackhole(t)
+
+	torrent := buildTestTorrent("Some.Movie.2023", map[string]int64{"Some.Movie.2023.mkv": 1024})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(torrent)
+	}))
+	defer server.Close()
+
+	meta, err := b.AddTorrentFile(context.Background(), server.URL, "Some/Movie: 2023")
+	assert.NoError(t, err)
+	assert.Len(t, meta.InfoHash, 40)
+	assert.Equal(t, "Some.Movie.2023", meta.Name)
+
+	data, err := os.ReadFile(filepath.Join(b.WatchDir, "Some Movie 2023.torrent"))
+	assert.NoError(t, err)
+	assert.Equal(t, torrent, data)
+}
+
+func TestBlackholeService_AddTorrentFile_MagnetRedirect(t *testing.T) {
+	b := setupTestBlackhole(t)
+
+	magnet := "magnet:?xt=urn:btih:c12fe1c06bba254a9dc9f519b335aa7c1367a88a&dn=Redirected.Movie"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Location", magnet)
+		w.WriteHeader(http.StatusFound)
+	}))
+	defer server.Close()
+
+	meta, err := b.AddTorrentFile(context.Background(), server.URL, "Jackett Title")
+	assert.NoError(t, err)
+	assert.Equal(t, "c12fe1c06bba254a9dc9f519b335aa7c1367a88a", meta.InfoHash)
+	assert.Equal(t, "Redirected.Movie", meta.Name)
+
+	data, err := os.ReadFile(filepath.Join(b.WatchDir, "Redirected.Movie.magnet"))
+	assert.NoError(t, err)
+	assert.Equal(t, magnet, string(data))
+}
+
+func TestBlackholeService_AddTorrentFile_HTTPError(t *testing.T) {
+	b := setupTestBlackhole(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	_, err := b.AddTorrentFile(context.Background(), server.URL, "Missing")
+	assert.Error(t, err)
+}
+
+func TestBlackholeService_FindCompleted(t *testing.T) {
+	b := setupTestBlackhole(t)
+
+	// Nothing completed yet
+	path, err := b.FindCompleted("Test Movie 2023 1080p")
+	assert.NoError(t, err)
+	assert.Empty(t, path)
+
+	// Multi-file download completes as a directory
+	dir := filepath.Join(b.CompletedDir, "Test.Movie.2023.1080p")
+	assert.NoError(t, os.Mkdir(dir, 0o755))
+
+	path, err = b.FindCompleted("Test Movie 2023 1080p")
+	assert.NoError(t, err)
+	assert.Equal(t, dir, path)
+
+	// Single-file download completes as "<name>.<ext>"
+	file := filepath.Join(b.CompletedDir, "Other.Movie.2021.720p.mkv")
+	assert.NoError(t, os.WriteFile(file, []byte("video"), 0o644))
+
+	path, err = b.FindCompleted("Other.Movie.2021.720p")
+	assert.NoError(t, err)
+	assert.Equal(t, file, path)
+}
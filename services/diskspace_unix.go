@@ -0,0 +1,20 @@
+//go:build !windows
+
+package services
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// DiskSpace returns the free and total bytes of the filesystem holding path.
+// Free space is what an unprivileged user may still write.
+func DiskSpace(path string) (free, total int64, err error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, 0, fmt.Errorf("failed to stat filesystem of %s: %w", path, err)
+	}
+
+	blockSize := int64(stat.Bsize) //nolint:unconvert // Bsize is not int64 on every platform
+	return int64(stat.Bavail) * blockSize, int64(stat.Blocks) * blockSize, nil
+}
@@ -0,0 +1,33 @@
+//go:build windows
+
+package services
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+var procGetDiskFreeSpaceEx = syscall.NewLazyDLL("kernel32.dll").NewProc("GetDiskFreeSpaceExW")
+
+// DiskSpace returns the free and total bytes of the volume holding path.
+// Free space is what the current user may still write.
+func DiskSpace(path string) (free, total int64, err error) {
+	name, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid path %s: %w", path, err)
+	}
+
+	var freeToCaller, totalBytes, totalFree uint64
+	ret, _, callErr := procGetDiskFreeSpaceEx.Call(
+		uintptr(unsafe.Pointer(name)),
+		uintptr(unsafe.Pointer(&freeToCaller)),
+		uintptr(unsafe.Pointer(&totalBytes)),
+		uintptr(unsafe.Pointer(&totalFree)),
+	)
+	if ret == 0 {
+		return 0, 0, fmt.Errorf("failed to get disk space of %s: %w", path, callErr)
+	}
+
+	return int64(freeToCaller), int64(totalBytes), nil
+}
@@ -26,11 +26,23 @@ type JackettSearchResult struct {
 	Peers        int    `json:"Peers"`
 	InfoHash     string `json:"InfoHash"`
 	MagnetURI    string `json:"MagnetUri"`
+	Tracker      string `json:"Tracker"`
+	TrackerID    string `json:"TrackerId"`
+}
+
+// JackettIndexerStatus is how one indexer did on a search, as Jackett reports it
+type JackettIndexerStatus struct {
+	ID          string `json:"ID"`
+	Name        string `json:"Name"`
+	Results     int    `json:"Results"`
+	Error       string `json:"Error"`
+	ElapsedTime int64  `json:"ElapsedTime"` // in milliseconds
 }
 
 // JackettResponse represents the response from Jackett API
 type JackettResponse struct {
-	Results []JackettSearchResult `json:"Results"`
+	Results  []JackettSearchResult  `json:"Results"`
+	Indexers []JackettIndexerStatus `json:"Indexers"`
 }
 
 // NewJackettService creates a new Jackett service instance
@@ -43,7 +55,7 @@ func NewJackettService(baseURL, apiKey string) *JackettService {
 }
 
 // Search performs a search query on Jackett
-func (j *JackettService) Search(query string, category string) ([]JackettSearchResult, error) {
+func (j *JackettService) Search(query string, category string) (*JackettResponse, error) {
 	params := url.Values{}
 	params.Set("apikey", j.APIKey)
 	params.Set("t", "movie") // Use movie search mode for better results
@@ -74,11 +86,11 @@ func (j *JackettService) Search(query string, category string) ([]JackettSearchR
 		return nil, fmt.Errorf("failed to decode jackett response: %w", err)
 	}
 
-	return jackettResp.Results, nil
+	return &jackettResp, nil
 }
 
 // SearchMovies performs a movie-specific search with additional parameters
-func (j *JackettService) SearchMovies(title string, year int, imdbID string, tmdbID int, category string) ([]JackettSearchResult, error) {
+func (j *JackettService) SearchMovies(title string, year int, imdbID string, tmdbID int, category string) (*JackettResponse, error) {
 	params := url.Values{}
 	params.Set("apikey", j.APIKey)
 	params.Set("t", "movie")
@@ -121,5 +133,5 @@ func (j *JackettService) SearchMovies(title string, year int, imdbID string, tmd
 		return nil, fmt.Errorf("failed to decode jackett response: %w", err)
 	}
 
-	return jackettResp.Results, nil
+	return &jackettResp, nil
 }
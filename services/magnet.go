@@ -0,0 +1,58 @@
+package services
+
+import (
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// MagnetInfoHash extracts the BitTorrent v1 info hash from a magnet URI's
+// xt=urn:btih: parameter, accepting both hex and base32 encodings, and returns
+// it as lowercase hex
+func MagnetInfoHash(magnetURI string) (string, error) {
+	parsed, err := url.Parse(magnetURI)
+	if err != nil || parsed.Scheme != "magnet" {
+		return "", fmt.Errorf("not a magnet URI: %s", magnetURI)
+	}
+
+	for _, xt := range parsed.Query()["xt"] {
+		if !strings.HasPrefix(strings.ToLower(xt), "urn:btih:") {
+			continue
+		}
+		return normalizeInfoHash(xt[len("urn:btih:"):])
+	}
+
+	return "", errors.New("magnet URI has no urn:btih info hash")
+}
+
+// normalizeInfoHash converts a 40 character hex or 32 character base32 info
+// hash to lowercase hex
+func normalizeInfoHash(hash string) (string, error) {
+	switch len(hash) {
+	case 40:
+		if _, err := hex.DecodeString(hash); err != nil {
+			return "", fmt.Errorf("invalid hex info hash %q", hash)
+		}
+		return strings.ToLower(hash), nil
+	case 32:
+		raw, err := base32.StdEncoding.DecodeString(strings.ToUpper(hash))
+		if err != nil {
+			return "", fmt.Errorf("invalid base32 info hash %q", hash)
+		}
+		return hex.EncodeToString(raw), nil
+	default:
+		return "", fmt.Errorf("info hash %q has unexpected length %d", hash, len(hash))
+	}
+}
+
+// MagnetDisplayName returns the dn= parameter of a magnet URI, if present
+func MagnetDisplayName(magnetURI string) string {
+	parsed, err := url.Parse(magnetURI)
+	if err != nil || parsed.Scheme != "magnet" {
+		return ""
+	}
+	return parsed.Query().Get("dn")
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMagnetInfoHash_Hex(t *testing.T) {
+	hash, err := MagnetInfoHash("magnet:?xt=urn:btih:C12FE1C06BBA254A9DC9F519B335AA7C1367A88A&dn=Test")
+	assert.NoError(t, err)
+	assert.Equal(t, "c12fe1c06bba254a9dc9f519b335aa7c1367a88a", hash)
+}
+
+func TestMagnetInfoHash_Base32(t *testing.T) {
+	// Base32 form of c12fe1c06bba254a9dc9f519b335aa7c1367a88a
+	hash, err := MagnetInfoHash("magnet:?xt=urn:btih:YEX6DQDLXISUVHOJ6UM3GNNKPQJWPKEK")
+	assert.NoError(t, err)
+	assert.Equal(t, "c12fe1c06bba254a9dc9f519b335aa7c1367a88a", hash)
+}
+
+func TestMagnetInfoHash_SkipsOtherTopics(t *testing.T) {
+	magnet := "magnet:?xt=urn:btmh:1220abcdef&xt=urn:btih:c12fe1c06bba254a9dc9f519b335aa7c1367a88a"
+	hash, err := MagnetInfoHash(magnet)
+	assert.NoError(t, err)
+	assert.Equal(t, "c12fe1c06bba254a9dc9f519b335aa7c1367a88a", hash)
+}
+
+func TestMagnetInfoHash_Invalid(t *testing.T) {
+	cases := []string{
+		"http://example.com/file.torrent",
+		"magnet:?dn=NoHash",
+		"magnet:?xt=urn:btih:tooshort",
+		"magnet:?xt=urn:btih:ZZ2FE1C06BBA254A9DC9F519B335AA7C1367A88A",
+	}
+	for _, magnet := range cases {
+		_, err := MagnetInfoHash(magnet)
+		assert.Error(t, err, magnet)
+	}
+}
+
+func TestMagnetDisplayName(t *testing.T) {
+	assert.Equal(t, "Test Movie 2023",
+		MagnetDisplayName("magnet:?xt=urn:btih:abc&dn=Test+Movie+2023"))
+	assert.Empty(t, MagnetDisplayName("magnet:?xt=urn:btih:abc"))
+	assert.Empty(t, MagnetDisplayName("http://example.com/file.torrent"))
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"media/models"
+)
+
+// ErrUnknownContainer is returned for files that are neither Matroska nor MP4
+var ErrUnknownContainer = errors.New("unsupported media container")
+
+// maxHeaderElementSize caps how much of a single header element (Matroska
+// Tracks, MP4 moov) is read into memory
+const maxHeaderElementSize = 64 << 20
+
+// ProbeMediaFile reads the container headers of a Matroska (.mkv, .webm) or
+// MP4 (.mp4, .m4v, .mov) file and describes its streams. Only headers are
+// read, so probing is cheap even for large files.
+func ProbeMediaFile(path string) (*models.MediaFile, error) {
+	file, err := os.Open(path) // #nosec G304 -- probing library files is the point
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.Printf("Failed to close %s: %v", path, err)
+		}
+	}()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+
+	magic := make([]byte, 12)
+	if _, err := io.ReadFull(file, magic); err != nil {
+		return nil, fmt.Errorf("%w: file too short", ErrUnknownContainer)
+	}
+
+	var media *models.MediaFile
+	switch {
+	case bytes.Equal(magic[:4], []byte{0x1A, 0x45, 0xDF, 0xA3}):
+		media, err = probeMatroska(file, info.Size())
+	case string(magic[4:8]) == "ftyp" || string(magic[4:8]) == "moov":
+		media, err = probeMP4(file, info.Size())
+	default:
+		return nil, ErrUnknownContainer
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	media.Path = path
+	media.Size = info.Size()
+	media.Resolution = ResolutionLabel(media.Width, media.Height)
+	media.ProbedAt = time.Now()
+	if media.AudioTracks == nil {
+		media.AudioTracks = []models.AudioTrack{}
+	}
+	if media.SubtitleTracks == nil {
+		media.SubtitleTracks = []models.SubtitleTrack{}
+	}
+	return media, nil
+}
+
+// ResolutionLabel names a frame size the way release titles do ("4K",
+// "1080p", "720p", "480p"). Widths are checked as well as heights, since wide
+// movies are cropped to fewer lines: 1920x800 is still 1080p.
+func ResolutionLabel(width, height int) string {
+	switch {
+	case width == 0 && height == 0:
+		return ""
+	case width >= 3200 || height >= 1800:
+		return "4K"
+	case width >= 1700 || height >= 1000:
+		return "1080p"
+	case width >= 1100 || height >= 650:
+		return "720p"
+	default:
+		return "480p"
+	}
+}
+
+// hdrFromTransfer names the HDR format signalled by an ITU-T H.273 transfer
+// characteristics value
+func hdrFromTransfer(transfer uint64) string {
+	switch transfer {
+	case 16:
+		return "HDR10"
+	case 18:
+		return "HLG"
+	default:
+		return ""
+	}
+}
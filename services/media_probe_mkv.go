@@ -0,0 +1,414 @@
+package services
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/bits"
+	"strings"
+
+	"media/models"
+)
+
+// Matroska element IDs, with their length markers, as listed in RFC 9559
+const (
+	mkvSegment                 = 0x18538067
+	mkvSeekHead                = 0x114D9B74
+	mkvSeek                    = 0x4DBB
+	mkvSeekID                  = 0x53AB
+	mkvSeekPosition            = 0x53AC
+	mkvInfo                    = 0x1549A966
+	mkvTimestampScale          = 0x2AD7B1
+	mkvDuration                = 0x4489
+	mkvTracks                  = 0x1654AE6B
+	mkvTrackEntry              = 0xAE
+	mkvTrackType               = 0x83
+	mkvFlagDefault             = 0x88
+	mkvFlagForced              = 0x55AA
+	mkvCodecID                 = 0x86
+	mkvLanguage                = 0x22B59C
+	mkvLanguageBCP47           = 0x22B59D
+	mkvVideo                   = 0xE0
+	mkvPixelWidth              = 0xB0
+	mkvPixelHeight             = 0xBA
+	mkvColour                  = 0x55B0
+	mkvTransferCharacteristics = 0x55BA
+	mkvBlockAdditionMapping    = 0x41E4
+	mkvBlockAddIDType          = 0x41E7
+	mkvAudio                   = 0xE1
+	mkvChannels                = 0x9F
+	mkvCluster                 = 0x1F43B675
+)
+
+// Matroska track types
+const (
+	mkvTrackVideo    = 1
+	mkvTrackAudio    = 2
+	mkvTrackSubtitle = 0x11
+)
+
+// Dolby Vision configuration record types in BlockAddIDType
+const (
+	mkvDolbyVisionConfig   = 0x64766343 // "dvcC"
+	mkvDolbyVisionELConfig = 0x64767643 // "dvvC"
+)
+
+// mkvCodecNames maps Matroska codec ID prefixes to short codec names
+var mkvCodecNames = []struct{ prefix, name string }{
+	{"V_MPEG4/ISO/AVC", "h264"}, {"V_MPEGH/ISO/HEVC", "hevc"}, {"V_AV1", "av1"}, {"V_VP9", "vp9"},
+	{"V_VP8", "vp8"}, {"V_MPEG4/ISO", "mpeg4"}, {"V_MPEG2", "mpeg2"}, {"V_MPEG1", "mpeg1"},
+	{"A_AAC", "aac"}, {"A_EAC3", "eac3"}, {"A_AC3", "ac3"}, {"A_DTS", "dts"}, {"A_TRUEHD", "truehd"},
+	{"A_FLAC", "flac"}, {"A_OPUS", "opus"}, {"A_VORBIS", "vorbis"}, {"A_MPEG/L3", "mp3"}, {"A_PCM", "pcm"},
+	{"S_TEXT/UTF8", "srt"}, {"S_TEXT/ASS", "ass"}, {"S_TEXT/SSA", "ass"}, {"S_TEXT/WEBVTT", "webvtt"},
+	{"S_HDMV/PGS", "pgs"}, {"S_VOBSUB", "vobsub"}, {"S_DVBSUB", "dvbsub"},
+}
+
+// ebmlHeader is the ID and size of an EBML element
+type ebmlHeader struct {
+	id        uint64
+	size      uint64
+	headerLen int
+	unknown   bool // size not known up front, as for live-written segments
+}
+
+// probeMatroska reads the Info and Tracks elements of a Matroska file
+func probeMatroska(r io.ReaderAt, fileSize int64) (*models.MediaFile, error) {
+	ebml, err := readEBMLHeaderAt(r, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	offset := int64(ebml.headerLen) + int64(ebml.size)
+	segment, err := readEBMLHeaderAt(r, offset)
+	if err != nil {
+		return nil, err
+	}
+	if segment.id != mkvSegment {
+		return nil, fmt.Errorf("invalid Matroska file: expected segment, found element %#x", segment.id)
+	}
+	segmentStart := offset + int64(segment.headerLen)
+	segmentEnd := fileSize
+	if !segment.unknown && segmentStart+int64(segment.size) < fileSize {
+		segmentEnd = segmentStart + int64(segment.size)
+	}
+
+	media := &models.MediaFile{Container: "matroska"}
+	var info, tracks []byte
+	seeks := make(map[uint64]int64)
+
+	// Info and Tracks normally precede the clusters; the seek head tells where
+	// to find them when they do not
+	for pos := segmentStart; pos < segmentEnd && (info == nil || tracks == nil); {
+		element, err := readEBMLHeaderAt(r, pos)
+		if err != nil {
+			return nil, err
+		}
+		payload := pos + int64(element.headerLen)
+
+		if element.id == mkvCluster || element.unknown {
+			break
+		}
+		switch element.id {
+		case mkvInfo:
+			info, err = readEBMLPayload(r, payload, element.size)
+		case mkvTracks:
+			tracks, err = readEBMLPayload(r, payload, element.size)
+		case mkvSeekHead:
+			var data []byte
+			if data, err = readEBMLPayload(r, payload, element.size); err == nil {
+				err = parseMatroskaSeekHead(data, seeks)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		pos = payload + int64(element.size)
+	}
+
+	for _, wanted := range []struct {
+		id   uint64
+		data *[]byte
+	}{{mkvInfo, &info}, {mkvTracks, &tracks}} {
+		position, ok := seeks[wanted.id]
+		if *wanted.data != nil || !ok {
+			continue
+		}
+		element, err := readEBMLHeaderAt(r, segmentStart+position)
+		if err != nil || element.id != wanted.id {
+			continue
+		}
+		if *wanted.data, err = readEBMLPayload(r, segmentStart+position+int64(element.headerLen), element.size); err != nil {
+			return nil, err
+		}
+	}
+
+	if tracks == nil {
+		return nil, errors.New("invalid Matroska file: no tracks found")
+	}
+	if err := parseMatroskaInfo(info, media); err != nil {
+		return nil, err
+	}
+	if err := parseMatroskaTracks(tracks, media); err != nil {
+		return nil, err
+	}
+	return media, nil
+}
+
+// parseMatroskaSeekHead records the segment-relative position of each element
+// listed in a SeekHead
+func parseMatroskaSeekHead(data []byte, seeks map[uint64]int64) error {
+	return walkEBML(data, func(id uint64, payload []byte) error {
+		if id != mkvSeek {
+			return nil
+		}
+		var seekID, position uint64
+		err := walkEBML(payload, func(id uint64, value []byte) error {
+			switch id {
+			case mkvSeekID:
+				seekID = ebmlUint(value)
+			case mkvSeekPosition:
+				position = ebmlUint(value)
+			}
+			return nil
+		})
+		if err == nil && seekID != 0 && position <= math.MaxInt64 {
+			seeks[seekID] = int64(position)
+		}
+		return err
+	})
+}
+
+// parseMatroskaInfo reads the duration from a segment's Info element
+func parseMatroskaInfo(data []byte, media *models.MediaFile) error {
+	scale := uint64(1000000)
+	var duration float64
+	err := walkEBML(data, func(id uint64, payload []byte) error {
+		switch id {
+		case mkvTimestampScale:
+			scale = ebmlUint(payload)
+		case mkvDuration:
+			duration = ebmlFloat(payload)
+		}
+		return nil
+	})
+	media.Duration = duration * float64(scale) / 1e9
+	return err
+}
+
+// parseMatroskaTracks reads every TrackEntry of a Tracks element
+func parseMatroskaTracks(data []byte, media *models.MediaFile) error {
+	return walkEBML(data, func(id uint64, entry []byte) error {
+		if id != mkvTrackEntry {
+			return nil
+		}
+
+		var trackType uint64
+		var codec, language, languageBCP47 string
+		isDefault, forced := true, false
+		var video, audio []byte
+		err := walkEBML(entry, func(id uint64, value []byte) error {
+			switch id {
+			case mkvTrackType:
+				trackType = ebmlUint(value)
+			case mkvCodecID:
+				codec = ebmlString(value)
+			case mkvLanguage:
+				language = ebmlString(value)
+			case mkvLanguageBCP47:
+				languageBCP47 = ebmlString(value)
+			case mkvFlagDefault:
+				isDefault = ebmlUint(value) != 0
+			case mkvFlagForced:
+				forced = ebmlUint(value) != 0
+			case mkvVideo:
+				video = value
+			case mkvAudio:
+				audio = value
+			case mkvBlockAdditionMapping:
+				return walkEBML(value, func(id uint64, value []byte) error {
+					if id == mkvBlockAddIDType {
+						if addType := ebmlUint(value); addType == mkvDolbyVisionConfig || addType == mkvDolbyVisionELConfig {
+							media.HDR = "Dolby Vision"
+						}
+					}
+					return nil
+				})
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		// The Language element defaults to English when it is left out
+		if languageBCP47 != "" {
+			language = languageBCP47
+		} else if language == "" {
+			language = "eng"
+		}
+		if language == "und" {
+			language = ""
+		}
+
+		switch trackType {
+		case mkvTrackVideo:
+			if media.VideoCodec != "" {
+				return nil // cover art and the like come after the main video
+			}
+			media.VideoCodec = mkvCodecName(codec)
+			return parseMatroskaVideo(video, media)
+		case mkvTrackAudio:
+			track := models.AudioTrack{Codec: mkvCodecName(codec), Language: language, Default: isDefault, Channels: 2}
+			err := walkEBML(audio, func(id uint64, value []byte) error {
+				if id == mkvChannels {
+					track.Channels = int(ebmlUint(value))
+				}
+				return nil
+			})
+			media.AudioTracks = append(media.AudioTracks, track)
+			return err
+		case mkvTrackSubtitle:
+			media.SubtitleTracks = append(media.SubtitleTracks,
+				models.SubtitleTrack{Codec: mkvCodecName(codec), Language: language, Forced: forced})
+		}
+		return nil
+	})
+}
+
+// parseMatroskaVideo reads the frame size and colour information of a video track
+func parseMatroskaVideo(data []byte, media *models.MediaFile) error {
+	return walkEBML(data, func(id uint64, value []byte) error {
+		switch id {
+		case mkvPixelWidth:
+			media.Width = int(ebmlUint(value))
+		case mkvPixelHeight:
+			media.Height = int(ebmlUint(value))
+		case mkvColour:
+			return walkEBML(value, func(id uint64, value []byte) error {
+				if id == mkvTransferCharacteristics && media.HDR == "" {
+					media.HDR = hdrFromTransfer(ebmlUint(value))
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+}
+
+// mkvCodecName shortens a Matroska codec ID
+func mkvCodecName(codecID string) string {
+	for _, codec := range mkvCodecNames {
+		if strings.HasPrefix(codecID, codec.prefix) {
+			return codec.name
+		}
+	}
+	return strings.ToLower(codecID)
+}
+
+// walkEBML calls fn for every element in data, which holds the payload of a
+// master element
+func walkEBML(data []byte, fn func(id uint64, payload []byte) error) error {
+	for len(data) > 0 {
+		element, err := parseEBMLHeader(data)
+		if err != nil {
+			return err
+		}
+		data = data[element.headerLen:]
+		if element.unknown || element.size > uint64(len(data)) {
+			return errors.New("invalid Matroska file: element exceeds its parent")
+		}
+		if err := fn(element.id, data[:element.size]); err != nil {
+			return err
+		}
+		data = data[element.size:]
+	}
+	return nil
+}
+
+// readEBMLHeaderAt reads the element header at offset
+func readEBMLHeaderAt(r io.ReaderAt, offset int64) (ebmlHeader, error) {
+	buf := make([]byte, 12)
+	n, err := r.ReadAt(buf, offset)
+	if n == 0 && err != nil {
+		return ebmlHeader{}, fmt.Errorf("failed to read Matroska element: %w", err)
+	}
+	return parseEBMLHeader(buf[:n])
+}
+
+// readEBMLPayload reads an element's payload, refusing implausibly large ones
+func readEBMLPayload(r io.ReaderAt, offset int64, size uint64) ([]byte, error) {
+	if size > maxHeaderElementSize {
+		return nil, fmt.Errorf("invalid Matroska file: %d byte header element", size)
+	}
+	data := make([]byte, size)
+	if _, err := r.ReadAt(data, offset); err != nil {
+		return nil, fmt.Errorf("failed to read Matroska element: %w", err)
+	}
+	return data, nil
+}
+
+// parseEBMLHeader decodes an element ID, which keeps its length marker, and
+// the element's data size
+func parseEBMLHeader(data []byte) (ebmlHeader, error) {
+	id, idLen, _, err := ebmlVint(data, true)
+	if err != nil {
+		return ebmlHeader{}, err
+	}
+	size, sizeLen, unknown, err := ebmlVint(data[idLen:], false)
+	if err != nil {
+		return ebmlHeader{}, err
+	}
+	return ebmlHeader{id: id, size: size, headerLen: idLen + sizeLen, unknown: unknown}, nil
+}
+
+// ebmlVint decodes a variable-length integer. allOnes reports the reserved
+// all-ones value that marks an unknown size.
+func ebmlVint(data []byte, keepMarker bool) (value uint64, length int, allOnes bool, err error) {
+	if len(data) == 0 || data[0] == 0 {
+		return 0, 0, false, errors.New("invalid Matroska file: malformed variable-length integer")
+	}
+	length = bits.LeadingZeros8(data[0]) + 1
+	if len(data) < length {
+		return 0, 0, false, errors.New("invalid Matroska file: truncated variable-length integer")
+	}
+
+	mask := byte(0xFF >> length)
+	value = uint64(data[0] & mask)
+	if keepMarker {
+		value = uint64(data[0])
+	}
+	allOnes = data[0]&mask == mask
+	for _, b := range data[1:length] {
+		value = value<<8 | uint64(b)
+		allOnes = allOnes && b == 0xFF
+	}
+	return value, length, allOnes, nil
+}
+
+// ebmlUint decodes an unsigned integer element
+func ebmlUint(data []byte) uint64 {
+	var value uint64
+	for _, b := range data {
+		value = value<<8 | uint64(b)
+	}
+	return value
+}
+
+// ebmlFloat decodes a 4 or 8 byte float element
+func ebmlFloat(data []byte) float64 {
+	switch len(data) {
+	case 4:
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(data)))
+	case 8:
+		return math.Float64frombits(binary.BigEndian.Uint64(data))
+	default:
+		return 0
+	}
+}
+
+// ebmlString decodes a string element, which may be padded with zero bytes
+func ebmlString(data []byte) string {
+	return strings.TrimRight(string(data), "\x00")
+}
@@ -0,0 +1,282 @@
+package services
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"media/models"
+)
+
+// mp4CodecNames maps MP4 sample entry types to short codec names
+var mp4CodecNames = map[string]string{
+	"avc1": "h264", "avc3": "h264", "hvc1": "hevc", "hev1": "hevc", "dvh1": "hevc", "dvhe": "hevc",
+	"av01": "av1", "vp09": "vp9", "mp4v": "mpeg4",
+	"mp4a": "aac", "ac-3": "ac3", "ec-3": "eac3", "dtsc": "dts", "dtsh": "dts", "dtsl": "dts", "dtse": "dts",
+	"mlpa": "truehd", "Opus": "opus", "fLaC": "flac", ".mp3": "mp3",
+	"tx3g": "mov_text", "wvtt": "webvtt", "stpp": "ttml", "c608": "cea608",
+}
+
+// mp4BoxHeader is the type and extent of an ISO BMFF box
+type mp4BoxHeader struct {
+	boxType   string
+	size      int64 // including the header
+	headerLen int64
+}
+
+// probeMP4 reads the moov box of an MP4 file
+func probeMP4(r io.ReaderAt, fileSize int64) (*models.MediaFile, error) {
+	// moov sits before or after the media data; skip over boxes to find it
+	for pos := int64(0); pos < fileSize; {
+		box, err := readMP4BoxHeaderAt(r, pos, fileSize)
+		if err != nil {
+			return nil, err
+		}
+		if box.boxType == "moov" {
+			size := box.size - box.headerLen
+			if size > maxHeaderElementSize {
+				return nil, fmt.Errorf("invalid MP4 file: %d byte moov box", size)
+			}
+			moov := make([]byte, size)
+			if _, err := r.ReadAt(moov, pos+box.headerLen); err != nil {
+				return nil, fmt.Errorf("failed to read MP4 moov box: %w", err)
+			}
+			return parseMP4Movie(moov)
+		}
+		pos += box.size
+	}
+	return nil, errors.New("invalid MP4 file: no moov box")
+}
+
+// parseMP4Movie reads the duration and tracks from a moov box
+func parseMP4Movie(moov []byte) (*models.MediaFile, error) {
+	media := &models.MediaFile{Container: "mp4"}
+	err := walkMP4(moov, func(boxType string, payload []byte) error {
+		switch boxType {
+		case "mvhd":
+			timescale, duration := mp4Timing(payload)
+			if timescale > 0 {
+				media.Duration = float64(duration) / float64(timescale)
+			}
+		case "trak":
+			return parseMP4Track(payload, media)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return media, nil
+}
+
+// parseMP4Track adds one trak box to media
+func parseMP4Track(trak []byte, media *models.MediaFile) error {
+	var handler, language string
+	var sampleEntry mp4SampleEntry
+
+	err := walkMP4Path(trak, []string{"mdia"}, func(boxType string, payload []byte) error {
+		switch boxType {
+		case "mdhd":
+			language = mp4Language(payload)
+		case "hdlr":
+			if len(payload) >= 12 {
+				handler = string(payload[8:12])
+			}
+		case "minf":
+			return walkMP4Path(payload, []string{"stbl"}, func(boxType string, payload []byte) error {
+				if boxType == "stsd" {
+					entry, err := parseMP4SampleDescription(payload)
+					sampleEntry = entry
+					return err
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	codec := mp4CodecNames[sampleEntry.format]
+	if codec == "" {
+		codec = sampleEntry.format
+	}
+
+	switch handler {
+	case "vide":
+		if media.VideoCodec != "" {
+			return nil
+		}
+		media.VideoCodec = codec
+		media.Width, media.Height = sampleEntry.width, sampleEntry.height
+		media.HDR = sampleEntry.hdr
+	case "soun":
+		media.AudioTracks = append(media.AudioTracks,
+			models.AudioTrack{Codec: codec, Language: language, Channels: sampleEntry.channels})
+	case "sbtl", "subt", "text":
+		media.SubtitleTracks = append(media.SubtitleTracks, models.SubtitleTrack{Codec: codec, Language: language})
+	}
+	return nil
+}
+
+// mp4SampleEntry is what the first entry of a sample description tells us
+type mp4SampleEntry struct {
+	format        string
+	width, height int
+	channels      int
+	hdr           string
+}
+
+// parseMP4SampleDescription reads the first sample entry of an stsd box
+func parseMP4SampleDescription(stsd []byte) (mp4SampleEntry, error) {
+	var entry mp4SampleEntry
+	if len(stsd) < 8 {
+		return entry, errors.New("invalid MP4 file: truncated sample description")
+	}
+
+	return entry, walkMP4(stsd[8:], func(boxType string, payload []byte) error {
+		if entry.format != "" {
+			return nil
+		}
+		entry.format = boxType
+
+		switch {
+		case len(payload) >= 78 && isMP4VideoEntry(boxType):
+			// Visual sample entry: reserved and pre-defined fields, then width and height
+			entry.width = int(binary.BigEndian.Uint16(payload[24:26]))
+			entry.height = int(binary.BigEndian.Uint16(payload[26:28]))
+			if boxType == "dvh1" || boxType == "dvhe" {
+				entry.hdr = "Dolby Vision"
+			}
+			return walkMP4(payload[78:], func(boxType string, payload []byte) error {
+				switch boxType {
+				case "dvcC", "dvvC", "dvwC":
+					entry.hdr = "Dolby Vision"
+				case "colr":
+					if len(payload) >= 8 && string(payload[:4]) == "nclx" && entry.hdr == "" {
+						entry.hdr = hdrFromTransfer(uint64(binary.BigEndian.Uint16(payload[6:8])))
+					}
+				}
+				return nil
+			})
+		case len(payload) >= 18 && !isMP4VideoEntry(boxType):
+			// Audio sample entry: reserved fields, then the channel count
+			entry.channels = int(binary.BigEndian.Uint16(payload[16:18]))
+		}
+		return nil
+	})
+}
+
+// isMP4VideoEntry reports whether a sample entry type is a video format
+func isMP4VideoEntry(format string) bool {
+	switch format {
+	case "avc1", "avc3", "hvc1", "hev1", "dvh1", "dvhe", "av01", "vp09", "mp4v":
+		return true
+	}
+	return false
+}
+
+// mp4Timing returns the timescale and duration of an mvhd or mdhd box
+func mp4Timing(payload []byte) (uint32, uint64) {
+	if len(payload) >= 32 && payload[0] == 1 {
+		return binary.BigEndian.Uint32(payload[20:24]), binary.BigEndian.Uint64(payload[24:32])
+	}
+	if len(payload) >= 20 {
+		return binary.BigEndian.Uint32(payload[12:16]), uint64(binary.BigEndian.Uint32(payload[16:20]))
+	}
+	return 0, 0
+}
+
+// mp4Language decodes the packed ISO 639-2 language code of an mdhd box
+func mp4Language(mdhd []byte) string {
+	offset := 20
+	if len(mdhd) > 0 && mdhd[0] == 1 {
+		offset = 32
+	}
+	if len(mdhd) < offset+2 {
+		return ""
+	}
+
+	packed := binary.BigEndian.Uint16(mdhd[offset : offset+2])
+	code := []byte{
+		byte(packed>>10&0x1F) + 0x60,
+		byte(packed>>5&0x1F) + 0x60,
+		byte(packed&0x1F) + 0x60,
+	}
+	if language := string(code); language != "und" && code[0] > 0x60 {
+		return language
+	}
+	return ""
+}
+
+// walkMP4Path descends through the named boxes and calls fn for every box
+// inside the last one
+func walkMP4Path(data []byte, path []string, fn func(boxType string, payload []byte) error) error {
+	if len(path) == 0 {
+		return walkMP4(data, fn)
+	}
+	return walkMP4(data, func(boxType string, payload []byte) error {
+		if boxType == path[0] {
+			return walkMP4Path(payload, path[1:], fn)
+		}
+		return nil
+	})
+}
+
+// walkMP4 calls fn for every box in data
+func walkMP4(data []byte, fn func(boxType string, payload []byte) error) error {
+	for len(data) >= 8 {
+		box, err := parseMP4BoxHeader(data, int64(len(data)))
+		if err != nil {
+			return err
+		}
+		if box.size > int64(len(data)) {
+			return errors.New("invalid MP4 file: box exceeds its parent")
+		}
+		if err := fn(box.boxType, data[box.headerLen:box.size]); err != nil {
+			return err
+		}
+		data = data[box.size:]
+	}
+	return nil
+}
+
+// readMP4BoxHeaderAt reads the box header at offset
+func readMP4BoxHeaderAt(r io.ReaderAt, offset, fileSize int64) (mp4BoxHeader, error) {
+	buf := make([]byte, 16)
+	n, err := r.ReadAt(buf, offset)
+	if n < 8 {
+		return mp4BoxHeader{}, fmt.Errorf("failed to read MP4 box: %w", err)
+	}
+	return parseMP4BoxHeader(buf[:n], fileSize-offset)
+}
+
+// parseMP4BoxHeader decodes a box header; remaining is the space left in the
+// parent, which a size of zero extends the box to
+func parseMP4BoxHeader(data []byte, remaining int64) (mp4BoxHeader, error) {
+	box := mp4BoxHeader{
+		boxType:   string(data[4:8]),
+		size:      int64(binary.BigEndian.Uint32(data[:4])),
+		headerLen: 8,
+	}
+	switch box.size {
+	case 0:
+		box.size = remaining
+	case 1:
+		if len(data) < 16 {
+			return box, errors.New("invalid MP4 file: truncated box header")
+		}
+		largeSize := binary.BigEndian.Uint64(data[8:16])
+		if largeSize > uint64(remaining) {
+			return box, errors.New("invalid MP4 file: box exceeds the file")
+		}
+		box.size = int64(largeSize)
+		box.headerLen = 16
+	}
+	if box.size < box.headerLen {
+		return box, fmt.Errorf("invalid MP4 file: %q box of %d bytes", box.boxType, box.size)
+	}
+	return box, nil
+}
@@ -0,0 +1,252 @@
+package services
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"media/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ebmlElement encodes an element with an 8 byte size field
+func ebmlElement(id uint64, payload ...[]byte) []byte {
+	var buf bytes.Buffer
+	idBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(idBytes, id)
+	buf.Write(bytes.TrimLeft(idBytes, "\x00"))
+
+	data := bytes.Join(payload, nil)
+	size := make([]byte, 8)
+	binary.BigEndian.PutUint64(size, uint64(len(data)))
+	size[0] = 0x01
+	buf.Write(size)
+	buf.Write(data)
+	return buf.Bytes()
+}
+
+// ebmlUnknownSize encodes the start of an element whose size is unknown
+func ebmlUnknownSize(id uint64) []byte {
+	idBytes := make([]byte, 4)
+	binary.BigEndian.PutUint32(idBytes, uint32(id))
+	return append(idBytes, 0x01, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF)
+}
+
+func ebmlUintValue(value uint64) []byte {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, value)
+	return buf
+}
+
+func ebmlFloatValue(value float64) []byte {
+	return ebmlUintValue(math.Float64bits(value))
+}
+
+// testMatroskaTracks builds a Tracks element with a 4K HDR10 video, two audio
+// tracks and a forced subtitle
+func testMatroskaTracks() []byte {
+	return ebmlElement(mkvTracks,
+		ebmlElement(mkvTrackEntry,
+			ebmlElement(mkvTrackType, ebmlUintValue(mkvTrackVideo)),
+			ebmlElement(mkvCodecID, []byte("V_MPEGH/ISO/HEVC")),
+			ebmlElement(mkvVideo,
+				ebmlElement(mkvPixelWidth, ebmlUintValue(3840)),
+				ebmlElement(mkvPixelHeight, ebmlUintValue(1600)),
+				ebmlElement(mkvColour, ebmlElement(mkvTransferCharacteristics, ebmlUintValue(16))),
+			),
+		),
+		ebmlElement(mkvTrackEntry,
+			ebmlElement(mkvTrackType, ebmlUintValue(mkvTrackAudio)),
+			ebmlElement(mkvCodecID, []byte("A_EAC3")),
+			ebmlElement(mkvAudio, ebmlElement(mkvChannels, ebmlUintValue(6))),
+		),
+		ebmlElement(mkvTrackEntry,
+			ebmlElement(mkvTrackType, ebmlUintValue(mkvTrackAudio)),
+			ebmlElement(mkvCodecID, []byte("A_AAC")),
+			ebmlElement(mkvLanguage, []byte("ger")),
+			ebmlElement(mkvFlagDefault, ebmlUintValue(0)),
+		),
+		ebmlElement(mkvTrackEntry,
+			ebmlElement(mkvTrackType, ebmlUintValue(mkvTrackSubtitle)),
+			ebmlElement(mkvCodecID, []byte("S_HDMV/PGS")),
+			ebmlElement(mkvLanguageBCP47, []byte("en-US")),
+			ebmlElement(mkvFlagForced, ebmlUintValue(1)),
+		),
+	)
+}
+
+func testMatroskaHeader() []byte {
+	return ebmlElement(0x1A45DFA3, ebmlElement(0x4282, []byte("matroska")))
+}
+
+func testMatroskaInfo() []byte {
+	// 2 hours in milliseconds at the default timestamp scale
+	return ebmlElement(mkvInfo,
+		ebmlElement(mkvTimestampScale, ebmlUintValue(1000000)),
+		ebmlElement(mkvDuration, ebmlFloatValue(7200000)),
+	)
+}
+
+func writeProbeFile(t *testing.T, name string, data []byte) string {
+	path := filepath.Join(t.TempDir(), name)
+	assert.NoError(t, os.WriteFile(path, data, 0o644))
+	return path
+}
+
+func assertMatroskaTracks(t *testing.T, path string) {
+	media, err := ProbeMediaFile(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, "matroska", media.Container)
+	assert.InDelta(t, 7200, media.Duration, 0.001)
+	assert.Equal(t, "hevc", media.VideoCodec)
+	assert.Equal(t, 3840, media.Width)
+	assert.Equal(t, 1600, media.Height)
+	assert.Equal(t, "4K", media.Resolution)
+	assert.Equal(t, "HDR10", media.HDR)
+
+	if assert.Len(t, media.AudioTracks, 2) {
+		assert.Equal(t, "eac3", media.AudioTracks[0].Codec)
+		assert.Equal(t, "eng", media.AudioTracks[0].Language)
+		assert.Equal(t, 6, media.AudioTracks[0].Channels)
+		assert.True(t, media.AudioTracks[0].Default)
+		assert.Equal(t, "aac", media.AudioTracks[1].Codec)
+		assert.Equal(t, "ger", media.AudioTracks[1].Language)
+		assert.False(t, media.AudioTracks[1].Default)
+	}
+	if assert.Len(t, media.SubtitleTracks, 1) {
+		assert.Equal(t, "pgs", media.SubtitleTracks[0].Codec)
+		assert.Equal(t, "en-US", media.SubtitleTracks[0].Language)
+		assert.True(t, media.SubtitleTracks[0].Forced)
+	}
+}
+
+func TestProbeMediaFile_Matroska(t *testing.T) {
+	data := append(testMatroskaHeader(), ebmlElement(mkvSegment,
+		testMatroskaInfo(),
+		testMatroskaTracks(),
+		ebmlElement(mkvCluster, make([]byte, 1024)),
+	)...)
+
+	assertMatroskaTracks(t, writeProbeFile(t, "movie.mkv", data))
+}
+
+func TestProbeMediaFile_MatroskaTracksAfterClusters(t *testing.T) {
+	// Live-written files have unknown sizes and list their tracks at the end,
+	// reachable only through the seek head
+	seekHead := func(tracksPosition uint64) []byte {
+		return ebmlElement(mkvSeekHead, ebmlElement(mkvSeek,
+			ebmlElement(mkvSeekID, ebmlUintValue(mkvTracks)[4:]),
+			ebmlElement(mkvSeekPosition, ebmlUintValue(tracksPosition)),
+		))
+	}
+	info := testMatroskaInfo()
+	cluster := append(ebmlUnknownSize(mkvCluster), make([]byte, 1024)...)
+	position := uint64(len(seekHead(0)) + len(info) + len(cluster))
+
+	data := append(testMatroskaHeader(), ebmlUnknownSize(mkvSegment)...)
+	data = append(data, seekHead(position)...)
+	data = append(data, info...)
+	data = append(data, cluster...)
+	data = append(data, testMatroskaTracks()...)
+
+	assertMatroskaTracks(t, writeProbeFile(t, "movie.mkv", data))
+}
+
+// mp4Box encodes an ISO BMFF box
+func mp4Box(boxType string, payload ...[]byte) []byte {
+	data := bytes.Join(payload, nil)
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint32(header, uint32(8+len(data)))
+	copy(header[4:], boxType)
+	return append(header, data...)
+}
+
+// mp4Track builds a trak box with one sample entry
+func mp4Track(handler, language string, entry []byte) []byte {
+	mdhd := make([]byte, 24)
+	packed := uint16(language[0]-0x60)<<10 | uint16(language[1]-0x60)<<5 | uint16(language[2]-0x60)
+	binary.BigEndian.PutUint16(mdhd[20:], packed)
+
+	hdlr := make([]byte, 25)
+	copy(hdlr[8:], handler)
+
+	stsd := make([]byte, 8)
+	binary.BigEndian.PutUint32(stsd[4:], 1)
+
+	return mp4Box("trak", mp4Box("mdia",
+		mp4Box("mdhd", mdhd),
+		mp4Box("hdlr", hdlr),
+		mp4Box("minf", mp4Box("stbl", mp4Box("stsd", stsd, entry))),
+	))
+}
+
+func TestProbeMediaFile_MP4(t *testing.T) {
+	mvhd := make([]byte, 100)
+	binary.BigEndian.PutUint32(mvhd[12:], 1000)
+	binary.BigEndian.PutUint32(mvhd[16:], 5400000)
+
+	visual := make([]byte, 78)
+	binary.BigEndian.PutUint16(visual[24:], 1280)
+	binary.BigEndian.PutUint16(visual[26:], 534)
+	colr := []byte("nclx\x00\x09\x00\x12\x00\x09\x00")
+
+	audio := make([]byte, 28)
+	binary.BigEndian.PutUint16(audio[16:], 2)
+
+	// moov after the media data, as written by most encoders
+	data := bytes.Join([][]byte{
+		mp4Box("ftyp", []byte("isom\x00\x00\x02\x00isomiso2avc1mp41")),
+		mp4Box("mdat", make([]byte, 4096)),
+		mp4Box("moov",
+			mp4Box("mvhd", mvhd),
+			mp4Track("vide", "und", mp4Box("avc1", visual, mp4Box("colr", colr))),
+			mp4Track("soun", "fre", mp4Box("mp4a", audio)),
+			mp4Track("sbtl", "eng", mp4Box("tx3g", make([]byte, 30))),
+		),
+	}, nil)
+
+	media, err := ProbeMediaFile(writeProbeFile(t, "movie.mp4", data))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, "mp4", media.Container)
+	assert.InDelta(t, 5400, media.Duration, 0.001)
+	assert.Equal(t, "h264", media.VideoCodec)
+	assert.Equal(t, 1280, media.Width)
+	assert.Equal(t, 534, media.Height)
+	assert.Equal(t, "720p", media.Resolution)
+	assert.Equal(t, "HLG", media.HDR)
+	if assert.Len(t, media.AudioTracks, 1) {
+		assert.Equal(t, models.AudioTrack{Codec: "aac", Language: "fre", Channels: 2}, media.AudioTracks[0])
+	}
+	if assert.Len(t, media.SubtitleTracks, 1) {
+		assert.Equal(t, "mov_text", media.SubtitleTracks[0].Codec)
+		assert.Equal(t, "eng", media.SubtitleTracks[0].Language)
+	}
+}
+
+func TestProbeMediaFile_Unknown(t *testing.T) {
+	_, err := ProbeMediaFile(writeProbeFile(t, "movie.avi", []byte("RIFF\x00\x00\x00\x00AVI LIST")))
+	assert.ErrorIs(t, err, ErrUnknownContainer)
+
+	// Truncated files are reported, not panicked on
+	data := append(testMatroskaHeader(), ebmlElement(mkvSegment, testMatroskaTracks())...)
+	_, err = ProbeMediaFile(writeProbeFile(t, "movie.mkv", data[:len(data)-20]))
+	assert.Error(t, err)
+}
+
+func TestResolutionLabel(t *testing.T) {
+	assert.Equal(t, "4K", ResolutionLabel(3840, 2160))
+	assert.Equal(t, "1080p", ResolutionLabel(1920, 800))
+	assert.Equal(t, "720p", ResolutionLabel(1280, 720))
+	assert.Equal(t, "480p", ResolutionLabel(720, 480))
+	assert.Equal(t, "", ResolutionLabel(0, 0))
+}
@@ -0,0 +1,265 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"media/models"
+)
+
+// MediaChange says what happened to a movie file a media server is told about
+type MediaChange string
+
+const (
+	MediaCreated  MediaChange = "created"
+	MediaModified MediaChange = "modified"
+	MediaDeleted  MediaChange = "deleted"
+)
+
+// MediaServerClient tells a media server about changes to its library, so it
+// does not have to wait for its next scheduled scan
+type MediaServerClient interface {
+	// Test checks that the server is reachable and accepts the credentials
+	Test(ctx context.Context) error
+	// Refresh asks the server to rescan the folder of a movie file
+	Refresh(ctx context.Context, path string, change MediaChange) error
+}
+
+// NewMediaServerClient returns the client for a media server connection
+func NewMediaServerClient(server models.MediaServer) (MediaServerClient, error) {
+	base := mediaServerBase{
+		url:    strings.TrimRight(server.URL, "/"),
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+
+	switch server.Type {
+	case models.MediaServerJellyfin, models.MediaServerEmby:
+		return &jellyfinClient{mediaServerBase: base, apiKey: server.APIKey}, nil
+	case models.MediaServerPlex:
+		return &plexClient{mediaServerBase: base, token: server.APIKey}, nil
+	case models.MediaServerKodi:
+		return &kodiClient{mediaServerBase: base, username: server.Username, password: server.Password}, nil
+	default:
+		return nil, fmt.Errorf("unsupported media server type %q", server.Type)
+	}
+}
+
+// mediaServerBase holds what every media server client needs
+type mediaServerBase struct {
+	url    string
+	client *http.Client
+}
+
+// do sends a request and returns the response body, failing on non-2xx statuses
+func (b *mediaServerBase) do(req *http.Request) ([]byte, error) {
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach %s: %w", b.url, err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Printf("Failed to close response body: %v", err)
+		}
+	}()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response from %s: %w", b.url, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("%s %s returned status %d", req.Method, req.URL.Path, resp.StatusCode)
+	}
+	return body, nil
+}
+
+// jellyfinClient talks to Jellyfin and Emby, which share this part of their API
+type jellyfinClient struct {
+	mediaServerBase
+	apiKey string
+}
+
+func (c *jellyfinClient) Test(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url+"/System/Info", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Emby-Token", c.apiKey)
+	_, err = c.do(req)
+	return err
+}
+
+func (c *jellyfinClient) Refresh(ctx context.Context, path string, change MediaChange) error {
+	updateTypes := map[MediaChange]string{MediaCreated: "Created", MediaModified: "Modified", MediaDeleted: "Deleted"}
+	body, err := json.Marshal(map[string]interface{}{
+		"Updates": []map[string]string{{"Path": path, "UpdateType": updateTypes[change]}},
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url+"/Library/Media/Updated", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Emby-Token", c.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+	_, err = c.do(req)
+	return err
+}
+
+// plexClient talks to Plex Media Server
+type plexClient struct {
+	mediaServerBase
+	token string
+}
+
+func (c *plexClient) get(ctx context.Context, path string, query url.Values) ([]byte, error) {
+	endpoint := c.url + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Plex-Token", c.token)
+	req.Header.Set("Accept", "application/json")
+	return c.do(req)
+}
+
+func (c *plexClient) Test(ctx context.Context) error {
+	_, err := c.get(ctx, "/identity", nil)
+	return err
+}
+
+// Refresh scans the movie's folder within the library section holding it.
+// For a deleted file the folder above is scanned, as the movie's own folder
+// may be gone too.
+func (c *plexClient) Refresh(ctx context.Context, path string, change MediaChange) error {
+	body, err := c.get(ctx, "/library/sections", nil)
+	if err != nil {
+		return err
+	}
+
+	var sections struct {
+		MediaContainer struct {
+			Directory []struct {
+				Key      string `json:"key"`
+				Location []struct {
+					Path string `json:"path"`
+				} `json:"Location"`
+			} `json:"Directory"`
+		} `json:"MediaContainer"`
+	}
+	if err := json.Unmarshal(body, &sections); err != nil {
+		return fmt.Errorf("failed to decode Plex library sections: %w", err)
+	}
+
+	sectionKey, location := "", ""
+	for _, section := range sections.MediaContainer.Directory {
+		for _, loc := range section.Location {
+			if isWithinPath(path, loc.Path) && len(loc.Path) > len(location) {
+				sectionKey, location = section.Key, loc.Path
+			}
+		}
+	}
+	if sectionKey == "" {
+		return fmt.Errorf("no Plex library contains %s", path)
+	}
+
+	folder := filepath.Dir(path)
+	if change == MediaDeleted && filepath.Clean(folder) != filepath.Clean(location) {
+		folder = filepath.Dir(folder)
+	}
+	_, err = c.get(ctx, "/library/sections/"+url.PathEscape(sectionKey)+"/refresh", url.Values{"path": {folder}})
+	return err
+}
+
+// kodiClient talks to Kodi over JSON-RPC
+type kodiClient struct {
+	mediaServerBase
+	username string
+	password string
+}
+
+// call invokes a JSON-RPC method and decodes its result into result
+func (c *kodiClient) call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	request := map[string]interface{}{"jsonrpc": "2.0", "id": 1, "method": method}
+	if params != nil {
+		request["params"] = params
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url+"/jsonrpc", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.username != "" || c.password != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	respBody, err := c.do(req)
+	if err != nil {
+		return err
+	}
+
+	var response struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return fmt.Errorf("failed to decode Kodi response: %w", err)
+	}
+	if response.Error != nil {
+		return fmt.Errorf("kodi %s failed: %s (%d)", method, response.Error.Message, response.Error.Code)
+	}
+	if result != nil {
+		return json.Unmarshal(response.Result, result)
+	}
+	return nil
+}
+
+func (c *kodiClient) Test(ctx context.Context) error {
+	var pong string
+	if err := c.call(ctx, "JSONRPC.Ping", nil, &pong); err != nil {
+		return err
+	}
+	if pong != "pong" {
+		return fmt.Errorf("unexpected Kodi ping response %q", pong)
+	}
+	return nil
+}
+
+// Refresh scans the movie's folder for new files, or cleans removed ones from
+// the library
+func (c *kodiClient) Refresh(ctx context.Context, path string, change MediaChange) error {
+	method := "VideoLibrary.Scan"
+	params := map[string]interface{}{"directory": filepath.Dir(path) + "/", "showdialogs": false}
+	if change == MediaDeleted {
+		method = "VideoLibrary.Clean"
+		params["content"] = "movies"
+	}
+	return c.call(ctx, method, params, nil)
+}
+
+// isWithinPath reports whether path is dir or lies below it
+func isWithinPath(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
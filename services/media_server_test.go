@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"media/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJellyfinClient(t *testing.T) {
+	var updates []map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Emby-Token") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/System/Info":
+			_, _ = w.Write([]byte(`{"ServerName":"jellyfin"}`))
+		case "/Library/Media/Updated":
+			var body struct{ Updates []map[string]string }
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			updates = append(updates, body.Updates...)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewMediaServerClient(models.MediaServer{Type: models.MediaServerJellyfin, URL: server.URL + "/", APIKey: "secret"})
+	assert.NoError(t, err)
+	assert.NoError(t, client.Test(context.Background()))
+	assert.NoError(t, client.Refresh(context.Background(), "/movies/Heat (1995)/Heat (1995).mkv", MediaCreated))
+	assert.NoError(t, client.Refresh(context.Background(), "/movies/Heat (1995)/Heat (1995).mkv", MediaDeleted))
+	assert.Equal(t, []map[string]string{
+		{"Path": "/movies/Heat (1995)/Heat (1995).mkv", "UpdateType": "Created"},
+		{"Path": "/movies/Heat (1995)/Heat (1995).mkv", "UpdateType": "Deleted"},
+	}, updates)
+
+	client, err = NewMediaServerClient(models.MediaServer{Type: models.MediaServerEmby, URL: server.URL, APIKey: "wrong"})
+	assert.NoError(t, err)
+	assert.Error(t, client.Test(context.Background()))
+}
+
+func TestPlexClient(t *testing.T) {
+	var refreshed []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Plex-Token") != "token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/identity":
+			_, _ = w.Write([]byte(`{"MediaContainer":{"machineIdentifier":"abc"}}`))
+		case "/library/sections":
+			_, _ = w.Write([]byte(`{"MediaContainer":{"Directory":[
+				{"key":"1","type":"movie","Location":[{"path":"/movies"}]},
+				{"key":"2","type":"movie","Location":[{"path":"/movies/4k"}]},
+				{"key":"3","type":"show","Location":[{"path":"/tv"}]}]}}`))
+		case "/library/sections/1/refresh", "/library/sections/2/refresh":
+			refreshed = append(refreshed, r.URL.Path+" "+r.URL.Query().Get("path"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewMediaServerClient(models.MediaServer{Type: models.MediaServerPlex, URL: server.URL, APIKey: "token"})
+	assert.NoError(t, err)
+	assert.NoError(t, client.Test(context.Background()))
+
+	assert.NoError(t, client.Refresh(context.Background(), "/movies/Heat (1995)/Heat (1995).mkv", MediaCreated))
+	assert.NoError(t, client.Refresh(context.Background(), "/movies/4k/Dune (2021)/Dune (2021).mkv", MediaDeleted))
+	assert.Error(t, client.Refresh(context.Background(), "/downloads/Heat.mkv", MediaCreated))
+	assert.Equal(t, []string{
+		"/library/sections/1/refresh /movies/Heat (1995)",
+		"/library/sections/2/refresh /movies/4k",
+	}, refreshed)
+}
+
+func TestKodiClient(t *testing.T) {
+	var calls []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username, password, ok := r.BasicAuth(); !ok || username != "kodi" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var call map[string]interface{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&call))
+		calls = append(calls, call)
+
+		switch call["method"] {
+		case "JSONRPC.Ping":
+			_, _ = w.Write([]byte(`{"id":1,"jsonrpc":"2.0","result":"pong"}`))
+		case "VideoLibrary.Scan", "VideoLibrary.Clean":
+			_, _ = w.Write([]byte(`{"id":1,"jsonrpc":"2.0","result":"OK"}`))
+		default:
+			_, _ = w.Write([]byte(`{"id":1,"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found."}}`))
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewMediaServerClient(models.MediaServer{Type: models.MediaServerKodi, URL: server.URL,
+		Username: "kodi", Password: "secret"})
+	assert.NoError(t, err)
+	assert.NoError(t, client.Test(context.Background()))
+	assert.NoError(t, client.Refresh(context.Background(), "/movies/Heat (1995)/Heat (1995).mkv", MediaCreated))
+	assert.NoError(t, client.Refresh(context.Background(), "/movies/Heat (1995)/Heat (1995).mkv", MediaDeleted))
+
+	if assert.Len(t, calls, 3) {
+		assert.Equal(t, "VideoLibrary.Scan", calls[1]["method"])
+		assert.Equal(t, "/movies/Heat (1995)/", calls[1]["params"].(map[string]interface{})["directory"])
+		assert.Equal(t, "VideoLibrary.Clean", calls[2]["method"])
+	}
+
+	kodi := client.(*kodiClient)
+	assert.ErrorContains(t, kodi.call(context.Background(), "Unknown.Method", nil, nil), "Method not found")
+
+	client, err = NewMediaServerClient(models.MediaServer{Type: models.MediaServerKodi, URL: server.URL})
+	assert.NoError(t, err)
+	assert.Error(t, client.Test(context.Background()))
+}
+
+func TestNewMediaServerClient_UnknownType(t *testing.T) {
+	_, err := NewMediaServerClient(models.MediaServer{Type: "roku", URL: "http://localhost"})
+	assert.Error(t, err)
+}
@@ -1,334 +1,624 @@
 package services
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"mime/multipart"
 	"net/http"
+	"net/http/cookiejar"
 	"net/url"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
-// QBittorrentService handles interactions with qBittorrent WebUI API
+// How long AddTorrent waits for a newly added torrent to appear in qBittorrent
+const (
+	torrentVerifyAttempts = 20
+	torrentVerifyInterval = 500 * time.Millisecond
+)
+
+// stopConditionVersion is the first qBittorrent release that honors the
+// stopCondition field, without which a magnet cannot be held for inspection
+var stopConditionVersion = [2]int{4, 6}
+
+// maxAuthRetries bounds how often a request is retried after the session expired
+const maxAuthRetries = 2
+
+// ErrRequestRejected matches an APIError where qBittorrent answered "Fails.",
+// e.g. for an invalid torrent or wrong credentials
+var ErrRequestRejected = errors.New("qBittorrent rejected the request")
+
+// APIError is returned when qBittorrent answers with an error status or with
+// its "Fails." body
+type APIError struct {
+	Endpoint   string
+	StatusCode int
+	Body       string
+}
+
+// Error implements the error interface
+func (e *APIError) Error() string {
+	if e.Rejected() {
+		return fmt.Sprintf("qBittorrent rejected %s", e.Endpoint)
+	}
+	return fmt.Sprintf("qBittorrent %s failed with status: %d, body: %s", e.Endpoint, e.StatusCode, e.Body)
+}
+
+// Rejected reports whether qBittorrent answered with its "Fails." body
+func (e *APIError) Rejected() bool {
+	return strings.TrimSpace(e.Body) == "Fails."
+}
+
+// Is lets errors.Is(err, ErrRequestRejected) match rejected requests
+func (e *APIError) Is(target error) bool {
+	return target == ErrRequestRejected && e.Rejected()
+}
+
+// QBittorrentService handles interactions with qBittorrent WebUI API. It is
+// safe for concurrent use: the session cookie lives in the client's cookie jar
+// and an expired session is renewed by a single login shared by all requests
+// that noticed it.
 type QBittorrentService struct {
 	BaseURL  string
 	Username string
 	Password string
 	Client   *http.Client
-	Cookie   string
+
+	// supportsStopCondition is set once qBittorrent's version is known to
+	// honor stopCondition
+	supportsStopCondition atomic.Bool
+
+	loginMu sync.Mutex
+	// session counts successful logins, so requests that failed on the same
+	// expired session can tell whether someone else already logged in again
+	session atomic.Uint64
 }
 
-// QBTorrent represents a torrent in qBittorrent
-type QBTorrent struct {
-	Hash     string  `json:"hash"`
+// AddTorrentOptions controls how a torrent is added to qBittorrent
+type AddTorrentOptions struct {
+	Category string
+	SavePath string
+	// Paused adds the torrent without downloading content. Magnets still
+	// fetch their metadata so the file list can be inspected, which needs
+	// qBittorrent 4.6 or later.
+	Paused bool
+}
+
+// formFields returns the /api/v2/torrents/add form fields for these options
+func (o AddTorrentOptions) formFields(magnet bool) map[string]string {
+	// Add tag to identify downloads from our Go application
+	fields := map[string]string{"tags": "go-movies"}
+	if o.Category != "" {
+		fields["category"] = o.Category
+	}
+	if o.SavePath != "" {
+		fields["savepath"] = o.SavePath
+	}
+	if o.Paused {
+		if magnet {
+			// A paused magnet never fetches metadata; stop once it has arrived instead
+			fields["stopCondition"] = "MetadataReceived"
+		} else {
+			// "paused" before qBittorrent 5, "stopped" from 5 onwards
+			fields["paused"] = "true"
+			fields["stopped"] = "true"
+		}
+	}
+	return fields
+}
+
+// QBTorrentFile represents a file inside a torrent in qBittorrent
+type QBTorrentFile struct {
+	Index    int     `json:"index"`
 	Name     string  `json:"name"`
 	Size     int64   `json:"size"`
 	Progress float64 `json:"progress"`
-	State    string  `json:"state"`
 	Priority int     `json:"priority"`
-	SavePath string  `json:"save_path"`
+}
+
+// QBTorrent represents a torrent in qBittorrent
+type QBTorrent struct {
+	Hash        string  `json:"hash"`
+	Name        string  `json:"name"`
+	Size        int64   `json:"size"`
+	Progress    float64 `json:"progress"`
+	State       string  `json:"state"`
+	Priority    int     `json:"priority"`
+	SavePath    string  `json:"save_path"`
+	ContentPath string  `json:"content_path"`
+	Ratio       float64 `json:"ratio"`
+	SeedingTime int64   `json:"seeding_time"` // in seconds
+}
+
+// DownloadPath returns where the torrent's content lives on the client's disk
+func (t QBTorrent) DownloadPath() string {
+	if t.ContentPath != "" {
+		return t.ContentPath
+	}
+	return filepath.Join(t.SavePath, t.Name)
 }
 
 // NewQBittorrentService creates a new qBittorrent service instance
 func NewQBittorrentService(baseURL, username, password string) *QBittorrentService {
+	// cookiejar.New only fails for a broken public suffix list, and we pass none
+	jar, _ := cookiejar.New(nil)
 	return &QBittorrentService{
 		BaseURL:  strings.TrimSuffix(baseURL, "/"),
 		Username: username,
 		Password: password,
 		Client: &http.Client{
 			Timeout: 30 * time.Second,
+			Jar:     jar,
 		},
 	}
 }
 
 // Login authenticates with qBittorrent WebUI
-func (q *QBittorrentService) Login() error {
-	loginURL := fmt.Sprintf("%s/api/v2/auth/login", q.BaseURL)
+func (q *QBittorrentService) Login(ctx context.Context) error {
+	q.loginMu.Lock()
+	defer q.loginMu.Unlock()
+	return q.login(ctx)
+}
+
+// login performs the login request; callers must hold loginMu
+func (q *QBittorrentService) login(ctx context.Context) error {
+	const endpoint = "/api/v2/auth/login"
 
 	data := url.Values{}
 	data.Set("username", q.Username)
 	data.Set("password", q.Password)
 
-	resp, err := q.Client.PostForm(loginURL, data)
+	req, err := http.NewRequestWithContext(ctx, "POST", q.BaseURL+endpoint, strings.NewReader(data.Encode()))
 	if err != nil {
-		return fmt.Errorf("failed to login to qBittorrent: %w", err)
+		return fmt.Errorf("failed to create login request: %w", err)
 	}
-	defer func() {
-		if err := resp.Body.Close(); err != nil {
-			log.Printf("Failed to close response body: %v", err)
-		}
-	}()
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("qBittorrent login failed with status: %d", resp.StatusCode)
+	resp, err := q.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to login to qBittorrent: %w", err)
+	}
+	body, err := readResponse(resp)
+	if err != nil {
+		return err
 	}
 
-	// Extract session cookie
-	for _, cookie := range resp.Cookies() {
-		if cookie.Name == "SID" {
-			q.Cookie = cookie.String()
-			break
-		}
+	// Wrong credentials are answered with 200 and "Fails."
+	apiErr := &APIError{Endpoint: endpoint, StatusCode: resp.StatusCode, Body: string(body)}
+	if resp.StatusCode != http.StatusOK || apiErr.Rejected() {
+		return fmt.Errorf("qBittorrent login failed: %w", apiErr)
 	}
 
-	if q.Cookie == "" {
+	if !q.hasSessionCookie() {
 		return fmt.Errorf("failed to get session cookie from qBittorrent")
 	}
 
+	q.session.Add(1)
 	log.Println("Successfully logged into qBittorrent")
 	return nil
 }
 
-// AddTorrentFile adds a torrent file to qBittorrent using the URL
-func (q *QBittorrentService) AddTorrentFile(torrentURL, category, savePath string) (string, error) {
-	// For now, use the same method as AddTorrent but with torrent file URL
-	// qBittorrent can download torrent files from URLs just like magnet URIs
-	log.Printf("Adding torrent file to qBittorrent: %s", torrentURL)
-	return q.AddTorrent(torrentURL, category, savePath)
-}
-
-// AddTorrent adds a new torrent to qBittorrent and returns the torrent hash
-func (q *QBittorrentService) AddTorrent(magnetURL, category, savePath string) (string, error) {
-	if q.Cookie == "" {
-		if err := q.Login(); err != nil {
-			return "", fmt.Errorf("failed to login: %w", err)
+// hasSessionCookie reports whether the cookie jar holds a session for BaseURL
+func (q *QBittorrentService) hasSessionCookie() bool {
+	base, err := url.Parse(q.BaseURL + "/")
+	if err != nil || q.Client.Jar == nil {
+		return false
+	}
+	for _, cookie := range q.Client.Jar.Cookies(base) {
+		if cookie.Name == "SID" {
+			return true
 		}
 	}
+	return false
+}
 
-	addURL := fmt.Sprintf("%s/api/v2/torrents/add", q.BaseURL)
+// relogin renews the session unless another request already did so since
+// the session the caller's request was made with
+func (q *QBittorrentService) relogin(ctx context.Context, staleSession uint64) error {
+	q.loginMu.Lock()
+	defer q.loginMu.Unlock()
 
-	data := url.Values{}
-	data.Set("urls", magnetURL)
-	if category != "" {
-		data.Set("category", category)
+	if q.session.Load() != staleSession {
+		return nil
 	}
-	if savePath != "" {
-		data.Set("savepath", savePath)
-	}
-	// Add tag to identify downloads from our Go application
-	data.Set("tags", "go-movies")
+	return q.login(ctx)
+}
 
-	log.Printf("Adding torrent to qBittorrent: %s", magnetURL)
+// do sends a request to the WebUI API and returns the response status and
+// body. Requests rejected with 403 are retried after logging in again, at most
+// maxAuthRetries times.
+func (q *QBittorrentService) do(ctx context.Context, method, endpoint string, body []byte, contentType string) (int, []byte, error) {
+	for attempt := 0; ; attempt++ {
+		session := q.session.Load()
+
+		var reader io.Reader
+		if body != nil {
+			reader = bytes.NewReader(body)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, q.BaseURL+endpoint, reader)
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to create %s request: %w", endpoint, err)
+		}
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+
+		resp, err := q.Client.Do(req)
+		if err != nil {
+			return 0, nil, fmt.Errorf("qBittorrent %s request failed: %w", endpoint, err)
+		}
+		respBody, err := readResponse(resp)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		if resp.StatusCode != http.StatusForbidden {
+			return resp.StatusCode, respBody, nil
+		}
+		if attempt == maxAuthRetries {
+			return 0, nil, &APIError{Endpoint: endpoint, StatusCode: resp.StatusCode, Body: string(respBody)}
+		}
 
-	req, err := http.NewRequest("POST", addURL, strings.NewReader(data.Encode()))
+		// No session yet, or it expired
+		if err := q.relogin(ctx, session); err != nil {
+			return 0, nil, fmt.Errorf("failed to re-login: %w", err)
+		}
+	}
+}
+
+// post sends a form or multipart body and fails on any answer but "Ok."
+func (q *QBittorrentService) post(ctx context.Context, endpoint string, body []byte, contentType string) error {
+	status, respBody, err := q.do(ctx, "POST", endpoint, body, contentType)
 	if err != nil {
-		return "", fmt.Errorf("failed to create add torrent request: %w", err)
+		return err
 	}
 
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	req.Header.Set("Cookie", q.Cookie)
+	apiErr := &APIError{Endpoint: endpoint, StatusCode: status, Body: string(respBody)}
+	if status != http.StatusOK || apiErr.Rejected() {
+		return apiErr
+	}
+	return nil
+}
 
-	resp, err := q.Client.Do(req)
+// postForm sends url-encoded form values
+func (q *QBittorrentService) postForm(ctx context.Context, endpoint string, data url.Values) error {
+	return q.post(ctx, endpoint, []byte(data.Encode()), "application/x-www-form-urlencoded")
+}
+
+// getJSON fetches an endpoint and decodes its JSON answer into out
+func (q *QBittorrentService) getJSON(ctx context.Context, endpoint string, out interface{}) error {
+	status, body, err := q.do(ctx, "GET", endpoint, nil, "")
 	if err != nil {
-		return "", fmt.Errorf("failed to add torrent: %w", err)
+		return err
 	}
+	if status != http.StatusOK {
+		return &APIError{Endpoint: endpoint, StatusCode: status, Body: string(body)}
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", endpoint, err)
+	}
+	return nil
+}
+
+// readResponse reads and closes a response body
+func readResponse(resp *http.Response) ([]byte, error) {
 	defer func() {
 		if err := resp.Body.Close(); err != nil {
 			log.Printf("Failed to close response body: %v", err)
 		}
 	}()
 
-	if resp.StatusCode == http.StatusForbidden {
-		// Session expired, try to login again
-		if err := q.Login(); err != nil {
-			return "", fmt.Errorf("failed to re-login: %w", err)
-		}
-		return q.AddTorrent(magnetURL, category, savePath)
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
+	return body, nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("add torrent failed with status: %d, body: %s", resp.StatusCode, string(body))
-	}
+// AddTorrentFile downloads the .torrent behind torrentURL, validates it and
+// uploads it to qBittorrent, returning its info hash. Links that redirect to a
+// magnet URI are added as magnets.
+func (q *QBittorrentService) AddTorrentFile(ctx context.Context, torrentURL string, opts AddTorrentOptions) (string, error) {
+	log.Printf("Fetching torrent file for qBittorrent: %s", torrentURL)
 
-	// Read and log the response body for debugging
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		log.Printf("Could not read response body: %v", err)
-	} else {
-		log.Printf("qBittorrent response: %s", string(body))
-	}
-
-	log.Printf("Successfully added torrent to qBittorrent")
-	
-	// Wait a moment for the torrent to be processed
-	time.Sleep(2 * time.Second)
-	
-	// Get the torrent hash by searching for recently added torrents with our tag
-	torrents, err := q.GetTorrentsByTag("go-movies")
+	download, err := FetchTorrent(ctx, q.Client, torrentURL)
 	if err != nil {
-		log.Printf("Warning: could not get torrent hash: %v", err)
-		return "", nil
-	}
-	
-	// Find the most recently added torrent (should be the one we just added)
-	var latestHash string
-	for _, torrent := range torrents {
-		// qBittorrent doesn't provide added_on in the info API, so we'll use the first one
-		// In practice, this should work since we're adding torrents one at a time
-		if latestHash == "" {
-			latestHash = torrent.Hash
-			break
-		}
+		return "", err
 	}
-	
-	if latestHash != "" {
-		log.Printf("Torrent added with hash: %s", latestHash)
-	}
-	
-	return latestHash, nil
+
+	return q.AddTorrentDownload(ctx, download, opts)
 }
 
-// GetTorrents retrieves list of all torrents
-func (q *QBittorrentService) GetTorrents() ([]QBTorrent, error) {
-	listURL := fmt.Sprintf("%s/api/v2/torrents/info", q.BaseURL)
+// AddTorrentDownload adds a torrent link fetched with FetchTorrent to
+// qBittorrent, returning its info hash
+func (q *QBittorrentService) AddTorrentDownload(ctx context.Context, download *TorrentDownload, opts AddTorrentOptions) (string, error) {
+	if download.MagnetURI != "" {
+		log.Printf("Torrent link redirected to a magnet URI")
+		return q.AddTorrent(ctx, download.MagnetURI, opts)
+	}
 
-	req, err := http.NewRequest("GET", listURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create get torrents request: %w", err)
+	meta := download.Meta
+	log.Printf("Parsed torrent '%s': %d files, %d bytes, hash %s",
+		meta.Name, len(meta.Files), meta.TotalSize, meta.InfoHash)
+
+	if err := q.uploadTorrent(ctx, download.Data, torrentFileName(meta), opts); err != nil {
+		return "", err
 	}
 
-	req.Header.Set("Cookie", q.Cookie)
+	if err := q.waitForTorrent(ctx, meta.InfoHash); err != nil {
+		return "", err
+	}
 
-	resp, err := q.Client.Do(req)
+	log.Printf("Torrent added with hash: %s", meta.InfoHash)
+	return meta.InfoHash, nil
+}
+
+// AddTorrent adds a magnet link to qBittorrent and returns its info hash
+func (q *QBittorrentService) AddTorrent(ctx context.Context, magnetURL string, opts AddTorrentOptions) (string, error) {
+	hash, err := MagnetInfoHash(magnetURL)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get torrents: %w", err)
+		return "", fmt.Errorf("cannot determine info hash: %w", err)
 	}
-	defer func() {
-		if err := resp.Body.Close(); err != nil {
-			log.Printf("Failed to close response body: %v", err)
+
+	if opts.Paused {
+		if err := q.checkStopCondition(ctx); err != nil {
+			return "", err
 		}
-	}()
+	}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("get torrents failed with status: %d", resp.StatusCode)
+	data := url.Values{}
+	data.Set("urls", magnetURL)
+	for name, value := range opts.formFields(true) {
+		data.Set(name, value)
 	}
 
-	var torrents []QBTorrent
-	if err := json.NewDecoder(resp.Body).Decode(&torrents); err != nil {
-		return nil, fmt.Errorf("failed to decode torrents response: %w", err)
+	log.Printf("Adding torrent to qBittorrent: %s", magnetURL)
+	if err := q.postForm(ctx, "/api/v2/torrents/add", data); err != nil {
+		return "", fmt.Errorf("failed to add torrent: %w", err)
 	}
 
-	return torrents, nil
+	if err := q.waitForTorrent(ctx, hash); err != nil {
+		return "", err
+	}
+
+	log.Printf("Torrent added with hash: %s", hash)
+	return hash, nil
 }
 
-// GetTorrentsByTag retrieves list of torrents with a specific tag
-func (q *QBittorrentService) GetTorrentsByTag(tag string) ([]QBTorrent, error) {
-	listURL := fmt.Sprintf("%s/api/v2/torrents/info?tag=%s", q.BaseURL, url.QueryEscape(tag))
+// checkStopCondition fails unless qBittorrent can stop a magnet once its
+// metadata arrived. Older versions start downloading a magnet right away, as
+// a paused one never fetches the file list that is to be inspected.
+func (q *QBittorrentService) checkStopCondition(ctx context.Context) error {
+	if q.supportsStopCondition.Load() {
+		return nil
+	}
 
-	req, err := http.NewRequest("GET", listURL, nil)
+	status, body, err := q.do(ctx, "GET", "/api/v2/app/version", nil, "")
 	if err != nil {
-		return nil, fmt.Errorf("failed to create get torrents request: %w", err)
+		return fmt.Errorf("failed to get qBittorrent version: %w", err)
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("failed to get qBittorrent version: %w",
+			&APIError{Endpoint: "/api/v2/app/version", StatusCode: status, Body: string(body)})
 	}
 
-	req.Header.Set("Cookie", q.Cookie)
+	version := strings.TrimSpace(string(body))
+	major, minor, ok := parseQBittorrentVersion(version)
+	if !ok {
+		return fmt.Errorf("cannot read qBittorrent version %q", version)
+	}
+	if major < stopConditionVersion[0] || (major == stopConditionVersion[0] && minor < stopConditionVersion[1]) {
+		return fmt.Errorf("qBittorrent %s would start magnets before their files are inspected; "+
+			"version %d.%d or later is required", version, stopConditionVersion[0], stopConditionVersion[1])
+	}
 
-	resp, err := q.Client.Do(req)
+	q.supportsStopCondition.Store(true)
+	return nil
+}
+
+// parseQBittorrentVersion reads the major and minor version from what
+// /api/v2/app/version answers, such as "v4.6.2" or "v5.0.0beta1"
+func parseQBittorrentVersion(version string) (major, minor int, ok bool) {
+	parts := strings.SplitN(strings.TrimPrefix(version, "v"), ".", 3)
+	if len(parts) < 2 {
+		return 0, 0, false
+	}
+	major, err := strconv.Atoi(parts[0])
 	if err != nil {
-		return nil, fmt.Errorf("failed to get torrents: %w", err)
+		return 0, 0, false
 	}
-	defer func() {
-		if err := resp.Body.Close(); err != nil {
-			log.Printf("Failed to close response body: %v", err)
-		}
-	}()
+	minor, err = strconv.Atoi(strings.TrimRightFunc(parts[1], func(r rune) bool { return r < '0' || r > '9' }))
+	if err != nil {
+		return 0, 0, false
+	}
+	return major, minor, true
+}
+
+// uploadTorrent uploads .torrent content to qBittorrent as multipart form data
+func (q *QBittorrentService) uploadTorrent(ctx context.Context, data []byte, fileName string, opts AddTorrentOptions) error {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("get torrents failed with status: %d", resp.StatusCode)
+	part, err := writer.CreateFormFile("torrents", fileName)
+	if err != nil {
+		return fmt.Errorf("failed to create torrent form part: %w", err)
+	}
+	if _, err := part.Write(data); err != nil {
+		return fmt.Errorf("failed to write torrent form part: %w", err)
 	}
 
-	var torrents []QBTorrent
-	if err := json.NewDecoder(resp.Body).Decode(&torrents); err != nil {
-		return nil, fmt.Errorf("failed to decode torrents response: %w", err)
+	for name, value := range opts.formFields(false) {
+		if err := writer.WriteField(name, value); err != nil {
+			return fmt.Errorf("failed to write form field %s: %w", name, err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to finish torrent upload form: %w", err)
 	}
 
-	return torrents, nil
+	log.Printf("Uploading torrent file to qBittorrent: %s", fileName)
+	if err := q.post(ctx, "/api/v2/torrents/add", body.Bytes(), writer.FormDataContentType()); err != nil {
+		return fmt.Errorf("failed to upload torrent: %w", err)
+	}
+	return nil
 }
 
-// RemoveTorrent removes a torrent from qBittorrent
-func (q *QBittorrentService) RemoveTorrent(hash string, deleteFiles bool) error {
-	if q.Cookie == "" {
-		if err := q.Login(); err != nil {
-			return fmt.Errorf("failed to login: %w", err)
+// waitForTorrent polls qBittorrent until a torrent with the given hash shows up
+func (q *QBittorrentService) waitForTorrent(ctx context.Context, hash string) error {
+	for attempt := 0; attempt < torrentVerifyAttempts; attempt++ {
+		torrent, err := q.GetTorrent(ctx, hash)
+		if err != nil {
+			return err
+		}
+		if torrent != nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(torrentVerifyInterval):
 		}
 	}
 
-	deleteURL := fmt.Sprintf("%s/api/v2/torrents/delete", q.BaseURL)
+	return fmt.Errorf("torrent %s not found in qBittorrent after adding", hash)
+}
 
-	data := url.Values{}
-	data.Set("hashes", hash)
-	data.Set("deleteFiles", fmt.Sprintf("%t", deleteFiles))
+// GetTorrent returns the torrent with the given info hash, or nil if
+// qBittorrent does not know about it
+func (q *QBittorrentService) GetTorrent(ctx context.Context, hash string) (*QBTorrent, error) {
+	var torrents []QBTorrent
+	if err := q.getJSON(ctx, "/api/v2/torrents/info?hashes="+url.QueryEscape(hash), &torrents); err != nil {
+		return nil, fmt.Errorf("failed to get torrent: %w", err)
+	}
 
-	req, err := http.NewRequest("POST", deleteURL, strings.NewReader(data.Encode()))
-	if err != nil {
-		return fmt.Errorf("failed to create delete torrent request: %w", err)
+	for i := range torrents {
+		if strings.EqualFold(torrents[i].Hash, hash) {
+			return &torrents[i], nil
+		}
 	}
 
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	req.Header.Set("Cookie", q.Cookie)
+	return nil, nil
+}
 
-	resp, err := q.Client.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to delete torrent: %w", err)
+// GetTorrents retrieves list of all torrents
+func (q *QBittorrentService) GetTorrents(ctx context.Context) ([]QBTorrent, error) {
+	var torrents []QBTorrent
+	if err := q.getJSON(ctx, "/api/v2/torrents/info", &torrents); err != nil {
+		return nil, fmt.Errorf("failed to get torrents: %w", err)
 	}
-	defer func() {
-		if err := resp.Body.Close(); err != nil {
-			log.Printf("Failed to close response body: %v", err)
+	return torrents, nil
+}
+
+// GetTorrentsByTag retrieves list of torrents with a specific tag
+func (q *QBittorrentService) GetTorrentsByTag(ctx context.Context, tag string) ([]QBTorrent, error) {
+	var torrents []QBTorrent
+	if err := q.getJSON(ctx, "/api/v2/torrents/info?tag="+url.QueryEscape(tag), &torrents); err != nil {
+		return nil, fmt.Errorf("failed to get torrents: %w", err)
+	}
+	return torrents, nil
+}
+
+// GetTorrentFiles retrieves the file list of a torrent. The list is empty
+// until a magnet's metadata has been received.
+func (q *QBittorrentService) GetTorrentFiles(ctx context.Context, hash string) ([]QBTorrentFile, error) {
+	var files []QBTorrentFile
+	if err := q.getJSON(ctx, "/api/v2/torrents/files?hash="+url.QueryEscape(hash), &files); err != nil {
+		return nil, fmt.Errorf("failed to get torrent files: %w", err)
+	}
+	return files, nil
+}
+
+// ResumeTorrent starts a paused torrent
+func (q *QBittorrentService) ResumeTorrent(ctx context.Context, hash string) error {
+	data := url.Values{}
+	data.Set("hashes", hash)
+
+	// qBittorrent 5 renamed "resume" to "start"
+	for _, endpoint := range []string{"/api/v2/torrents/resume", "/api/v2/torrents/start"} {
+		status, body, err := q.do(ctx, "POST", endpoint, []byte(data.Encode()), "application/x-www-form-urlencoded")
+		if err != nil {
+			return fmt.Errorf("failed to resume torrent: %w", err)
 		}
-	}()
 
-	if resp.StatusCode == http.StatusForbidden {
-		// Session expired, try to login again
-		if err := q.Login(); err != nil {
-			return fmt.Errorf("failed to re-login: %w", err)
+		switch status {
+		case http.StatusOK:
+			log.Printf("Resumed torrent %s", hash)
+			return nil
+		case http.StatusNotFound:
+			continue
+		default:
+			return fmt.Errorf("failed to resume torrent: %w",
+				&APIError{Endpoint: endpoint, StatusCode: status, Body: string(body)})
 		}
-		return q.RemoveTorrent(hash, deleteFiles)
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("delete torrent failed with status: %d, body: %s", resp.StatusCode, string(body))
+	return fmt.Errorf("qBittorrent does not support resuming torrents")
+}
+
+// RemoveTorrent removes a torrent from qBittorrent
+func (q *QBittorrentService) RemoveTorrent(ctx context.Context, hash string, deleteFiles bool) error {
+	data := url.Values{}
+	data.Set("hashes", hash)
+	data.Set("deleteFiles", fmt.Sprintf("%t", deleteFiles))
+
+	if err := q.postForm(ctx, "/api/v2/torrents/delete", data); err != nil {
+		return fmt.Errorf("failed to delete torrent: %w", err)
 	}
 
 	log.Printf("Successfully removed torrent %s from qBittorrent (deleteFiles=%t)", hash, deleteFiles)
 	return nil
 }
 
-// TestConnection tests the connection to qBittorrent
-func (q *QBittorrentService) TestConnection() error {
-	if err := q.Login(); err != nil {
-		return err
-	}
+// FilePriorityDoNotDownload is the qBittorrent file priority that skips a file
+const FilePriorityDoNotDownload = 0
 
-	// Try to get application version as a test
-	versionURL := fmt.Sprintf("%s/api/v2/app/version", q.BaseURL)
+// SetFilePriority sets the download priority of the files with the given
+// indexes, as reported by GetTorrentFiles
+func (q *QBittorrentService) SetFilePriority(ctx context.Context, hash string, indexes []int, priority int) error {
+	if len(indexes) == 0 {
+		return nil
+	}
 
-	req, err := http.NewRequest("GET", versionURL, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+	ids := make([]string, len(indexes))
+	for i, index := range indexes {
+		ids[i] = strconv.Itoa(index)
 	}
 
-	req.Header.Set("Cookie", q.Cookie)
+	data := url.Values{}
+	data.Set("hash", hash)
+	data.Set("id", strings.Join(ids, "|"))
+	data.Set("priority", strconv.Itoa(priority))
 
-	resp, err := q.Client.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to test connection: %w", err)
+	if err := q.postForm(ctx, "/api/v2/torrents/filePrio", data); err != nil {
+		return fmt.Errorf("failed to set file priority: %w", err)
 	}
-	defer func() {
-		if err := resp.Body.Close(); err != nil {
-			log.Printf("Failed to close response body: %v", err)
-		}
-	}()
+	return nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("qBittorrent connection test failed with status: %d", resp.StatusCode)
+// TestConnection tests the connection to qBittorrent
+func (q *QBittorrentService) TestConnection(ctx context.Context) error {
+	if err := q.Login(ctx); err != nil {
+		return err
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	// Try to get application version as a test
+	status, body, err := q.do(ctx, "GET", "/api/v2/app/version", nil, "")
 	if err != nil {
-		return fmt.Errorf("failed to read response body: %w", err)
+		return fmt.Errorf("failed to test connection: %w", err)
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("qBittorrent connection test failed with status: %d", status)
 	}
 
 	log.Printf("qBittorrent connection successful, version: %s", string(body))
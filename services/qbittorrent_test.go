@@ -0,0 +1,328 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeQBittorrent is a minimal in-memory qBittorrent WebUI
+type fakeQBittorrent struct {
+	mu       sync.Mutex
+	torrents []QBTorrent
+	// hashForURL maps an added URL to the torrent hash it produces
+	hashForURL map[string]string
+	// sid is the current session; requests without it are answered with 403
+	sid    string
+	logins int
+	// rejectAdds makes every add answer "Fails."
+	rejectAdds bool
+	// version is what /api/v2/app/version answers
+	version string
+}
+
+// expireSession invalidates the current session cookie
+func (f *fakeQBittorrent) expireSession() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sid = ""
+}
+
+func (f *fakeQBittorrent) loginCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.logins
+}
+
+func (f *fakeQBittorrent) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/auth/login", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if r.FormValue("password") != "secret" {
+			_, _ = w.Write([]byte("Fails."))
+			return
+		}
+		f.logins++
+		f.sid = fmt.Sprintf("session-%d", f.logins)
+		http.SetCookie(w, &http.Cookie{Name: "SID", Value: f.sid, Path: "/"})
+		_, _ = w.Write([]byte("Ok."))
+	})
+	mux.HandleFunc("/api/v2/torrents/add", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		if f.rejectAdds {
+			_, _ = w.Write([]byte("Fails."))
+			return
+		}
+
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+			file, _, err := r.FormFile("torrents")
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			data, _ := io.ReadAll(file)
+			meta, err := ParseTorrentFile(data)
+			if err != nil {
+				_, _ = w.Write([]byte("Fails."))
+				return
+			}
+			f.torrents = append(f.torrents, QBTorrent{Hash: meta.InfoHash, Name: meta.Name})
+			_, _ = w.Write([]byte("Ok."))
+			return
+		}
+
+		if err := r.ParseForm(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if hash, ok := f.hashForURL[r.FormValue("urls")]; ok {
+			f.torrents = append(f.torrents, QBTorrent{Hash: hash, Name: hash})
+		}
+		_, _ = w.Write([]byte("Ok."))
+	})
+	mux.HandleFunc("/api/v2/app/version", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(f.version))
+	})
+	mux.HandleFunc("/api/v2/torrents/info", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		result := []QBTorrent{}
+		hashes := r.URL.Query().Get("hashes")
+		for _, torrent := range f.torrents {
+			if hashes == "" || strings.Contains(hashes, torrent.Hash) {
+				result = append(result, torrent)
+			}
+		}
+		_ = json.NewEncoder(w).Encode(result)
+	})
+
+	// Everything but login requires a valid session
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/auth/login" {
+			f.mu.Lock()
+			cookie, err := r.Cookie("SID")
+			valid := err == nil && f.sid != "" && cookie.Value == f.sid
+			f.mu.Unlock()
+			if !valid {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+func TestQBittorrentService_AddTorrent_ReturnsMagnetHash(t *testing.T) {
+	const existing = "1111111111111111111111111111111111111111"
+	const wanted = "c12fe1c06bba254a9dc9f519b335aa7c1367a88a"
+	magnet := "magnet:?xt=urn:btih:C12FE1C06BBA254A9DC9F519B335AA7C1367A88A"
+
+	fake := &fakeQBittorrent{
+		torrents:   []QBTorrent{{Hash: existing, Name: "Another Movie"}},
+		hashForURL: map[string]string{magnet: wanted},
+	}
+	server := httptest.NewServer(fake.handler())
+	defer server.Close()
+
+	q := NewQBittorrentService(server.URL, "admin", "secret")
+	hash, err := q.AddTorrent(context.Background(), magnet, AddTorrentOptions{Category: "movies"})
+	assert.NoError(t, err)
+	assert.Equal(t, wanted, hash)
+}
+
+func TestQBittorrentService_AddTorrent_PausedMagnetNeedsStopCondition(t *testing.T) {
+	const hash = "c12fe1c06bba254a9dc9f519b335aa7c1367a88a"
+	magnet := "magnet:?xt=urn:btih:" + hash
+
+	tests := []struct {
+		version string
+		wantErr bool
+	}{
+		{"v4.5.5", true},
+		{"v4.6.2", false},
+		{"v5.0.0beta1", false},
+		{"unknown", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			fake := &fakeQBittorrent{hashForURL: map[string]string{magnet: hash}, version: tt.version}
+			server := httptest.NewServer(fake.handler())
+			defer server.Close()
+
+			q := NewQBittorrentService(server.URL, "admin", "secret")
+			_, err := q.AddTorrent(context.Background(), magnet, AddTorrentOptions{Paused: true})
+			if tt.wantErr {
+				// Older versions would start downloading the magnet right away
+				assert.Error(t, err)
+				assert.Empty(t, fake.torrents)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, fake.torrents, 1)
+			}
+		})
+	}
+}
+
+func TestQBittorrentService_AddTorrent_InvalidMagnet(t *testing.T) {
+	q := NewQBittorrentService("http://127.0.0.1:0", "admin", "secret")
+	_, err := q.AddTorrent(context.Background(), "magnet:?dn=NoHash", AddTorrentOptions{Category: "movies"})
+	assert.Error(t, err)
+}
+
+func TestQBittorrentService_AddTorrentFile_Uploads(t *testing.T) {
+	torrent := buildTestTorrent("Test.Movie.2023", map[string]int64{"Test.Movie.2023.mkv": 4096})
+	meta, err := ParseTorrentFile(torrent)
+	assert.NoError(t, err)
+
+	fake := &fakeQBittorrent{}
+	server := httptest.NewServer(fake.handler())
+	defer server.Close()
+
+	indexer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(torrent)
+	}))
+	defer indexer.Close()
+
+	q := NewQBittorrentService(server.URL, "admin", "secret")
+	hash, err := q.AddTorrentFile(context.Background(), indexer.URL+"/dl/file.torrent", AddTorrentOptions{Category: "movies"})
+	assert.NoError(t, err)
+	assert.Equal(t, meta.InfoHash, hash)
+}
+
+func TestQBittorrentService_AddTorrentFile_FollowsMagnetRedirect(t *testing.T) {
+	const wanted = "c12fe1c06bba254a9dc9f519b335aa7c1367a88a"
+	magnet := "magnet:?xt=urn:btih:" + wanted + "&dn=Test"
+
+	fake := &fakeQBittorrent{hashForURL: map[string]string{magnet: wanted}}
+	server := httptest.NewServer(fake.handler())
+	defer server.Close()
+
+	indexer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Location", magnet)
+		w.WriteHeader(http.StatusFound)
+	}))
+	defer indexer.Close()
+
+	q := NewQBittorrentService(server.URL, "admin", "secret")
+	hash, err := q.AddTorrentFile(context.Background(), indexer.URL, AddTorrentOptions{Category: "movies"})
+	assert.NoError(t, err)
+	assert.Equal(t, wanted, hash)
+}
+
+func TestQBittorrentService_ReloginOnceForConcurrentRequests(t *testing.T) {
+	fake := &fakeQBittorrent{}
+	server := httptest.NewServer(fake.handler())
+	defer server.Close()
+
+	q := NewQBittorrentService(server.URL, "admin", "secret")
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := q.GetTorrents(ctx)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, fake.loginCount())
+
+	// An expired session is renewed transparently
+	fake.expireSession()
+	_, err := q.GetTorrentsByTag(ctx, "go-movies")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, fake.loginCount())
+}
+
+func TestQBittorrentService_BoundedAuthRetries(t *testing.T) {
+	logins := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/auth/login", func(w http.ResponseWriter, _ *http.Request) {
+		logins++
+		http.SetCookie(w, &http.Cookie{Name: "SID", Value: "session", Path: "/"})
+		_, _ = w.Write([]byte("Ok."))
+	})
+	mux.HandleFunc("/api/v2/torrents/info", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	q := NewQBittorrentService(server.URL, "admin", "secret")
+	_, err := q.GetTorrents(context.Background())
+
+	var apiErr *APIError
+	if assert.True(t, errors.As(err, &apiErr)) {
+		assert.Equal(t, http.StatusForbidden, apiErr.StatusCode)
+	}
+	assert.Equal(t, maxAuthRetries, logins)
+}
+
+func TestQBittorrentService_RejectedRequests(t *testing.T) {
+	fake := &fakeQBittorrent{rejectAdds: true}
+	server := httptest.NewServer(fake.handler())
+	defer server.Close()
+
+	q := NewQBittorrentService(server.URL, "admin", "secret")
+	_, err := q.AddTorrent(context.Background(), "magnet:?xt=urn:btih:c12fe1c06bba254a9dc9f519b335aa7c1367a88a", AddTorrentOptions{})
+	assert.ErrorIs(t, err, ErrRequestRejected)
+
+	// Wrong credentials are answered with "Fails." too
+	q = NewQBittorrentService(server.URL, "admin", "wrong")
+	err = q.Login(context.Background())
+	assert.ErrorIs(t, err, ErrRequestRejected)
+}
+
+func TestQBittorrentService_HonorsContext(t *testing.T) {
+	fake := &fakeQBittorrent{}
+	server := httptest.NewServer(fake.handler())
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	q := NewQBittorrentService(server.URL, "admin", "secret")
+	_, err := q.GetTorrents(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestQBittorrentService_SetFilePriority(t *testing.T) {
+	var form url.Values
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/auth/login", func(w http.ResponseWriter, _ *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "SID", Value: "session", Path: "/"})
+		_, _ = w.Write([]byte("Ok."))
+	})
+	mux.HandleFunc("/api/v2/torrents/filePrio", func(w http.ResponseWriter, r *http.Request) {
+		if _, err := r.Cookie("SID"); err != nil {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_ = r.ParseForm()
+		form = r.PostForm
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	q := NewQBittorrentService(server.URL, "admin", "secret")
+	assert.NoError(t, q.SetFilePriority(context.Background(), "abc", []int{1, 3}, FilePriorityDoNotDownload))
+	assert.Equal(t, "abc", form.Get("hash"))
+	assert.Equal(t, "1|3", form.Get("id"))
+	assert.Equal(t, "0", form.Get("priority"))
+}
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
+	"strconv"
 	"time"
 
 	"media/models"
@@ -19,16 +21,17 @@ type TMDBService struct {
 
 // TMDBMovie represents a movie response from TMDB API
 type TMDBMovie struct {
-	ID          int         `json:"id"`
-	Title       string      `json:"title"`
-	Overview    string      `json:"overview"`
-	ReleaseDate string      `json:"release_date"`
-	PosterPath  string      `json:"poster_path"`
-	VoteAverage float64     `json:"vote_average"`
-	Runtime     int         `json:"runtime"`
-	Genres      []Genre     `json:"genres"`
-	Credits     Credits     `json:"credits"`
-	ExternalIDs ExternalIDs `json:"external_ids"`
+	ID           int         `json:"id"`
+	Title        string      `json:"title"`
+	Overview     string      `json:"overview"`
+	ReleaseDate  string      `json:"release_date"`
+	PosterPath   string      `json:"poster_path"`
+	BackdropPath string      `json:"backdrop_path"`
+	VoteAverage  float64     `json:"vote_average"`
+	Runtime      int         `json:"runtime"`
+	Genres       []Genre     `json:"genres"`
+	Credits      Credits     `json:"credits"`
+	ExternalIDs  ExternalIDs `json:"external_ids"`
 }
 
 // Genre represents a movie genre from TMDB
@@ -53,6 +56,42 @@ type ExternalIDs struct {
 	IMDBID string `json:"imdb_id"`
 }
 
+// TMDBSearchResult is a movie returned by a TMDB search
+type TMDBSearchResult struct {
+	ID            int     `json:"id"`
+	Title         string  `json:"title"`
+	OriginalTitle string  `json:"original_title"`
+	ReleaseDate   string  `json:"release_date"`
+	Popularity    float64 `json:"popularity"`
+}
+
+// TMDBImage is a poster or backdrop listed for a movie
+type TMDBImage struct {
+	FilePath    string  `json:"file_path"`
+	Width       int     `json:"width"`
+	Height      int     `json:"height"`
+	VoteAverage float64 `json:"vote_average"`
+}
+
+// TMDBArtwork holds the URLs of a movie's best rated poster and backdrop in
+// their original size; either may be empty
+type TMDBArtwork struct {
+	PosterURL string
+	FanartURL string
+}
+
+// Year returns the release year, or 0 if the release date is unknown
+func (r TMDBSearchResult) Year() int {
+	if len(r.ReleaseDate) < 4 {
+		return 0
+	}
+	year, err := strconv.Atoi(r.ReleaseDate[:4])
+	if err != nil {
+		return 0
+	}
+	return year
+}
+
 // NewTMDBService creates a new TMDB service instance
 func NewTMDBService(apiKey string) *TMDBService {
 	return &TMDBService{
@@ -91,6 +130,88 @@ func (t *TMDBService) GetMovie(tmdbID int) (*models.Movie, error) {
 	return t.convertToMovie(tmdbMovie), nil
 }
 
+// SearchMovies searches TMDB for movies by title, narrowed to a release year
+// when year is non-zero. Results are in TMDB's relevance order.
+func (t *TMDBService) SearchMovies(query string, year int) ([]TMDBSearchResult, error) {
+	params := url.Values{}
+	params.Set("api_key", t.apiKey)
+	params.Set("query", query)
+	if year > 0 {
+		params.Set("year", strconv.Itoa(year))
+	}
+
+	resp, err := t.client.Get("https://api.themoviedb.org/3/search/movie?" + params.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("failed to search TMDB: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Printf("Failed to close response body: %v", err)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("TMDB API returned status %d", resp.StatusCode)
+	}
+
+	var page struct {
+		Results []TMDBSearchResult `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, fmt.Errorf("failed to decode TMDB search response: %w", err)
+	}
+
+	return page.Results, nil
+}
+
+// GetArtwork looks up the best rated English or textless poster and backdrop
+// of a movie
+func (t *TMDBService) GetArtwork(tmdbID int) (*TMDBArtwork, error) {
+	url := fmt.Sprintf("https://api.themoviedb.org/3/movie/%d/images?api_key=%s&"+
+		"include_image_language=en,null", tmdbID, t.apiKey)
+
+	resp, err := t.client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch images from TMDB: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Printf("Failed to close response body: %v", err)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("TMDB API returned status %d", resp.StatusCode)
+	}
+
+	var images struct {
+		Posters   []TMDBImage `json:"posters"`
+		Backdrops []TMDBImage `json:"backdrops"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&images); err != nil {
+		return nil, fmt.Errorf("failed to decode TMDB images response: %w", err)
+	}
+
+	return &TMDBArtwork{
+		PosterURL: bestImageURL(images.Posters),
+		FanartURL: bestImageURL(images.Backdrops),
+	}, nil
+}
+
+// bestImageURL returns the original size URL of the highest voted image
+func bestImageURL(images []TMDBImage) string {
+	var best *TMDBImage
+	for i := range images {
+		if best == nil || images[i].VoteAverage > best.VoteAverage {
+			best = &images[i]
+		}
+	}
+	if best == nil || best.FilePath == "" {
+		return ""
+	}
+	return "https://image.tmdb.org/t/p/original" + best.FilePath
+}
+
 func (t *TMDBService) convertToMovie(tmdbMovie TMDBMovie) *models.Movie {
 	movie := &models.Movie{
 		Title:       tmdbMovie.Title,
@@ -115,6 +236,9 @@ func (t *TMDBService) convertToMovie(tmdbMovie TMDBMovie) *models.Movie {
 	if tmdbMovie.PosterPath != "" {
 		movie.Poster = fmt.Sprintf("https://image.tmdb.org/t/p/w500%s", tmdbMovie.PosterPath)
 	}
+	if tmdbMovie.BackdropPath != "" {
+		movie.Backdrop = fmt.Sprintf("https://image.tmdb.org/t/p/w1280%s", tmdbMovie.BackdropPath)
+	}
 
 	// Extract genres
 	if len(tmdbMovie.Genres) > 0 {
@@ -0,0 +1,242 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// maxTorrentFileSize caps how much we read when fetching a .torrent file
+const maxTorrentFileSize = 10 * 1024 * 1024
+
+// maxTorrentRedirects caps how many redirects FetchTorrent follows
+const maxTorrentRedirects = 10
+
+// TorrentFile is a single file inside a torrent
+type TorrentFile struct {
+	Path   string `json:"path"`
+	Length int64  `json:"length"`
+}
+
+// TorrentMeta is the parsed content of a .torrent file
+type TorrentMeta struct {
+	InfoHash  string        `json:"info_hash"`
+	Name      string        `json:"name"`
+	Files     []TorrentFile `json:"files"`
+	TotalSize int64         `json:"total_size"`
+	Private   bool          `json:"private"`
+}
+
+// TorrentDownload is what a torrent link resolved to: either .torrent data
+// with its parsed metadata, or a magnet URI the indexer redirected to
+type TorrentDownload struct {
+	Data      []byte
+	Meta      *TorrentMeta
+	MagnetURI string
+}
+
+// ParseTorrentFile decodes and validates a .torrent file
+func ParseTorrentFile(data []byte) (*TorrentMeta, error) {
+	value, err := DecodeBencode(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid torrent file: %w", err)
+	}
+
+	root, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("invalid torrent file: top level is not a dictionary")
+	}
+	info, ok := root["info"].(map[string]interface{})
+	if !ok {
+		return nil, errors.New("invalid torrent file: missing info dictionary")
+	}
+
+	name, ok := info["name"].(string)
+	if !ok || strings.TrimSpace(name) == "" {
+		return nil, errors.New("invalid torrent file: missing name")
+	}
+	if !isSafePathElement(name) {
+		return nil, fmt.Errorf("invalid torrent file: unsafe name %q", name)
+	}
+
+	if pieceLength, ok := info["piece length"].(int64); !ok || pieceLength <= 0 {
+		return nil, errors.New("invalid torrent file: missing piece length")
+	}
+	if pieces, ok := info["pieces"].(string); !ok || len(pieces) == 0 || len(pieces)%20 != 0 {
+		return nil, errors.New("invalid torrent file: malformed pieces")
+	}
+
+	hash, err := TorrentInfoHash(data)
+	if err != nil {
+		return nil, err
+	}
+
+	meta := &TorrentMeta{
+		InfoHash: hash,
+		Name:     name,
+	}
+	if private, ok := info["private"].(int64); ok && private == 1 {
+		meta.Private = true
+	}
+
+	if length, ok := info["length"].(int64); ok {
+		// Single-file torrent
+		if length < 0 {
+			return nil, errors.New("invalid torrent file: negative length")
+		}
+		meta.Files = []TorrentFile{{Path: name, Length: length}}
+		meta.TotalSize = length
+		return meta, nil
+	}
+
+	files, ok := info["files"].([]interface{})
+	if !ok || len(files) == 0 {
+		return nil, errors.New("invalid torrent file: no files")
+	}
+
+	for i, raw := range files {
+		file, err := parseTorrentFileEntry(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid torrent file: file %d: %w", i, err)
+		}
+		meta.Files = append(meta.Files, *file)
+		meta.TotalSize += file.Length
+	}
+
+	return meta, nil
+}
+
+// parseTorrentFileEntry parses one entry of a multi-file torrent's files list
+func parseTorrentFileEntry(raw interface{}) (*TorrentFile, error) {
+	entry, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("entry is not a dictionary")
+	}
+
+	length, ok := entry["length"].(int64)
+	if !ok || length < 0 {
+		return nil, errors.New("missing or negative length")
+	}
+
+	parts, ok := entry["path"].([]interface{})
+	if !ok || len(parts) == 0 {
+		return nil, errors.New("missing path")
+	}
+
+	elements := make([]string, 0, len(parts))
+	for _, part := range parts {
+		element, ok := part.(string)
+		if !ok || !isSafePathElement(element) {
+			return nil, fmt.Errorf("unsafe path element %v", part)
+		}
+		elements = append(elements, element)
+	}
+
+	return &TorrentFile{Path: path.Join(elements...), Length: length}, nil
+}
+
+// isSafePathElement rejects empty, relative or separator-containing path elements
+func isSafePathElement(element string) bool {
+	if element == "" || element == "." || element == ".." {
+		return false
+	}
+	return !strings.ContainsAny(element, "/\\\x00")
+}
+
+// FetchTorrent downloads a torrent link. Indexers such as Jackett sometimes
+// answer with a redirect to a magnet URI; that is returned in MagnetURI
+// instead of following it. The client's cookie jar is not used: cookies
+// ignore the port, so a qBittorrent session could leak to an indexer on the
+// same host.
+func FetchTorrent(ctx context.Context, client *http.Client, torrentURL string) (*TorrentDownload, error) {
+	noRedirects := *client
+	noRedirects.Jar = nil
+	noRedirects.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	current := torrentURL
+	for redirects := 0; redirects <= maxTorrentRedirects; redirects++ {
+		if strings.HasPrefix(current, "magnet:") {
+			return &TorrentDownload{MagnetURI: current}, nil
+		}
+
+		req, err := http.NewRequestWithContext(ctx, "GET", current, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create torrent file request: %w", err)
+		}
+
+		resp, err := noRedirects.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch torrent file: %w", err)
+		}
+
+		location, data, err := readTorrentResponse(resp)
+		if err != nil {
+			return nil, err
+		}
+		if location == "" {
+			meta, err := ParseTorrentFile(data)
+			if err != nil {
+				return nil, err
+			}
+			return &TorrentDownload{Data: data, Meta: meta}, nil
+		}
+
+		next, err := resp.Request.URL.Parse(location)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redirect location %q: %w", location, err)
+		}
+		current = next.String()
+		if next.Scheme == "magnet" {
+			// url.URL re-encoding can mangle magnet parameters, keep the original
+			current = location
+		}
+	}
+
+	return nil, fmt.Errorf("too many redirects fetching torrent file")
+}
+
+// readTorrentResponse returns the redirect location, or the body of a successful response
+func readTorrentResponse(resp *http.Response) (string, []byte, error) {
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Printf("Failed to close response body: %v", err)
+		}
+	}()
+
+	switch {
+	case resp.StatusCode >= 300 && resp.StatusCode < 400:
+		location := resp.Header.Get("Location")
+		if location == "" {
+			return "", nil, fmt.Errorf("redirect with status %d has no location", resp.StatusCode)
+		}
+		return location, nil, nil
+	case resp.StatusCode != http.StatusOK:
+		return "", nil, fmt.Errorf("torrent file download failed with status: %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxTorrentFileSize+1))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read torrent file: %w", err)
+	}
+	if len(data) > maxTorrentFileSize {
+		return "", nil, fmt.Errorf("torrent file exceeds %d bytes", maxTorrentFileSize)
+	}
+
+	return "", data, nil
+}
+
+// torrentFileName returns a file name for uploading a torrent
+func torrentFileName(meta *TorrentMeta) string {
+	name := SanitizeFileName(meta.Name)
+	if name == "" {
+		name = meta.InfoHash
+	}
+	return name + ".torrent"
+}
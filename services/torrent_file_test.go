@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// buildTestTorrent returns a bencoded .torrent with the given name and files.
+// A single file whose path equals name produces a single-file torrent.
+func buildTestTorrent(name string, files map[string]int64) []byte {
+	pieces := strings.Repeat("a", 20)
+	var info string
+	if length, ok := files[name]; ok && len(files) == 1 {
+		info = fmt.Sprintf("d6:lengthi%de4:name%d:%s12:piece lengthi16384e6:pieces20:%se",
+			length, len(name), name, pieces)
+	} else {
+		var list strings.Builder
+		for path, length := range files {
+			var elements strings.Builder
+			for _, element := range strings.Split(path, "/") {
+				fmt.Fprintf(&elements, "%d:%s", len(element), element)
+			}
+			fmt.Fprintf(&list, "d6:lengthi%de4:pathl%see", length, elements.String())
+		}
+		info = fmt.Sprintf("d5:filesl%se4:name%d:%s12:piece lengthi16384e6:pieces20:%se",
+			list.String(), len(name), name, pieces)
+	}
+	return []byte("d8:announce14:http://tracker4:info" + info + "e")
+}
+
+func TestParseTorrentFile_SingleFile(t *testing.T) {
+	data := buildTestTorrent("Test.Movie.2023.mkv", map[string]int64{"Test.Movie.2023.mkv": 1024})
+
+	meta, err := ParseTorrentFile(data)
+	assert.NoError(t, err)
+	assert.Equal(t, "Test.Movie.2023.mkv", meta.Name)
+	assert.Equal(t, int64(1024), meta.TotalSize)
+	assert.Equal(t, []TorrentFile{{Path: "Test.Movie.2023.mkv", Length: 1024}}, meta.Files)
+	assert.Len(t, meta.InfoHash, 40)
+}
+
+func TestParseTorrentFile_MultiFile(t *testing.T) {
+	data := buildTestTorrent("Test.Movie.2023", map[string]int64{
+		"Test.Movie.2023.mkv": 4096,
+		"Subs/English.srt":    64,
+		"Sample/sample.mkv":   512,
+	})
+
+	meta, err := ParseTorrentFile(data)
+	assert.NoError(t, err)
+	assert.Equal(t, "Test.Movie.2023", meta.Name)
+	assert.Equal(t, int64(4672), meta.TotalSize)
+	assert.Len(t, meta.Files, 3)
+	assert.Contains(t, meta.Files, TorrentFile{Path: "Subs/English.srt", Length: 64})
+}
+
+func TestParseTorrentFile_Invalid(t *testing.T) {
+	cases := map[string]string{
+		"not bencode":    "hello",
+		"no info":        "d8:announce14:http://trackere",
+		"no name":        "d4:infod6:lengthi1e12:piece lengthi1e6:pieces20:aaaaaaaaaaaaaaaaaaaaee",
+		"bad pieces":     "d4:infod6:lengthi1e4:name1:a12:piece lengthi1e6:pieces3:abcee",
+		"no files":       "d4:infod4:name1:a12:piece lengthi1e6:pieces20:aaaaaaaaaaaaaaaaaaaaee",
+		"path traversal": "d4:infod5:filesld6:lengthi1e4:pathl2:..6:passwdeee4:name1:a12:piece lengthi1e6:pieces20:aaaaaaaaaaaaaaaaaaaaee",
+	}
+	for name, input := range cases {
+		_, err := ParseTorrentFile([]byte(input))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestFetchTorrent_TorrentFile(t *testing.T) {
+	data := buildTestTorrent("Test.Movie.2023.mkv", map[string]int64{"Test.Movie.2023.mkv": 2048})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/dl" {
+			http.Redirect(w, r, "/file.torrent", http.StatusFound)
+			return
+		}
+		_, _ = w.Write(data)
+	}))
+	defer server.Close()
+
+	download, err := FetchTorrent(context.Background(), http.DefaultClient, server.URL+"/dl")
+	assert.NoError(t, err)
+	assert.Empty(t, download.MagnetURI)
+	assert.Equal(t, data, download.Data)
+	assert.Equal(t, "Test.Movie.2023.mkv", download.Meta.Name)
+}
+
+func TestFetchTorrent_DoesNotSendClientCookies(t *testing.T) {
+	data := buildTestTorrent("Test.Movie.2023.mkv", map[string]int64{"Test.Movie.2023.mkv": 2048})
+
+	var cookies []*http.Cookie
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cookies = r.Cookies()
+		_, _ = w.Write(data)
+	}))
+	defer server.Close()
+
+	// A qBittorrent session on the same host, but another port
+	jar, err := cookiejar.New(nil)
+	assert.NoError(t, err)
+	serverURL, err := url.Parse(server.URL)
+	assert.NoError(t, err)
+	jar.SetCookies(&url.URL{Scheme: "http", Host: serverURL.Hostname() + ":8080"},
+		[]*http.Cookie{{Name: "SID", Value: "session", Path: "/"}})
+
+	_, err = FetchTorrent(context.Background(), &http.Client{Jar: jar}, server.URL)
+	assert.NoError(t, err)
+	assert.Empty(t, cookies)
+}
+
+func TestFetchTorrent_MagnetRedirect(t *testing.T) {
+	magnet := "magnet:?xt=urn:btih:c12fe1c06bba254a9dc9f519b335aa7c1367a88a&dn=Test+Movie&tr=udp%3A%2F%2Ftracker"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Location", magnet)
+		w.WriteHeader(http.StatusFound)
+	}))
+	defer server.Close()
+
+	download, err := FetchTorrent(context.Background(), http.DefaultClient, server.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, magnet, download.MagnetURI)
+	assert.Nil(t, download.Data)
+}
+
+func TestFetchTorrent_InvalidTorrent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("<html>login required</html>"))
+	}))
+	defer server.Close()
+
+	_, err := FetchTorrent(context.Background(), http.DefaultClient, server.URL)
+	assert.Error(t, err)
+}
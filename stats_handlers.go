@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// statsSince returns the start of the period stats cover, from the days query
+// parameter (1 to 365, 30 by default)
+func statsSince(r *http.Request) (time.Time, error) {
+	days := 30
+	if value := r.URL.Query().Get("days"); value != "" {
+		var err error
+		days, err = strconv.Atoi(value)
+		if err != nil || days < 1 || days > 365 {
+			return time.Time{}, fmt.Errorf("days must be between 1 and 365")
+		}
+	}
+	return time.Now().UTC().AddDate(0, 0, -days), nil
+}
+
+// getLibraryStatsHandler returns an overview of the library, and its grabs,
+// imports and searches over the last days
+func (app *App) getLibraryStatsHandler(w http.ResponseWriter, r *http.Request) {
+	since, err := statsSince(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	stats, err := app.statsRepo.GetLibraryStats(since)
+	if err != nil {
+		log.Printf("Error getting library stats: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(stats); err != nil {
+		log.Printf("Error encoding library stats: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// getSearchStatsHandler returns how long searches took across the library,
+// overall and per indexer, over the last days
+func (app *App) getSearchStatsHandler(w http.ResponseWriter, r *http.Request) {
+	since, err := statsSince(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	stats, err := app.searchRunRepo.GetStats(since)
+	if err != nil {
+		log.Printf("Error getting search stats: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(stats); err != nil {
+		log.Printf("Error encoding search stats: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"media/database"
+	"media/models"
+	"media/repository"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetLibraryStatsHandler(t *testing.T) {
+	testDB, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	defer func() {
+		if err := testDB.Close(); err != nil {
+			t.Logf("Failed to close test database: %v", err)
+		}
+	}()
+	testDB.SetMaxOpenConns(1)
+	if err := testDB.InitSchema(); err != nil {
+		t.Fatalf("Failed to initialize test schema: %v", err)
+	}
+
+	movieRepo := repository.NewMovieRepository(testDB)
+	movieEventRepo := repository.NewMovieEventRepository(testDB)
+	app := &App{statsRepo: repository.NewStatsRepository(testDB)}
+
+	movie := &models.Movie{Title: "Heat", Year: 1995, Status: models.StatusDownloading}
+	assert.NoError(t, movieRepo.Create(movie))
+
+	// One grab this week and one a month and a half ago
+	for _, at := range []time.Time{time.Now().Add(-time.Hour), time.Now().AddDate(0, 0, -45)} {
+		details, err := json.Marshal(models.StatusChangeDetails{OldStatus: models.StatusSearching,
+			NewStatus: models.StatusDownloading, Indexer: "YTS"})
+		assert.NoError(t, err)
+		assert.NoError(t, movieEventRepo.Save(&models.MovieEvent{MovieID: movie.ID,
+			Type: models.EventStatusChanged, CreatedAt: at, Details: details,
+			DetailsVersion: models.EventDetailsVersion}))
+	}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/v1/stats", app.getLibraryStatsHandler).Methods("GET")
+	get := func(query string) (*httptest.ResponseRecorder, models.LibraryStats) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/stats"+query, nil))
+		var stats models.LibraryStats
+		if rr.Code == http.StatusOK {
+			assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &stats))
+		}
+		return rr, stats
+	}
+
+	// The last 30 days by default
+	rr, stats := get("")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, 1, stats.Movies)
+	assert.Equal(t, []models.IndexerGrabStats{{Indexer: "YTS", Grabs: 1}}, stats.Indexers)
+	assert.WithinDuration(t, time.Now().AddDate(0, 0, -30), stats.Since, time.Minute)
+
+	rr, stats = get("?days=60")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, []models.IndexerGrabStats{{Indexer: "YTS", Grabs: 2}}, stats.Indexers)
+
+	for _, query := range []string{"?days=0", "?days=366", "?days=week"} {
+		rr, _ = get(query)
+		assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+	}
+}
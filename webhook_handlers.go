@@ -0,0 +1,233 @@
+package main
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"media/models"
+
+	"github.com/gorilla/mux"
+)
+
+// validateWebhook normalizes a webhook, returning a message for the client if
+// it is incomplete or subscribes to unknown events. Webhooks without a secret
+// are given a random one.
+func validateWebhook(webhook *models.Webhook) string {
+	webhook.Name = strings.TrimSpace(webhook.Name)
+	webhook.URL = strings.TrimSpace(webhook.URL)
+
+	if webhook.Name == "" {
+		return "name is required"
+	}
+	if parsed, err := url.Parse(webhook.URL); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "url must be an http or https URL"
+	}
+	for _, eventType := range webhook.Events {
+		if strings.TrimSpace(string(eventType)) == "" {
+			return "events must not be empty"
+		}
+		if !eventType.Known() {
+			return fmt.Sprintf("unknown event type %q", eventType)
+		}
+	}
+
+	if webhook.Secret == "" {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return "failed to generate a secret"
+		}
+		webhook.Secret = hex.EncodeToString(secret)
+	}
+	return ""
+}
+
+// getWebhooksHandler lists the webhooks, without their secrets
+func (app *App) getWebhooksHandler(w http.ResponseWriter, _ *http.Request) {
+	webhooks, err := app.webhookRepo.GetAll()
+	if err != nil {
+		log.Printf("Error getting webhooks: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	for i := range webhooks {
+		webhooks[i].Redact()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(webhooks); err != nil {
+		log.Printf("Error encoding webhooks: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// createWebhookHandler adds a webhook. The response is the only one that
+// includes its secret.
+func (app *App) createWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	webhook := models.Webhook{Enabled: true}
+	if err := json.NewDecoder(r.Body).Decode(&webhook); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if message := validateWebhook(&webhook); message != "" {
+		http.Error(w, message, http.StatusBadRequest)
+		return
+	}
+
+	if err := app.webhookRepo.Create(&webhook); err != nil {
+		log.Printf("Error creating webhook: %v", err)
+		http.Error(w, "Failed to create webhook", http.StatusInternalServerError)
+		return
+	}
+	webhook.HasSecret = true
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(webhook); err != nil {
+		log.Printf("Error encoding webhook response: %v", err)
+	}
+}
+
+// updateWebhookHandler changes a webhook. Its secret is kept unless a new one
+// is given, and is not returned.
+func (app *App) updateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid webhook ID", http.StatusBadRequest)
+		return
+	}
+
+	webhook, err := app.webhookRepo.GetByID(id)
+	if err != nil {
+		http.Error(w, "Webhook not found", http.StatusNotFound)
+		return
+	}
+
+	// Fields left out of the request keep their current values
+	if err := json.NewDecoder(r.Body).Decode(webhook); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	webhook.ID = id
+	if message := validateWebhook(webhook); message != "" {
+		http.Error(w, message, http.StatusBadRequest)
+		return
+	}
+
+	if err := app.webhookRepo.Update(webhook); err != nil {
+		log.Printf("Error updating webhook: %v", err)
+		http.Error(w, "Failed to update webhook", http.StatusInternalServerError)
+		return
+	}
+	webhook.Redact()
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(webhook); err != nil {
+		log.Printf("Error encoding webhook response: %v", err)
+	}
+}
+
+func (app *App) deleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid webhook ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := app.webhookRepo.Delete(id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Webhook not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error deleting webhook: %v", err)
+		http.Error(w, "Failed to delete webhook", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getWebhookDeliveriesHandler lists the latest deliveries of a webhook
+func (app *App) getWebhookDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid webhook ID", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := app.webhookRepo.GetByID(id); err != nil {
+		http.Error(w, "Webhook not found", http.StatusNotFound)
+		return
+	}
+
+	limit := 50
+	if value := r.URL.Query().Get("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > 500 {
+			http.Error(w, "limit must be between 1 and 500", http.StatusBadRequest)
+			return
+		}
+	}
+
+	deliveries, err := app.webhookRepo.GetDeliveries(id, limit)
+	if err != nil {
+		log.Printf("Error getting webhook deliveries: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(deliveries); err != nil {
+		log.Printf("Error encoding webhook deliveries: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// redeliverWebhookHandler sends an earlier delivery's payload again and
+// returns the new delivery
+func (app *App) redeliverWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid webhook ID", http.StatusBadRequest)
+		return
+	}
+	deliveryID, err := strconv.Atoi(mux.Vars(r)["delivery_id"])
+	if err != nil {
+		http.Error(w, "Invalid delivery ID", http.StatusBadRequest)
+		return
+	}
+
+	original, err := app.webhookRepo.GetDelivery(deliveryID)
+	if err != nil || original.WebhookID != id {
+		http.Error(w, "Delivery not found", http.StatusNotFound)
+		return
+	}
+
+	delivery, err := app.webhooks.Redeliver(r.Context(), original)
+	if err != nil {
+		log.Printf("Error redelivering webhook delivery %d: %v", deliveryID, err)
+		http.Error(w, "Failed to redeliver webhook", http.StatusInternalServerError)
+		return
+	}
+
+	status := http.StatusCreated
+	if delivery.Status != models.DeliverySucceeded {
+		status = http.StatusBadGateway
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(delivery); err != nil {
+		log.Printf("Error encoding webhook delivery response: %v", err)
+	}
+}
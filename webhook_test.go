@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"media/database"
+	"media/models"
+	"media/repository"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookHandlers(t *testing.T) {
+	testDB, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	defer func() {
+		if err := testDB.Close(); err != nil {
+			t.Logf("Failed to close test database: %v", err)
+		}
+	}()
+	testDB.SetMaxOpenConns(1)
+	if err := testDB.InitSchema(); err != nil {
+		t.Fatalf("Failed to initialize test schema: %v", err)
+	}
+
+	webhookRepo := repository.NewWebhookRepository(testDB)
+	app := &App{webhookRepo: webhookRepo}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/v1/webhooks", app.getWebhooksHandler).Methods("GET")
+	router.HandleFunc("/api/v1/webhooks", app.createWebhookHandler).Methods("POST")
+	router.HandleFunc("/api/v1/webhooks/{id}", app.updateWebhookHandler).Methods("PUT")
+	router.HandleFunc("/api/v1/webhooks/{id}", app.deleteWebhookHandler).Methods("DELETE")
+
+	send := func(method, url, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(method, url, strings.NewReader(body)))
+		return rr
+	}
+
+	// Event types must exist, so a typo is not a subscription that never fires
+	rr := send(http.MethodPost, "/api/v1/webhooks",
+		`{"name":"Home","url":"https://example.com/hook","events":["imported","downloaded"]}`)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "downloaded")
+
+	// The secret is returned once, when the webhook is created
+	rr = send(http.MethodPost, "/api/v1/webhooks",
+		`{"name":"Home","url":"https://example.com/hook","events":["imported"]}`)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	var created models.Webhook
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &created))
+	assert.Len(t, created.Secret, 64)
+	assert.True(t, created.HasSecret)
+
+	rr = send(http.MethodGet, "/api/v1/webhooks", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NotContains(t, rr.Body.String(), created.Secret)
+	var listed []models.Webhook
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &listed))
+	if assert.Len(t, listed, 1) {
+		assert.Empty(t, listed[0].Secret)
+		assert.True(t, listed[0].HasSecret)
+	}
+
+	// Updating keeps the secret without returning it
+	url := fmt.Sprintf("/api/v1/webhooks/%d", created.ID)
+	rr = send(http.MethodPut, url, `{"enabled":false}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NotContains(t, rr.Body.String(), created.Secret)
+	stored, err := webhookRepo.GetByID(created.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, created.Secret, stored.Secret)
+	assert.False(t, stored.Enabled)
+
+	assert.Equal(t, http.StatusNoContent, send(http.MethodDelete, url, "").Code)
+	assert.Equal(t, http.StatusNotFound, send(http.MethodDelete, url, "").Code)
+
+	// Database failures are not reported as missing webhooks
+	assert.NoError(t, testDB.Close())
+	assert.Equal(t, http.StatusInternalServerError, send(http.MethodDelete, url, "").Code)
+}